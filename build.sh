@@ -4,6 +4,9 @@ set -e
 VERSION=${VERSION:-"0.1.0"}
 APP_NAME="binance-pivot-monitor"
 OUTPUT_DIR="dist"
+PKG="example.com/binance-pivot-monitor/internal/httpapi"
+GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
 
 mkdir -p "$OUTPUT_DIR"
 
@@ -29,7 +32,7 @@ for PLATFORM in "${PLATFORMS[@]}"; do
   echo "  Building $GOOS/$GOARCH..."
   
   CGO_ENABLED=0 GOOS=$GOOS GOARCH=$GOARCH go build \
-    -ldflags="-s -w -X main.Version=$VERSION" \
+    -ldflags="-s -w -X $PKG.Version=$VERSION -X $PKG.GitCommit=$GIT_COMMIT -X $PKG.BuildDate=$BUILD_DATE" \
     -o "$OUTPUT_DIR/$OUTPUT_NAME" \
     ./cmd/server
 done
@@ -8,15 +8,18 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/backoff"
 	"example.com/binance-pivot-monitor/internal/binance"
 	"example.com/binance-pivot-monitor/internal/httpapi"
 	"example.com/binance-pivot-monitor/internal/kline"
 	"example.com/binance-pivot-monitor/internal/monitor"
+	"example.com/binance-pivot-monitor/internal/offline"
 	"example.com/binance-pivot-monitor/internal/pattern"
 	"example.com/binance-pivot-monitor/internal/pivot"
 	"example.com/binance-pivot-monitor/internal/ranking"
@@ -30,53 +33,185 @@ func main() {
 	dataDir := flag.String("data-dir", "data", "")
 	corsOrigins := flag.String("cors-origins", "*", "")
 	restBase := flag.String("binance-rest", "https://fapi.binance.com", "")
+	coinRestBase := flag.String("binance-coin-rest", binance.DefaultCoinBaseURL, "")
+	restTimeout := flag.Duration("rest-timeout", binance.DefaultRESTTimeout, "")
+	coinMargined := flag.Bool("coin-margined", false, "")
 	refreshWorkers := flag.Int("refresh-workers", 16, "")
+	levelMergeEpsilon := flag.Float64("level-merge-epsilon", 0, "")
+	pivotMaxSymbols := flag.Int("pivot-max-symbols", 0, "")
+	pivotRetryMin := flag.Duration("pivot-retry-min", pivot.DefaultRetryMin, "")
+	pivotRetryMax := flag.Duration("pivot-retry-max", pivot.DefaultRetryMax, "")
+	pivotHistory := flag.Int("pivot-history", pivot.DefaultHistorySize, "")
 	monitorHeartbeat := flag.Duration("monitor-heartbeat", 0, "")
+	tickerHeartbeat := flag.Duration("ticker-heartbeat", 0, "")
+	maxClockSkew := flag.Duration("max-clock-skew", monitor.DefaultMaxClockSkew, "")
+	maxDecompressed := flag.Int64("max-decompressed-bytes", monitor.DefaultMaxDecompressedBytes, "")
 	historyMax := flag.Int("history-max", 20000, "")
 	historyFile := flag.String("history-file", "signals/history.jsonl", "")
+	historyRotateSize := flag.Int64("history-rotate-size", 0, "")
+	historyRotateDaily := flag.Bool("history-rotate-daily", false, "")
+	historyFormat := flag.String("history-format", "json", "")
+	historyFlushInterval := flag.Duration("history-flush-interval", 0, "")
 	tickerBatchInterval := flag.Duration("ticker-batch-interval", 500*time.Millisecond, "")
+	tickerMinChangePct := flag.Float64("ticker-min-change-pct", 0, "")
+	offlineMode := flag.Bool("offline", false, "")
+	replica := flag.Bool("replica", false, "")
+	replicaPoll := flag.Duration("replica-poll", 2*time.Second, "")
+	replayFile := flag.String("replay-file", "", "")
+	replaySpeed := flag.Float64("replay-speed", 1, "")
+	replayLoop := flag.Bool("replay-loop", false, "")
+	jsonCaseFlag := flag.String("json-case", "snake", "")
+	priceDecimalString := flag.Bool("price-decimal-string", false, "")
+	reconnectMin := flag.Duration("reconnect-min", backoff.DefaultMin, "")
+	reconnectMax := flag.Duration("reconnect-max", backoff.DefaultMax, "")
+	reconnectJitter := flag.Float64("reconnect-jitter", backoff.DefaultJitter, "")
+	debugMode := flag.Bool("debug", false, "")
+	maxSSEConns := flag.Int("max-sse-connections", 0, "")
+	sseWriteTimeout := flag.Duration("sse-write-timeout", httpapi.DefaultSSEWriteTimeout, "")
+	historyEnrichLimit := flag.Int("history-enrich-limit", httpapi.DefaultHistoryEnrichLimit, "")
+	historyEnrichTimeout := flag.Duration("history-enrich-timeout", httpapi.DefaultHistoryEnrichTimeout, "")
+	klineWarmup := flag.Bool("kline-warmup", false, "")
+	trendAwareLevels := flag.Bool("trend-aware-levels", false, "")
+	rearmBand := flag.Float64("rearm-band", 0, "")
+	touchTolerance := flag.Float64("touch-tolerance", 0, "")
+	confluencePct := flag.Float64("confluence-pct", 0, "")
+	walkWindow := flag.Duration("walk-window", 0, "")
+	watchMidPivots := flag.Bool("watch-mid-pivots", false, "")
+	activityDayOffset := flag.Duration("activity-day-offset", 0, "")
+	activeHoursFlag := flag.String("active-hours", "", "")
+	activeHoursTZ := flag.String("active-hours-tz", "UTC", "")
+	detectorPresetFlag := flag.String("detector-preset", pattern.PresetBalanced, "")
 	flag.Parse()
 
+	reconnect := backoff.Policy{Min: *reconnectMin, Max: *reconnectMax, Jitter: *reconnectJitter}
+	if *reconnectJitter == 0 {
+		reconnect.Jitter = -1 // Explicit 0 disables jitter
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Read pattern recognition config from environment
+	// Read pattern recognition config from environment; the detector preset
+	// supplies the defaults of the detector settings
+	detectorPreset, err := pattern.ParsePreset(*detectorPresetFlag)
+	if err != nil {
+		log.Fatalf("invalid -detector-preset: %v", err)
+	}
+	presetConfig := pattern.PresetConfig(detectorPreset)
 	patternEnabled := getEnvBool("PATTERN_ENABLED", true)
 	klineCount := getEnvInt("KLINE_COUNT", 12)
 	klineInterval := getEnvDurationOrMinutes("KLINE_INTERVAL", 15*time.Minute)
-	patternMinConfidence := getEnvInt("PATTERN_MIN_CONFIDENCE", 60) // Requirement 8: default 60
+	klineExtraIntervals := getEnvDurationsOrMinutes("KLINE_EXTRA_INTERVALS")
+	patternMinConfidence := getEnvInt("PATTERN_MIN_CONFIDENCE", presetConfig.MinConfidence) // Requirement 8: default 60
 	patternHistoryFile := os.Getenv("PATTERN_HISTORY_FILE")
 	if patternHistoryFile == "" {
 		patternHistoryFile = "patterns/history.jsonl" // Requirement 6.2: default path
 	}
-	patternCryptoMode := getEnvBool("PATTERN_CRYPTO_MODE", true)
+	patternCryptoMode := getEnvBool("PATTERN_CRYPTO_MODE", presetConfig.CryptoMode)
 	patternHistoryMax := getEnvInt("PATTERN_HISTORY_MAX", 1000) // Requirement 6.3: default 1000
+	patternHistoryMaxAge := getEnvDuration("PATTERN_HISTORY_MAX_AGE", 0)
+	patternMinConfidencePer := getEnvPatternInts("PATTERN_MIN_CONFIDENCE_PER_PATTERN")
+	patternTalibEnabled := getEnvPatternTypes("PATTERN_TALIB_PATTERNS")
+	patternMinVolume := getEnvFloat("PATTERN_MIN_VOLUME", 0)
+	patternMinKlines := getEnvInt("PATTERN_MIN_KLINES", 0)
+	patternDetectionWindow := getEnvInt("PATTERN_DETECTION_WINDOW", 0)
+	patternDojiRatio := getEnvFloat("PATTERN_DOJI_BODY_RATIO", presetConfig.DojiBodyRatio)
+	patternWorkers := getEnvInt("PATTERN_WORKERS", monitor.DefaultPatternWorkers)
+	patternCacheSize := getEnvInt("PATTERN_CACHE_SIZE", 0)
+	patternRawScores := getEnvBool("PATTERN_RAW_SCORES", false)
+	patternTalibScale := getEnvFloat("PATTERN_TALIB_CONFIDENCE_SCALE", pattern.DefaultTalibConfidenceScale)
+	patternStructure := getEnvBool("PATTERN_STRUCTURE", false)
+	patternPivotProximityPct := getEnvFloat("PATTERN_PIVOT_PROXIMITY_PCT", 0)
+	patternPivotBoost := getEnvInt("PATTERN_PIVOT_BOOST", monitor.DefaultPivotConfidenceBoost)
+	patternSSEMinConfidence := getEnvInt("PATTERN_SSE_MIN_CONFIDENCE", 0)
+	combinerCleanupInterval := getEnvDuration("COMBINER_CLEANUP_INTERVAL", time.Minute)
+	combinerMaxPerSymbol := getEnvInt("COMBINER_MAX_PER_SYMBOL", signalpkg.DefaultCombinerMaxPerSymbol)
+	klineVolumeSource := strings.ToLower(strings.TrimSpace(os.Getenv("KLINE_VOLUME_SOURCE")))
+	klineVolumeSymbols := getEnvList("KLINE_VOLUME_SYMBOLS")
+	watchSymbols := getEnvList("WATCH_SYMBOLS")
+	symbolAliases := getEnvSymbolAliases("SYMBOL_ALIASES")
 
 	// Log configuration
-	log.Printf("config: addr=%s data-dir=%s", *addr, *dataDir)
-	log.Printf("config: pattern_enabled=%v kline_count=%d kline_interval=%v", patternEnabled, klineCount, klineInterval)
-	log.Printf("config: pattern_min_confidence=%d pattern_crypto_mode=%v pattern_history_max=%d", patternMinConfidence, patternCryptoMode, patternHistoryMax)
-	log.Printf("config: pattern_history_file=%s", patternHistoryFile)
+	log.Printf("config: addr=%s data-dir=%s offline=%v", *addr, *dataDir, *offlineMode)
+	if *replica {
+		log.Printf("config: replica=true replica_poll=%v", *replicaPoll)
+	}
+	if *coinMargined {
+		log.Printf("config: coin_margined=true binance_coin_rest=%s", *coinRestBase)
+	}
+	log.Printf("config: pattern_enabled=%v kline_count=%d kline_interval=%v pattern_detection_window=%d", patternEnabled, klineCount, klineInterval, patternDetectionWindow)
+	if len(klineExtraIntervals) > 0 {
+		log.Printf("config: kline_extra_intervals=%v", klineExtraIntervals)
+	}
+	log.Printf("config: detector_preset=%s pattern_min_confidence=%d pattern_crypto_mode=%v pattern_history_max=%d", detectorPreset, patternMinConfidence, patternCryptoMode, patternHistoryMax)
+	log.Printf("config: pattern_history_file=%s pattern_history_max_age=%v", patternHistoryFile, patternHistoryMaxAge)
+	log.Printf("config: pattern_min_volume=%g pattern_workers=%d pattern_min_klines=%d pattern_doji_body_ratio=%g pattern_talib_confidence_scale=%g", patternMinVolume, patternWorkers, patternMinKlines, patternDojiRatio, patternTalibScale)
+	log.Printf("config: pattern_pivot_proximity_pct=%g pattern_pivot_boost=%d", patternPivotProximityPct, patternPivotBoost)
+	log.Printf("config: pattern_sse_min_confidence=%d pattern_structure=%v", patternSSEMinConfidence, patternStructure)
+	if len(patternMinConfidencePer) > 0 {
+		log.Printf("config: pattern_min_confidence_per_pattern=%v", patternMinConfidencePer)
+	}
+	if patternTalibEnabled != nil {
+		log.Printf("config: pattern_talib_patterns=%v", patternTalibEnabled)
+	}
+	log.Printf("config: kline_volume_source=%q kline_volume_symbols=%d", klineVolumeSource, len(klineVolumeSymbols))
+	if len(symbolAliases) > 0 {
+		log.Printf("config: symbol_aliases=%d", len(symbolAliases))
+	}
 
 	store := pivot.NewStore()
+	store.SetHistorySize(*pivotHistory)
 	rest := binance.NewRESTClient(*restBase)
+	rest.Timeout = *restTimeout
+	rest.CoinBaseURL = *coinRestBase
 	refresher := pivot.NewRefresher(*dataDir, store, rest)
 	refresher.Workers = *refreshWorkers
-	refresher.LoadFromDisk()
+	refresher.CoinMargined = *coinMargined
+	refresher.LevelMergeEpsilon = *levelMergeEpsilon
+	refresher.RetryMin = *pivotRetryMin
+	refresher.RetryMax = *pivotRetryMax
 
-	go func() {
-		ctxInit, cancel := context.WithTimeout(ctx, 15*time.Minute)
-		defer cancel()
-
-		if snap, _ := store.Snapshot(pivot.PeriodDaily); snap == nil {
-			_ = refresher.Refresh(ctxInit, pivot.PeriodDaily)
-		}
-		if snap, _ := store.Snapshot(pivot.PeriodWeekly); snap == nil {
-			_ = refresher.Refresh(ctxInit, pivot.PeriodWeekly)
+	// Ticker store (also used to filter pattern detection by liquidity and
+	// to rank symbols when -pivot-max-symbols limits the refresh)
+	tickerStore := ticker.NewStore()
+	if *pivotMaxSymbols > 0 {
+		refresher.MaxSymbols = *pivotMaxSymbols
+		refresher.SymbolVolume = func(symbol string) float64 {
+			if t, ok := tickerStore.Get(symbol); ok {
+				return t.QuoteVolume
+			}
+			return 0
 		}
-	}()
+		log.Printf("config: pivot refresh limited to top %d symbols by quote volume", *pivotMaxSymbols)
+	}
 
-	refresher.StartScheduler(ctx)
+	// Offline mode: deterministic synthetic data, no REST or websocket calls
+	var sim *offline.Simulator
+	if *offlineMode {
+		sim = offline.NewSimulator()
+		sim.SeedPivots(store, time.Now().UTC())
+		log.Printf("offline mode: seeded pivots for %d symbols", len(sim.BasePrices))
+	} else if *replica {
+		// Replica mode: pivots come from the primary's files, never from Binance
+		refresher.LoadFromDisk()
+		go refresher.Follow(ctx, *replicaPoll)
+	} else {
+		refresher.LoadFromDisk()
+
+		go func() {
+			ctxInit, cancel := context.WithTimeout(ctx, 15*time.Minute)
+			defer cancel()
+
+			if snap, _ := store.Snapshot(pivot.PeriodDaily); snap == nil {
+				_ = refresher.Refresh(ctxInit, pivot.PeriodDaily)
+			}
+			if snap, _ := store.Snapshot(pivot.PeriodWeekly); snap == nil {
+				_ = refresher.Refresh(ctxInit, pivot.PeriodWeekly)
+			}
+		}()
+
+		refresher.StartScheduler(ctx)
+	}
 
 	signalBroker := sse.NewBroker[signalpkg.Signal]()
 	history := signalpkg.NewHistory(*historyMax)
@@ -85,14 +220,75 @@ func main() {
 		if !filepath.IsAbs(path) {
 			path = filepath.Join(*dataDir, path)
 		}
-		if err := history.EnablePersistence(path); err != nil {
-			log.Fatalf("history persistence init error: %v", err)
+		if *replica {
+			go history.Follow(ctx, path, *replicaPoll, signalBroker.Publish)
+		} else {
+			format, err := signalpkg.ParseFormat(*historyFormat)
+			if err != nil {
+				log.Fatalf("invalid -history-format: %v", err)
+			}
+			history.SetRotation(*historyRotateSize, *historyRotateDaily)
+			history.SetFormat(format)
+			history.SetFlushInterval(*historyFlushInterval)
+			if err := history.EnablePersistence(path); err != nil {
+				log.Fatalf("history persistence init error: %v", err)
+			}
+
+			// Write buffered appends; Close flushes the rest on shutdown
+			if *historyFlushInterval > 0 {
+				log.Printf("config: history_flush_interval=%v", *historyFlushInterval)
+				go func() {
+					ticker := time.NewTicker(*historyFlushInterval)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-ctx.Done():
+							return
+						case <-ticker.C:
+							history.Flush()
+						}
+					}
+				}()
+			}
+
+			// Retry failed appends even when no new signals arrive
+			go func() {
+				ticker := time.NewTicker(30 * time.Second)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						history.FlushPending()
+					}
+				}
+			}()
 		}
 	}
+	if *replayFile != "" {
+		// Replay recorded signals over SSE for UI development
+		replayer := &signalpkg.Replayer{Path: *replayFile, Broker: signalBroker, Speed: *replaySpeed, Loop: *replayLoop}
+		go func() {
+			if err := replayer.Run(ctx); err != nil {
+				log.Printf("signal replay error: %v", err)
+			}
+		}()
+	}
 	cooldown := signalpkg.NewCooldown(30 * time.Minute)
+	cooldownScope, err := monitor.ParseCooldownScope(os.Getenv("COOLDOWN_SCOPE"))
+	if err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+	log.Printf("config: cooldown_scope=%s", cooldownScope)
+	jsonCase, err := httpapi.ParseJSONCase(*jsonCaseFlag)
+	if err != nil {
+		log.Fatalf("config error: %v", err)
+	}
 
 	// Initialize pattern recognition components (if enabled)
 	var klineStore *kline.Store
+	var extraKlineStores []*kline.Store
 	var patternDetector *pattern.Detector
 	var patternHistory *pattern.History
 	var patternBroker *sse.Broker[pattern.Signal]
@@ -100,14 +296,31 @@ func main() {
 
 	if patternEnabled {
 		klineStore = kline.NewStore(klineInterval, klineCount)
+		for _, iv := range klineExtraIntervals {
+			if iv == klineInterval {
+				continue
+			}
+			extraKlineStores = append(extraKlineStores, kline.NewStore(iv, klineCount))
+		}
 		patternDetector = pattern.NewDetector(pattern.DetectorConfig{
 			MinConfidence:      patternMinConfidence,
-			HighEfficiencyOnly: false,
+			HighEfficiencyOnly: presetConfig.HighEfficiencyOnly,
 			CryptoMode:         patternCryptoMode,
-			GapThreshold:       0.001,
+			GapThreshold:       presetConfig.GapThreshold,
+
+			MinConfidencePerPattern: patternMinConfidencePer,
+			EnabledTalibPatterns:    patternTalibEnabled,
+			MinKlines:               patternMinKlines,
+			DojiBodyRatio:           patternDojiRatio,
+			CacheSize:               patternCacheSize,
+			RawScores:               patternRawScores,
+			TalibConfidenceScale:    patternTalibScale,
+			StructurePatterns:       patternStructure,
 		})
 		patternBroker = sse.NewBroker[pattern.Signal]()
 		signalCombiner = signalpkg.NewCombiner(15 * time.Minute)
+		signalCombiner.SetMaxPerSymbol(combinerMaxPerSymbol)
+		signalCombiner.StartCleaner(ctx, combinerCleanupInterval)
 
 		// Initialize pattern history
 		var err error
@@ -115,16 +328,65 @@ func main() {
 		if !filepath.IsAbs(histPath) {
 			histPath = filepath.Join(*dataDir, histPath)
 		}
-		patternHistory, err = pattern.NewHistory(histPath, patternHistoryMax)
-		if err != nil {
-			log.Printf("pattern history init warning: %v (continuing without persistence)", err)
-			patternHistory, _ = pattern.NewHistory("", 10000)
+		if *replica {
+			patternHistory, _ = pattern.NewHistory("", patternHistoryMax)
+			patternHistory.MaxAge = patternHistoryMaxAge
+			go patternHistory.Follow(ctx, histPath, *replicaPoll, func(sig pattern.Signal) {
+				if sig.Confidence >= patternSSEMinConfidence {
+					patternBroker.Publish(sig)
+				}
+			})
+		} else {
+			patternHistory, err = pattern.NewHistory(histPath, patternHistoryMax)
+			if err != nil {
+				log.Printf("pattern history init warning: %v (continuing without persistence)", err)
+				patternHistory, _ = pattern.NewHistory("", 10000)
+			}
+			patternHistory.MaxAge = patternHistoryMaxAge
+		}
+		if patternHistoryMaxAge > 0 {
+			// Expire rarely-detected patterns even when no new signals arrive
+			go func() {
+				ticker := time.NewTicker(10 * time.Minute)
+				defer ticker.Stop()
+				patternHistory.Prune()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if n := patternHistory.Prune(); n > 0 {
+							log.Printf("pattern history: pruned %d expired signals", n)
+						}
+					}
+				}
+			}()
 		}
 
 		// Start kline close timer for synchronized closes at interval boundaries
 		klineStore.StartCloseTimer()
+		for _, ks := range extraKlineStores {
+			ks.StartCloseTimer()
+		}
 
-		log.Printf("pattern recognition enabled: kline_count=%d interval=%v", klineCount, klineInterval)
+		// Optional real volume from aggTrade streams (default: mark price only, no volume)
+		if klineVolumeSource == "aggtrade" && !*offlineMode && !*replica {
+			go func() {
+				symbols := klineVolumeSymbols
+				if len(symbols) == 0 {
+					symbols = waitForPivotSymbols(ctx, store)
+				}
+				if len(symbols) == 0 {
+					return
+				}
+				log.Printf("kline volume source: aggtrade symbols=%d", len(symbols))
+				feed := monitor.NewAggTradeFeed(klineStore, symbols)
+				feed.Backoff = reconnect
+				feed.Run(ctx)
+			}()
+		}
+
+		log.Printf("pattern recognition enabled: kline_count=%d interval=%v extra_timeframes=%d", klineCount, klineInterval, len(extraKlineStores))
 	}
 
 	// Create monitor with full config
@@ -133,58 +395,168 @@ func main() {
 		Broker:          signalBroker,
 		History:         history,
 		Cooldown:        cooldown,
+		CooldownScope:   cooldownScope,
 		KlineStore:      klineStore,
 		PatternDetector: patternDetector,
 		PatternHistory:  patternHistory,
 		PatternBroker:   patternBroker,
 		SignalCombiner:  signalCombiner,
+
+		ExtraKlineStores: extraKlineStores,
+		TickerStore:      tickerStore,
+		MinPatternVolume: patternMinVolume,
+		PatternWorkers:   patternWorkers,
+		DetectionWindow:  patternDetectionWindow,
 	})
 	mon.HeartbeatEvery = *monitorHeartbeat
-	go mon.Run(ctx)
+	mon.MaxClockSkew = *maxClockSkew
+	if *maxClockSkew == 0 {
+		mon.MaxClockSkew = -1 // Explicit 0 disables the check
+	}
+	mon.MaxDecompressedBytes = *maxDecompressed
+	mon.TrendAwareLevels = *trendAwareLevels
+	mon.RearmBand = *rearmBand
+	mon.TouchTolerance = *touchTolerance
+	mon.ConfluencePct = *confluencePct
+	mon.WalkWindow = *walkWindow
+	mon.WatchMidPivots = *watchMidPivots
+	mon.ActivityDayOffset = *activityDayOffset
+	activeHours, err := monitor.ParseActiveHours(*activeHoursFlag, *activeHoursTZ)
+	if err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+	if activeHours != nil {
+		mon.ActiveHours = activeHours
+		log.Printf("config: active_hours=%s", activeHours)
+	}
+	if len(watchSymbols) > 0 {
+		if len(watchSymbols) > binance.MaxStreamsPerConn {
+			log.Fatalf("config error: WATCH_SYMBOLS lists %d symbols (max %d)", len(watchSymbols), binance.MaxStreamsPerConn)
+		}
+		mon.WatchSymbols = watchSymbols
+		log.Printf("config: watch_symbols=%s", strings.Join(watchSymbols, ","))
+	}
+	if *coinMargined {
+		mon.CoinSymbols = func() []string { return coinSymbols(store) }
+	}
+	mon.PivotProximityPct = patternPivotProximityPct
+	mon.PivotConfidenceBoost = patternPivotBoost
+	mon.PatternSSEMinConfidence = patternSSEMinConfidence
+	mon.Backoff = reconnect
 
 	// Ticker monitor
-	tickerStore := ticker.NewStore()
 	tickerMon := ticker.NewMonitor(tickerStore)
 	tickerMon.BatchInterval = *tickerBatchInterval
-	go tickerMon.Run(ctx)
+	tickerMon.MinTickerChangePct = *tickerMinChangePct
+	tickerMon.Backoff = reconnect
+	tickerMon.HeartbeatEvery = *tickerHeartbeat
+
+	// monDone is closed once the monitor and its pattern workers have stopped
+	monDone := make(chan struct{})
+	runMonitor := func() {
+		defer close(monDone)
+		mon.Run(ctx)
+	}
+
+	// warmupDone is closed once kline warm-up has finished or is not needed
+	warmupDone := make(chan struct{})
+
+	if *replica {
+		log.Printf("replica mode: monitors disabled, following %s", *dataDir)
+		close(monDone)
+		close(warmupDone)
+	} else if sim != nil {
+		if klineStore != nil {
+			sim.SeedKlines(klineStore, klineInterval, klineCount, time.Now().UTC())
+		}
+		for _, ks := range extraKlineStores {
+			sim.SeedKlines(ks, ks.Interval(), klineCount, time.Now().UTC())
+		}
+		sim.OnTicker = tickerMon.Apply
+		mon.PriceSource = sim
+		close(warmupDone)
+		go tickerMon.RunBatches(ctx)
+		go runMonitor()
+	} else {
+		monitorAfterWarmup := false
+		if *klineWarmup && klineStore != nil {
+			warmup := func(symbols []string) {
+				ctxWarm, cancel := context.WithTimeout(ctx, 5*time.Minute)
+				defer cancel()
+				for _, ks := range append([]*kline.Store{klineStore}, extraKlineStores...) {
+					n := monitor.WarmupKlines(ctxWarm, rest, ks, symbols, klineCount, *refreshWorkers)
+					log.Printf("kline warmup %s: seeded %d/%d symbols", ks.Timeframe(), n, len(symbols))
+				}
+				close(warmupDone)
+			}
+			// Seed before live updates when pivots are already on disk,
+			// in the background so the HTTP server starts meanwhile;
+			// otherwise wait for the first refresh in the background.
+			if symbols := pivotSymbols(store); len(symbols) > 0 {
+				monitorAfterWarmup = true
+				go func() {
+					warmup(symbols)
+					runMonitor()
+				}()
+			} else {
+				go func() {
+					if symbols := waitForPivotSymbols(ctx, store); len(symbols) > 0 {
+						warmup(symbols)
+					}
+				}()
+			}
+		} else {
+			close(warmupDone)
+		}
+		go tickerMon.Run(ctx)
+		if !monitorAfterWarmup {
+			go runMonitor()
+		}
+	}
 
 	// Ranking monitor
 	rankingEnabled := getEnvBool("RANKING_ENABLED", true)
 	var rankingStore *ranking.Store
 	if rankingEnabled {
 		rankingStore = ranking.NewStore(*dataDir, ranking.DefaultMaxAge)
+		rankingStore.Compress = getEnvBool("RANKING_COMPRESS", false)
+		rankingStore.ReadOnly = *replica
 		if err := rankingStore.Load(); err != nil {
 			log.Printf("ranking store load warning: %v", err)
 		}
 
-		sampler := ranking.NewSampler(tickerStore, rankingStore)
-		go sampler.Run(ctx)
+		// A replica serves the primary's last persisted rankings as loaded
+		if !*replica {
+			sampler := ranking.NewSampler(tickerStore, rankingStore)
+			go sampler.Run(ctx)
 
-		// Persist ranking data periodically
-		go func() {
-			ticker := time.NewTicker(5 * time.Minute)
-			defer ticker.Stop()
-			for {
-				select {
-				case <-ctx.Done():
-					// Final persist on shutdown
-					if err := rankingStore.Persist(); err != nil {
-						log.Printf("ranking store final persist error: %v", err)
-					}
-					return
-				case <-ticker.C:
-					if err := rankingStore.Persist(); err != nil {
-						log.Printf("ranking store persist error: %v", err)
+			// Persist ranking data periodically
+			go func() {
+				ticker := time.NewTicker(5 * time.Minute)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						// Final persist on shutdown
+						if err := rankingStore.Persist(); err != nil {
+							log.Printf("ranking store final persist error: %v", err)
+						}
+						return
+					case <-ticker.C:
+						if err := rankingStore.Persist(); err != nil {
+							log.Printf("ranking store persist error: %v", err)
+						}
 					}
 				}
-			}
-		}()
+			}()
 
-		log.Printf("ranking monitor enabled: sample_interval=5m retention=24h")
+			log.Printf("ranking monitor enabled: sample_interval=5m retention=24h")
+		}
 	}
 
 	api := httpapi.New(signalBroker, history, httpapi.ParseAllowedOrigins(*corsOrigins))
 	api.PivotStatus = refresher
+	api.PivotFiles = refresher
 	api.PivotStore = store
 	api.TickerStore = tickerStore
 	api.TickerMonitor = tickerMon
@@ -193,6 +565,45 @@ func main() {
 	api.KlineStore = klineStore
 	api.SignalCombiner = signalCombiner
 	api.RankingStore = rankingStore
+	api.SignalActivity = mon
+	if patternDetector != nil {
+		api.PatternSwitch = mon
+	}
+	api.JSONCase = jsonCase
+	api.PriceDecimalString = *priceDecimalString
+	api.MaxSSEConnections = *maxSSEConns
+	api.SSEWriteTimeout = *sseWriteTimeout
+	if *sseWriteTimeout == 0 {
+		api.SSEWriteTimeout = -1 // Explicit 0 disables the deadline
+	}
+	api.HistoryEnrichLimit = *historyEnrichLimit
+	if *historyEnrichLimit == 0 {
+		api.HistoryEnrichLimit = -1 // Explicit 0 disables the cap
+	}
+	api.HistoryEnrichTimeout = *historyEnrichTimeout
+	if *historyEnrichTimeout == 0 {
+		api.HistoryEnrichTimeout = -1 // Explicit 0 disables the budget
+	}
+	api.SymbolAliases = symbolAliases
+	api.Debug = *debugMode
+	if api.AdminToken = strings.TrimSpace(os.Getenv("ADMIN_TOKEN")); api.AdminToken != "" {
+		log.Printf("config: admin endpoints enabled")
+	}
+	api.Cooldown = cooldown
+
+	// Tell SSE clients once daily and weekly pivots (and warm-up) are in
+	go func() {
+		if !waitForPivots(ctx, store) {
+			return
+		}
+		select {
+		case <-warmupDone:
+		case <-ctx.Done():
+			return
+		}
+		api.MarkReady()
+		log.Printf("startup complete: pivots and kline warmup ready")
+	}()
 
 	srv := &http.Server{
 		Addr:              *addr,
@@ -200,7 +611,9 @@ func main() {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
 		ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -211,6 +624,19 @@ func main() {
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("http server error: %v", err)
 	}
+	<-shutdownDone
+
+	// Stop writers before closing the history files
+	<-monDone
+	if err := history.Close(); err != nil {
+		log.Printf("history close error: %v", err)
+	}
+	if patternHistory != nil {
+		if err := patternHistory.Close(); err != nil {
+			log.Printf("pattern history close error: %v", err)
+		}
+	}
+	log.Printf("shutdown complete")
 }
 
 // getEnvBool reads a boolean from environment variable.
@@ -223,6 +649,149 @@ func getEnvBool(key string, defaultVal bool) bool {
 	return v == "true" || v == "1" || v == "yes" || v == "on"
 }
 
+// getEnvList reads a comma-separated list from environment variable (upper-cased, empty items dropped).
+func getEnvList(key string) []string {
+	var out []string
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		p = strings.ToUpper(strings.TrimSpace(p))
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// getEnvPatternInts reads "pattern=value" pairs (comma-separated) from environment variable.
+// Invalid pairs are skipped.
+func getEnvPatternInts(key string) map[pattern.PatternType]int {
+	out := make(map[pattern.PatternType]int)
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok {
+			continue
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			continue
+		}
+		out[pattern.PatternType(strings.ToLower(strings.TrimSpace(name)))] = i
+	}
+	return out
+}
+
+// getEnvSymbolAliases reads "SYMBOL=alias" pairs (comma-separated) from
+// environment variable. Symbols are normalized; pairs with an empty side are
+// skipped. Returns nil when no pair is set.
+func getEnvSymbolAliases(key string) map[string]string {
+	var out map[string]string
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		sym, alias, ok := strings.Cut(strings.TrimSpace(p), "=")
+		sym, alias = binance.NormalizeSymbol(sym), strings.TrimSpace(alias)
+		if !ok || sym == "" || alias == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[sym] = alias
+	}
+	return out
+}
+
+// getEnvPatternTypes reads a comma-separated list of pattern types from environment variable.
+// Returns nil when unset (meaning all patterns).
+func getEnvPatternTypes(key string) []pattern.PatternType {
+	if strings.TrimSpace(os.Getenv(key)) == "" {
+		return nil
+	}
+	out := []pattern.PatternType{}
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			out = append(out, pattern.PatternType(p))
+		}
+	}
+	return out
+}
+
+// getEnvDurationsOrMinutes reads a comma-separated list of durations from environment variable.
+// Each item accepts "5m" format or a plain number of minutes; invalid items are skipped.
+func getEnvDurationsOrMinutes(key string) []time.Duration {
+	var out []time.Duration
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(p); err == nil && d > 0 {
+			out = append(out, d)
+		} else if mins, err := strconv.Atoi(p); err == nil && mins > 0 {
+			out = append(out, time.Duration(mins)*time.Minute)
+		}
+	}
+	return out
+}
+
+// pivotSymbols returns the sorted symbols with daily pivots, or nil if none are loaded.
+func pivotSymbols(store *pivot.Store) []string {
+	snap, _ := store.Snapshot(pivot.PeriodDaily)
+	if snap == nil || len(snap.Symbols) == 0 {
+		return nil
+	}
+	symbols := make([]string, 0, len(snap.Symbols))
+	for sym := range snap.Symbols {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// coinSymbols returns the COIN-margined symbols with daily pivots (sorted).
+func coinSymbols(store *pivot.Store) []string {
+	var symbols []string
+	for _, sym := range pivotSymbols(store) {
+		if binance.ContractType(sym) == binance.ContractCoin {
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
+
+// waitForPivotSymbols blocks until daily pivots are loaded and returns their symbols (sorted).
+func waitForPivotSymbols(ctx context.Context, store *pivot.Store) []string {
+	t := time.NewTicker(5 * time.Second)
+	defer t.Stop()
+	for {
+		if symbols := pivotSymbols(store); len(symbols) > 0 {
+			return symbols
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// waitForPivots blocks until both daily and weekly pivots are loaded.
+// It returns false if ctx is done first.
+func waitForPivots(ctx context.Context, store *pivot.Store) bool {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		daily, _ := store.Snapshot(pivot.PeriodDaily)
+		weekly, _ := store.Snapshot(pivot.PeriodWeekly)
+		if daily != nil && weekly != nil {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+}
+
 // getEnvInt reads an integer from environment variable.
 func getEnvInt(key string, defaultVal int) int {
 	v := os.Getenv(key)
@@ -235,6 +804,18 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+// getEnvFloat reads a float from environment variable.
+func getEnvFloat(key string, defaultVal float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return f
+	}
+	return defaultVal
+}
+
 // getEnvDuration reads a duration from environment variable.
 func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	v := os.Getenv(key)
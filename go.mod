@@ -2,10 +2,10 @@ module example.com/binance-pivot-monitor
 
 go 1.22
 
-require github.com/gorilla/websocket v1.5.1
-
 require (
-	github.com/iwat/talib-cdl-go v1.0.0 // indirect
-	github.com/leanovate/gopter v0.2.11 // indirect
-	golang.org/x/net v0.17.0 // indirect
+	github.com/gorilla/websocket v1.5.1
+	github.com/iwat/talib-cdl-go v1.0.0
+	github.com/leanovate/gopter v0.2.11
 )
+
+require golang.org/x/net v0.17.0 // indirect
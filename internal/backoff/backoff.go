@@ -0,0 +1,91 @@
+// Package backoff implements capped exponential reconnect backoff with jitter,
+// so that many instances reconnecting after an outage do not do so in lockstep.
+package backoff
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Defaults used for zero Policy fields.
+const (
+	DefaultMin    = 1 * time.Second
+	DefaultMax    = 30 * time.Second
+	DefaultJitter = 0.2
+)
+
+// Policy configures a backoff. Zero Min/Max use the defaults; Jitter is the
+// fraction of each delay that is randomized (0 uses DefaultJitter, negative
+// disables jitter, values above 1 are capped at 1).
+type Policy struct {
+	Min    time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+// Backoff tracks the current delay of one reconnect loop. It is not safe for
+// concurrent use; each loop owns its own Backoff.
+type Backoff struct {
+	min, max time.Duration
+	jitter   float64
+	next     time.Duration
+}
+
+// New creates a Backoff starting at the policy's Min delay.
+func (p Policy) New() *Backoff {
+	b := &Backoff{min: p.Min, max: p.Max, jitter: p.Jitter}
+	if b.min <= 0 {
+		b.min = DefaultMin
+	}
+	if b.max <= 0 {
+		b.max = DefaultMax
+	}
+	if b.max < b.min {
+		b.max = b.min
+	}
+	switch {
+	case b.jitter == 0:
+		b.jitter = DefaultJitter
+	case b.jitter < 0:
+		b.jitter = 0
+	case b.jitter > 1:
+		b.jitter = 1
+	}
+	b.next = b.min
+	return b
+}
+
+// Next returns the delay to wait now and doubles the base delay up to Max.
+// The returned delay is drawn uniformly from [d*(1-Jitter), d], where d is the
+// current base delay, so it always lies within [Min*(1-Jitter), Max].
+func (b *Backoff) Next() time.Duration {
+	d := b.next
+	if b.next < b.max {
+		b.next *= 2
+		if b.next > b.max {
+			b.next = b.max
+		}
+	}
+	if b.jitter > 0 {
+		d -= time.Duration(rand.Float64() * b.jitter * float64(d))
+	}
+	return d
+}
+
+// Reset restarts from the Min delay, e.g. after a successful connection.
+func (b *Backoff) Reset() {
+	b.next = b.min
+}
+
+// Wait sleeps for Next(). It returns false if ctx is done first.
+func (b *Backoff) Wait(ctx context.Context) bool {
+	t := time.NewTimer(b.Next())
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
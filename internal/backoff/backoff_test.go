@@ -0,0 +1,70 @@
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoff_GrowsWithinBounds(t *testing.T) {
+	p := Policy{Min: 100 * time.Millisecond, Max: 2 * time.Second, Jitter: 0.5}
+	b := p.New()
+
+	lower := time.Duration(float64(p.Min) * (1 - p.Jitter))
+	base := p.Min
+	for i := 0; i < 20; i++ {
+		d := b.Next()
+		if d < lower || d > p.Max {
+			t.Fatalf("attempt %d: delay %v outside [%v, %v]", i, d, lower, p.Max)
+		}
+		// Jitter only shortens the current base delay
+		if d > base || d < time.Duration(float64(base)*(1-p.Jitter)) {
+			t.Fatalf("attempt %d: delay %v outside jitter range of base %v", i, d, base)
+		}
+		base *= 2
+		if base > p.Max {
+			base = p.Max
+		}
+	}
+
+	b.Reset()
+	if d := b.Next(); d > p.Min {
+		t.Errorf("after Reset delay = %v, want <= %v", d, p.Min)
+	}
+}
+
+func TestBackoff_NoJitter(t *testing.T) {
+	b := Policy{Min: time.Second, Max: 5 * time.Second, Jitter: -1}.New()
+	want := []time.Duration{1, 2, 4, 5, 5}
+	for i, w := range want {
+		if d := b.Next(); d != w*time.Second {
+			t.Errorf("attempt %d: delay %v, want %v", i, d, w*time.Second)
+		}
+	}
+}
+
+func TestBackoff_JitterSpreadsDelays(t *testing.T) {
+	p := Policy{Min: time.Second, Max: time.Second}
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		seen[p.New().Next()] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected jittered delays to differ")
+	}
+}
+
+func TestPolicy_Defaults(t *testing.T) {
+	b := Policy{}.New()
+	if b.min != DefaultMin || b.max != DefaultMax || b.jitter != DefaultJitter {
+		t.Errorf("defaults = %v/%v/%v", b.min, b.max, b.jitter)
+	}
+}
+
+func TestBackoff_WaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if (Policy{Min: time.Hour}).New().Wait(ctx) {
+		t.Error("Wait should return false when ctx is done")
+	}
+}
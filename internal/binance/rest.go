@@ -10,61 +10,96 @@ import (
 	"time"
 )
 
+// DefaultCoinBaseURL is the COIN-margined futures (dapi) REST base URL.
+const DefaultCoinBaseURL = "https://dapi.binance.com"
+
 type RESTClient struct {
 	BaseURL string
 	HTTP    *http.Client
+
+	// CoinBaseURL serves COIN-margined symbols (see ContractType).
+	// Empty uses DefaultCoinBaseURL.
+	CoinBaseURL string
+
+	// Timeout bounds each request, including reading the body, on top of
+	// the caller's context. Zero uses DefaultRESTTimeout; negative disables it.
+	Timeout time.Duration
 }
 
+// DefaultRESTTimeout is the default per-request timeout of RESTClient.
+const DefaultRESTTimeout = 15 * time.Second
+
 func NewRESTClient(baseURL string) *RESTClient {
 	return &RESTClient{
 		BaseURL: baseURL,
 		HTTP: &http.Client{
 			Timeout: 15 * time.Second,
 		},
+		Timeout: DefaultRESTTimeout,
 	}
 }
 
+// withTimeout derives the context of one request from ctx and Timeout.
+func (c *RESTClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultRESTTimeout
+	}
+	if timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 type exchangeInfoResp struct {
 	Symbols []struct {
-		Symbol       string `json:"symbol"`
-		Status       string `json:"status"`
-		ContractType string `json:"contractType"`
-		QuoteAsset   string `json:"quoteAsset"`
+		Symbol         string `json:"symbol"`
+		Status         string `json:"status"`
+		ContractStatus string `json:"contractStatus"` // dapi uses this instead of status
+		ContractType   string `json:"contractType"`
+		QuoteAsset     string `json:"quoteAsset"`
 	} `json:"symbols"`
 }
 
 func (c *RESTClient) ExchangeInfoUSDTPERP(ctx context.Context) ([]string, error) {
-	url := c.BaseURL + "/fapi/v1/exchangeInfo"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	out, err := c.exchangeInfo(ctx, c.BaseURL+"/fapi/v1/exchangeInfo")
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.HTTP.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("exchangeInfo status=%d body=%s", resp.StatusCode, string(b))
+	symbols := make([]string, 0, len(out.Symbols))
+	for _, s := range out.Symbols {
+		if s.Status != "TRADING" {
+			continue
+		}
+		if s.ContractType != "PERPETUAL" {
+			continue
+		}
+		if s.QuoteAsset != "USDT" {
+			continue
+		}
+		symbols = append(symbols, s.Symbol)
 	}
+	return symbols, nil
+}
 
-	var out exchangeInfoResp
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+// ExchangeInfoCoinPERP returns the trading COIN-margined perpetual symbols
+// (e.g. BTCUSD_PERP) from the dapi exchange info.
+func (c *RESTClient) ExchangeInfoCoinPERP(ctx context.Context) ([]string, error) {
+	out, err := c.exchangeInfo(ctx, c.coinBaseURL()+"/dapi/v1/exchangeInfo")
+	if err != nil {
 		return nil, err
 	}
 
 	symbols := make([]string, 0, len(out.Symbols))
 	for _, s := range out.Symbols {
-		if s.Status != "TRADING" {
+		if s.ContractStatus != "TRADING" {
 			continue
 		}
 		if s.ContractType != "PERPETUAL" {
 			continue
 		}
-		if s.QuoteAsset != "USDT" {
+		if ContractType(s.Symbol) != ContractCoin {
 			continue
 		}
 		symbols = append(symbols, s.Symbol)
@@ -72,8 +107,51 @@ func (c *RESTClient) ExchangeInfoUSDTPERP(ctx context.Context) ([]string, error)
 	return symbols, nil
 }
 
+func (c *RESTClient) coinBaseURL() string {
+	if c.CoinBaseURL == "" {
+		return DefaultCoinBaseURL
+	}
+	return c.CoinBaseURL
+}
+
+// klinesURL returns the klines endpoint serving symbol's contract type.
+func (c *RESTClient) klinesURL(symbol string) string {
+	if ContractType(symbol) == ContractCoin {
+		return c.coinBaseURL() + "/dapi/v1/klines"
+	}
+	return c.BaseURL + "/fapi/v1/klines"
+}
+
+func (c *RESTClient) exchangeInfo(ctx context.Context, url string) (*exchangeInfoResp, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("exchangeInfo status=%d body=%s", resp.StatusCode, string(b))
+	}
+
+	var out exchangeInfoResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 func (c *RESTClient) PrevKline(ctx context.Context, symbol, interval string) (high, low, close float64, err error) {
-	url := fmt.Sprintf("%s/fapi/v1/klines?symbol=%s&interval=%s&limit=2", c.BaseURL, symbol, interval)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	url := fmt.Sprintf("%s?symbol=%s&interval=%s&limit=2", c.klinesURL(symbol), symbol, interval)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return 0, 0, 0, err
@@ -131,3 +209,92 @@ func (c *RESTClient) PrevKline(ctx context.Context, symbol, interval string) (hi
 
 	return high, low, close, nil
 }
+
+// Kline is one candle returned by the futures klines REST endpoint.
+// CloseTime is Binance's, i.e. one millisecond before the next open.
+type Kline struct {
+	OpenTime   time.Time
+	CloseTime  time.Time
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Volume     float64
+	TradeCount int64
+}
+
+// Klines returns up to limit most recent klines for symbol, oldest first.
+// The last one is usually still forming.
+func (c *RESTClient) Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	url := fmt.Sprintf("%s?symbol=%s&interval=%s&limit=%d", c.klinesURL(symbol), symbol, interval, limit)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("klines %s %s status=%d body=%s", symbol, interval, resp.StatusCode, string(b))
+	}
+
+	var raw [][]any
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	out := make([]Kline, 0, len(raw))
+	for _, k := range raw {
+		kl, err := parseRESTKline(k)
+		if err != nil {
+			return nil, fmt.Errorf("klines %s %s: %w", symbol, interval, err)
+		}
+		out = append(out, kl)
+	}
+	return out, nil
+}
+
+// parseRESTKline decodes [openTime, "open", "high", "low", "close", "volume",
+// closeTime, "quoteVolume", trades, ...].
+func parseRESTKline(k []any) (Kline, error) {
+	if len(k) < 9 {
+		return Kline{}, fmt.Errorf("invalid kline")
+	}
+	openMs, ok1 := k[0].(float64)
+	closeMs, ok2 := k[6].(float64)
+	trades, ok3 := k[8].(float64)
+	if !ok1 || !ok2 || !ok3 {
+		return Kline{}, fmt.Errorf("invalid kline times")
+	}
+
+	var prices [5]float64
+	for i := range prices {
+		str, ok := k[i+1].(string)
+		if !ok {
+			return Kline{}, fmt.Errorf("kline field %d not string", i+1)
+		}
+		v, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return Kline{}, err
+		}
+		prices[i] = v
+	}
+
+	return Kline{
+		OpenTime:   time.UnixMilli(int64(openMs)).UTC(),
+		CloseTime:  time.UnixMilli(int64(closeMs)).UTC(),
+		Open:       prices[0],
+		High:       prices[1],
+		Low:        prices[2],
+		Close:      prices[3],
+		Volume:     prices[4],
+		TradeCount: int64(trades),
+	}, nil
+}
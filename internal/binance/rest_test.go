@@ -0,0 +1,114 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRESTClient_Klines(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/klines" || r.URL.Query().Get("symbol") != "BTCUSDT" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`[
+			[1704103200000,"100.0","110.0","90.0","105.0","12.5",1704103499999,"1300.0",42,"6","600","0"],
+			[1704103500000,"105.0","106.0","104.0","105.5","1.0",1704103799999,"105.0",3,"0","0","0"]
+		]`))
+	}))
+	defer ts.Close()
+
+	got, err := NewRESTClient(ts.URL).Klines(context.Background(), "BTCUSDT", "5m", 2)
+	if err != nil {
+		t.Fatalf("Klines: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d klines, want 2", len(got))
+	}
+	k := got[0]
+	if !k.OpenTime.Equal(time.UnixMilli(1704103200000)) || !k.CloseTime.Equal(time.UnixMilli(1704103499999)) {
+		t.Errorf("times = %v..%v", k.OpenTime, k.CloseTime)
+	}
+	if k.Open != 100 || k.High != 110 || k.Low != 90 || k.Close != 105 || k.Volume != 12.5 || k.TradeCount != 42 {
+		t.Errorf("kline = %+v", k)
+	}
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[[1704103200000,"x","110.0","90.0","105.0","1",1704103499999,"0",1]]`))
+	}))
+	defer bad.Close()
+	if _, err := NewRESTClient(bad.URL).Klines(context.Background(), "BTCUSDT", "5m", 1); err == nil {
+		t.Error("expected an error for a malformed kline")
+	}
+}
+
+func TestRESTClient_CoinMargined(t *testing.T) {
+	coin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dapi/v1/exchangeInfo":
+			_, _ = w.Write([]byte(`{"symbols":[
+				{"symbol":"BTCUSD_PERP","contractStatus":"TRADING","contractType":"PERPETUAL"},
+				{"symbol":"ETHUSD_PERP","contractStatus":"SETTLING","contractType":"PERPETUAL"},
+				{"symbol":"BTCUSD_240628","contractStatus":"TRADING","contractType":"CURRENT_QUARTER"}
+			]}`))
+		case "/dapi/v1/klines":
+			_, _ = w.Write([]byte(`[
+				[1704067200000,"100.0","110.0","90.0","105.0","1",1704153599999,"0",1],
+				[1704153600000,"105.0","106.0","104.0","105.5","1",1704239999999,"0",1]
+			]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer coin.Close()
+
+	c := NewRESTClient("http://usdt.invalid")
+	c.CoinBaseURL = coin.URL
+
+	symbols, err := c.ExchangeInfoCoinPERP(context.Background())
+	if err != nil {
+		t.Fatalf("ExchangeInfoCoinPERP: %v", err)
+	}
+	if len(symbols) != 1 || symbols[0] != "BTCUSD_PERP" {
+		t.Errorf("symbols = %v, want [BTCUSD_PERP]", symbols)
+	}
+
+	// Coin-margined symbols are served by the dapi base URL
+	h, l, cl, err := c.PrevKline(context.Background(), "BTCUSD_PERP", "1d")
+	if err != nil {
+		t.Fatalf("PrevKline: %v", err)
+	}
+	if h != 110 || l != 90 || cl != 105 {
+		t.Errorf("PrevKline = %v %v %v, want 110 90 105", h, l, cl)
+	}
+}
+
+func TestRESTClient_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // never responds
+	}))
+	defer ts.Close()
+
+	c := NewRESTClient(ts.URL)
+	c.HTTP = &http.Client{} // no transport timeout: only Timeout can end the call
+	c.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := c.ExchangeInfoUSDTPERP(context.Background())
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want a deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("returned after %v, want about 50ms", elapsed)
+	}
+
+	if _, _, _, err := c.PrevKline(context.Background(), "BTCUSDT", "1d"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PrevKline err = %v, want a deadline exceeded", err)
+	}
+}
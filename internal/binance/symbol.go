@@ -0,0 +1,38 @@
+package binance
+
+import "strings"
+
+// NormalizeSymbol returns the canonical form of a user-supplied symbol:
+// upper-case with surrounding and inner whitespace, "-" and "/" removed,
+// e.g. " btc-usdt " and "btc/usdt" both become "BTCUSDT".
+// "_" is kept because it is part of delivery contract symbols (BTCUSDT_240628).
+func NormalizeSymbol(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '-', '/':
+			return -1
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, s)
+}
+
+// Contract types, as tagged on signals.
+const (
+	ContractUSDT = "usdt" // USDT-margined futures (fapi), e.g. BTCUSDT
+	ContractCoin = "coin" // COIN-margined futures (dapi), e.g. BTCUSD_PERP
+)
+
+// CoinPerpSuffix ends every COIN-margined perpetual symbol.
+const CoinPerpSuffix = "_PERP"
+
+// ContractType returns ContractCoin for COIN-margined perpetual symbols and
+// ContractUSDT for everything else.
+func ContractType(symbol string) string {
+	if strings.HasSuffix(symbol, CoinPerpSuffix) {
+		return ContractCoin
+	}
+	return ContractUSDT
+}
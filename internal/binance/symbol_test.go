@@ -0,0 +1,42 @@
+package binance
+
+import "testing"
+
+func TestNormalizeSymbol(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"BTCUSDT", "BTCUSDT"},
+		{"btcusdt", "BTCUSDT"},
+		{"  BtcUsdt \t", "BTCUSDT"},
+		{"btc-usdt", "BTCUSDT"},
+		{"btc/usdt", "BTCUSDT"},
+		{"btc usdt", "BTCUSDT"},
+		{"btcusdt_240628", "BTCUSDT_240628"},
+		{"1000pepeusdt", "1000PEPEUSDT"},
+		{"", ""},
+		{" - ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeSymbol(tt.in); got != tt.want {
+			t.Errorf("NormalizeSymbol(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContractType(t *testing.T) {
+	tests := map[string]string{
+		"BTCUSDT":        ContractUSDT,
+		"BTCUSDT_240628": ContractUSDT,
+		"BTCUSD_PERP":    ContractCoin,
+		"ETHUSD_PERP":    ContractCoin,
+		"BTCUSD_240628":  ContractUSDT, // quarterly delivery, not monitored
+	}
+	for sym, want := range tests {
+		if got := ContractType(sym); got != want {
+			t.Errorf("ContractType(%q) = %q, want %q", sym, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package binance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// FStreamCombinedBaseURL is the combined stream endpoint (payloads wrapped in {"stream","data"}).
+const FStreamCombinedBaseURL = "wss://fstream.binance.com/stream"
+
+// MaxStreamsPerConn is the maximum number of streams Binance allows on a single connection.
+const MaxStreamsPerConn = 200
+
+// AggTradeEvent 归集成交数据
+type AggTradeEvent struct {
+	Symbol       string  // 交易对
+	Price        float64 // 成交价格
+	Quantity     float64 // 成交量
+	FirstTradeID int64   // 被归集的首个交易ID
+	LastTradeID  int64   // 被归集的末次交易ID
+	TradeTime    int64   // 成交时间
+	EventTime    int64   // 事件时间
+}
+
+// Trades returns how many fills the aggregated trade bundles
+// (LastTradeID-FirstTradeID+1), or 1 when the IDs are missing.
+func (e AggTradeEvent) Trades() int64 {
+	if e.FirstTradeID <= 0 || e.LastTradeID < e.FirstTradeID {
+		return 1
+	}
+	return e.LastTradeID - e.FirstTradeID + 1
+}
+
+func (e *AggTradeEvent) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	parseFloat := func(key string) float64 {
+		switch val := raw[key].(type) {
+		case json.Number:
+			f, _ := val.Float64()
+			return f
+		case string:
+			f, _ := json.Number(val).Float64()
+			return f
+		}
+		return 0
+	}
+
+	parseInt := func(key string) int64 {
+		switch val := raw[key].(type) {
+		case json.Number:
+			i, _ := val.Int64()
+			return i
+		case string:
+			i, _ := json.Number(val).Int64()
+			return i
+		}
+		return 0
+	}
+
+	if v, ok := raw["s"].(string); ok {
+		e.Symbol = v
+	}
+	e.Price = parseFloat("p")
+	e.Quantity = parseFloat("q")
+	e.FirstTradeID = parseInt("f")
+	e.LastTradeID = parseInt("l")
+	e.TradeTime = parseInt("T")
+	e.EventTime = parseInt("E")
+
+	return nil
+}
+
+// ParseAggTradeMessage parses a raw aggTrade message, either bare or wrapped by a combined stream.
+func ParseAggTradeMessage(b []byte) (AggTradeEvent, bool) {
+	var wrapped struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &wrapped); err == nil && len(wrapped.Data) > 0 {
+		b = wrapped.Data
+	}
+
+	var ev AggTradeEvent
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return AggTradeEvent{}, false
+	}
+	if ev.Symbol == "" || ev.Quantity <= 0 {
+		return AggTradeEvent{}, false
+	}
+	return ev, true
+}
+
+// DialAggTrade 订阅指定交易对的归集成交（组合流，最多 MaxStreamsPerConn 个）
+func DialAggTrade(ctx context.Context, symbols []string) (*websocket.Conn, *http.Response, error) {
+	d := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+	}
+	streams := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		streams = append(streams, strings.ToLower(s)+"@aggTrade")
+	}
+	url := FStreamCombinedBaseURL + "?streams=" + strings.Join(streams, "/")
+	return d.DialContext(ctx, url, nil)
+}
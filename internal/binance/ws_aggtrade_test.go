@@ -0,0 +1,53 @@
+package binance
+
+import "testing"
+
+func TestParseAggTradeMessage_Combined(t *testing.T) {
+	msg := `{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","E":1700000000100,"s":"BTCUSDT","a":5933014,"p":"50000.10","q":"0.250","f":100,"l":105,"T":1700000000050,"m":true}}`
+
+	ev, ok := ParseAggTradeMessage([]byte(msg))
+	if !ok {
+		t.Fatal("ParseAggTradeMessage failed")
+	}
+	if ev.Symbol != "BTCUSDT" {
+		t.Errorf("Symbol = %s, want BTCUSDT", ev.Symbol)
+	}
+	if ev.Price != 50000.10 {
+		t.Errorf("Price = %f, want 50000.10", ev.Price)
+	}
+	if ev.Quantity != 0.25 {
+		t.Errorf("Quantity = %f, want 0.25", ev.Quantity)
+	}
+	if ev.FirstTradeID != 100 || ev.LastTradeID != 105 || ev.Trades() != 6 {
+		t.Errorf("TradeIDs = %d-%d (%d trades), want 100-105 (6)", ev.FirstTradeID, ev.LastTradeID, ev.Trades())
+	}
+	if ev.TradeTime != 1700000000050 {
+		t.Errorf("TradeTime = %d, want 1700000000050", ev.TradeTime)
+	}
+}
+
+func TestParseAggTradeMessage_Bare(t *testing.T) {
+	msg := `{"e":"aggTrade","E":"1700000000100","s":"ETHUSDT","p":"3000","q":"2","T":"1700000000050"}`
+
+	ev, ok := ParseAggTradeMessage([]byte(msg))
+	if !ok {
+		t.Fatal("ParseAggTradeMessage failed")
+	}
+	if ev.Symbol != "ETHUSDT" || ev.Quantity != 2 || ev.TradeTime != 1700000000050 || ev.Trades() != 1 {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestParseAggTradeMessage_Invalid(t *testing.T) {
+	cases := []string{
+		``,
+		`not json`,
+		`{"result":null,"id":1}`,
+		`{"s":"BTCUSDT","q":"0"}`,
+	}
+	for _, c := range cases {
+		if _, ok := ParseAggTradeMessage([]byte(c)); ok {
+			t.Errorf("ParseAggTradeMessage(%q) should fail", c)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,6 +13,9 @@ import (
 
 const FStreamWSBaseURL = "wss://fstream.binance.com/ws"
 
+// DStreamCombinedBaseURL is the COIN-margined combined stream endpoint.
+const DStreamCombinedBaseURL = "wss://dstream.binance.com/stream"
+
 type MarkPriceEvent struct {
 	EventTime int64  `json:"E"`
 	Symbol    string `json:"s"`
@@ -67,3 +71,37 @@ func DialMarkPriceArr1s(ctx context.Context) (*websocket.Conn, *http.Response, e
 	url := FStreamWSBaseURL + "/!markPrice@arr@1s"
 	return d.DialContext(ctx, url, nil)
 }
+
+// DialMarkPrice subscribes to the 1s mark price of the given USDT-margined
+// symbols only (at most MaxStreamsPerConn), for deployments watching a few
+// symbols where the all-market array is wasteful. Payloads arrive one event
+// at a time wrapped in {"stream","data"}.
+func DialMarkPrice(ctx context.Context, symbols []string) (*websocket.Conn, *http.Response, error) {
+	d := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+	}
+	return d.DialContext(ctx, MarkPriceStreamURL(FStreamCombinedBaseURL, symbols), nil)
+}
+
+// DialCoinMarkPrice1s subscribes to the 1s mark price of COIN-margined symbols.
+// dapi has no all-market mark price array, so this is a combined stream of
+// per-symbol streams (at most MaxStreamsPerConn) whose payloads arrive one
+// event at a time wrapped in {"stream","data"}.
+func DialCoinMarkPrice1s(ctx context.Context, symbols []string) (*websocket.Conn, *http.Response, error) {
+	d := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+	}
+	return d.DialContext(ctx, MarkPriceStreamURL(DStreamCombinedBaseURL, symbols), nil)
+}
+
+// MarkPriceStreamURL returns the combined stream URL at base subscribing to
+// the 1s mark price stream of each symbol.
+func MarkPriceStreamURL(base string, symbols []string) string {
+	streams := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		streams = append(streams, strings.ToLower(s)+"@markPrice@1s")
+	}
+	return base + "?streams=" + strings.Join(streams, "/")
+}
@@ -0,0 +1,17 @@
+package binance
+
+import "testing"
+
+func TestMarkPriceStreamURL(t *testing.T) {
+	got := MarkPriceStreamURL(FStreamCombinedBaseURL, []string{"BTCUSDT", "ethusdt"})
+	want := "wss://fstream.binance.com/stream?streams=btcusdt@markPrice@1s/ethusdt@markPrice@1s"
+	if got != want {
+		t.Errorf("MarkPriceStreamURL = %q, want %q", got, want)
+	}
+
+	got = MarkPriceStreamURL(DStreamCombinedBaseURL, []string{"BTCUSD_PERP"})
+	want = "wss://dstream.binance.com/stream?streams=btcusd_perp@markPrice@1s"
+	if got != want {
+		t.Errorf("MarkPriceStreamURL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+// Package clock abstracts the current time so that staleness, cooldown and
+// retention logic can be tested deterministically without sleeping.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock reports the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+// Now implements Clock.
+func (Real) Now() time.Time { return time.Now() }
+
+// Or returns c, or the wall clock when c is nil. Components holding an
+// optional Clock field call it so a zero value keeps working.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// Fake is a manually driven Clock for tests. It is safe for concurrent use.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake creates a Fake clock set to t.
+func NewFake(t time.Time) *Fake {
+	return &Fake{now: t}
+}
+
+// Now implements Clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set moves the clock to t.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	f.now = t
+	f.mu.Unlock()
+}
+
+// Advance moves the clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
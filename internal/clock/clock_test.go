@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	if !f.Now().Equal(start) {
+		t.Fatalf("Now() = %v, want %v", f.Now(), start)
+	}
+
+	f.Advance(90 * time.Second)
+	if want := start.Add(90 * time.Second); !f.Now().Equal(want) {
+		t.Errorf("after Advance, Now() = %v, want %v", f.Now(), want)
+	}
+
+	f.Set(start)
+	if !f.Now().Equal(start) {
+		t.Errorf("after Set, Now() = %v, want %v", f.Now(), start)
+	}
+}
+
+func TestOr(t *testing.T) {
+	if _, ok := Or(nil).(Real); !ok {
+		t.Error("Or(nil) should return the real clock")
+	}
+	f := NewFake(time.Time{})
+	if Or(f) != Clock(f) {
+		t.Error("Or(c) should return c")
+	}
+}
@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClearResponse is the response for /api/admin/clear.
+type ClearResponse struct {
+	Cleared string `json:"cleared"` // "signals", "patterns" or "ranking"
+	Count   int    `json:"count"`   // Signals or snapshots removed from memory
+}
+
+// authorized reports whether r carries "Authorization: Bearer <AdminToken>".
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1
+}
+
+// handleAdminClear wipes an in-memory store and its persistence file, for
+// demos and testing. Only served when AdminToken is set.
+// POST /api/admin/clear?what=signals|patterns|ranking
+func (s *Server) handleAdminClear(w http.ResponseWriter, r *http.Request) {
+	if s.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+		return
+	}
+
+	what := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("what")))
+	var clear func() (int, error)
+	switch what {
+	case "signals":
+		if s.History != nil {
+			clear = s.History.Clear
+		}
+	case "patterns":
+		if s.PatternHistory != nil {
+			clear = s.PatternHistory.Clear
+		}
+	case "ranking":
+		if s.RankingStore != nil {
+			clear = s.RankingStore.Clear
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"what must be signals, patterns or ranking"}`))
+		return
+	}
+	if clear == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = s.writeJSON(w, map[string]string{"error": what + " not available"})
+		return
+	}
+
+	n, err := clear()
+	if err != nil {
+		log.Printf("admin: clear %s: %v", what, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = s.writeJSON(w, map[string]string{"error": fmt.Sprintf("clear %s: %v", what, err)})
+		return
+	}
+
+	log.Printf("admin: cleared %d %s", n, what)
+	_ = s.writeJSON(w, ClearResponse{Cleared: what, Count: n})
+}
+
+// PatternSwitchResponse is the response for /api/admin/patterns.
+type PatternSwitchResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// handleAdminPatterns switches pattern detection on or off without a
+// restart. Only served when AdminToken is set.
+// POST /api/admin/patterns?enabled=true|false
+func (s *Server) handleAdminPatterns(w http.ResponseWriter, r *http.Request) {
+	if s.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"unauthorized"}`))
+		return
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(r.URL.Query().Get("enabled")))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"enabled must be true or false"}`))
+		return
+	}
+	if s.PatternSwitch == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"pattern detection not available"}`))
+		return
+	}
+
+	s.PatternSwitch.SetPatternsEnabled(enabled)
+	log.Printf("admin: pattern detection enabled=%v", enabled)
+	_ = s.writeJSON(w, PatternSwitchResponse{Enabled: enabled})
+}
@@ -0,0 +1,89 @@
+package httpapi
+
+import (
+	"example.com/binance-pivot-monitor/internal/pattern"
+	"example.com/binance-pivot-monitor/internal/ranking"
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+	"example.com/binance-pivot-monitor/internal/ticker"
+)
+
+// aliasFor returns the display alias configured for symbol, or the symbol
+// itself when it has none.
+func (s *Server) aliasFor(symbol string) string {
+	if alias, ok := s.SymbolAliases[symbol]; ok {
+		return alias
+	}
+	return symbol
+}
+
+// aliasSignal sets sig.Alias when SymbolAliases is configured.
+func (s *Server) aliasSignal(sig *signalpkg.Signal) {
+	if s.SymbolAliases != nil {
+		sig.Alias = s.aliasFor(sig.Symbol)
+	}
+}
+
+// aliasSignals sets Alias on signals in place when SymbolAliases is configured.
+func (s *Server) aliasSignals(signals []signalpkg.Signal) {
+	for i := range signals {
+		s.aliasSignal(&signals[i])
+	}
+}
+
+// aliasPattern sets sig.Alias when SymbolAliases is configured.
+func (s *Server) aliasPattern(sig *pattern.Signal) {
+	if s.SymbolAliases != nil && sig != nil {
+		sig.Alias = s.aliasFor(sig.Symbol)
+	}
+}
+
+// aliasPatterns sets Alias on pattern signals in place when SymbolAliases is configured.
+func (s *Server) aliasPatterns(signals []pattern.Signal) {
+	for i := range signals {
+		s.aliasPattern(&signals[i])
+	}
+}
+
+// aliasRankingItems returns items with Alias set when SymbolAliases is
+// configured. Items are copied, as the ranking store caches them.
+func (s *Server) aliasRankingItems(items []ranking.RankingItem) []ranking.RankingItem {
+	if s.SymbolAliases == nil {
+		return items
+	}
+	out := make([]ranking.RankingItem, len(items))
+	for i, it := range items {
+		it.Alias = s.aliasFor(it.Symbol)
+		out[i] = it
+	}
+	return out
+}
+
+// aliasSummaries sets Aliases on pattern summaries in place when
+// SymbolAliases is configured, one per symbol.
+func (s *Server) aliasSummaries(summaries []pattern.PatternSummary) {
+	if s.SymbolAliases == nil {
+		return
+	}
+	for i := range summaries {
+		aliases := make([]string, len(summaries[i].Symbols))
+		for j, sym := range summaries[i].Symbols {
+			aliases[j] = s.aliasFor(sym)
+		}
+		summaries[i].Aliases = aliases
+	}
+}
+
+// aliasTickers returns tickers with Alias set when SymbolAliases is
+// configured. Tickers are copied, as SSE batches are shared by subscribers.
+func (s *Server) aliasTickers(tickers map[string]*ticker.Ticker) map[string]*ticker.Ticker {
+	if s.SymbolAliases == nil {
+		return tickers
+	}
+	out := make(map[string]*ticker.Ticker, len(tickers))
+	for k, t := range tickers {
+		c := *t
+		c.Alias = s.aliasFor(c.Symbol)
+		out[k] = &c
+	}
+	return out
+}
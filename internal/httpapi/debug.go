@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+)
+
+// CooldownEntry is one active cooldown key.
+type CooldownEntry struct {
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CooldownResponse is the response for /api/debug/cooldown.
+type CooldownResponse struct {
+	Symbol string          `json:"symbol,omitempty"`
+	Keys   []CooldownEntry `json:"keys"`
+}
+
+// handleDebugCooldown lists active cooldown keys and their expiry, to explain
+// suppressed signals. Only served when Debug is set.
+// GET /api/debug/cooldown?symbol=BTCUSDT (symbol optional, all keys if omitted)
+func (s *Server) handleDebugCooldown(w http.ResponseWriter, r *http.Request) {
+	if !s.Debug {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.Cooldown == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"cooldown not available"}`))
+		return
+	}
+
+	symbol := binance.NormalizeSymbol(r.URL.Query().Get("symbol"))
+	resp := CooldownResponse{Symbol: symbol, Keys: []CooldownEntry{}}
+	for key, expiry := range s.Cooldown.ActiveKeys() {
+		// Keys are "SYMBOL", "SYMBOL|period" or "SYMBOL|period|level" depending on scope
+		if symbol != "" && key != symbol && !strings.HasPrefix(key, symbol+"|") {
+			continue
+		}
+		resp.Keys = append(resp.Keys, CooldownEntry{Key: key, ExpiresAt: expiry.UTC()})
+	}
+	sort.Slice(resp.Keys, func(i, j int) bool {
+		return resp.Keys[i].Key < resp.Keys[j].Key
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
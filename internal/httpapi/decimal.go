@@ -0,0 +1,68 @@
+package httpapi
+
+import "strconv"
+
+// decimalKeys are the keys whose numbers PriceDecimalString rewrites: signal,
+// ticker and ranking prices, pivot levels (and mid-pivots) and the OHLC they
+// come from. A new non-price field must not reuse these names.
+var decimalKeys = map[string]bool{
+	"price": true, "last_price": true, "prev_price": true,
+	"current_open": true, "current_close": true,
+	"open": true, "high": true, "low": true, "close": true,
+	"pp": true, "r1": true, "r2": true, "r3": true, "r4": true, "r5": true,
+	"s1": true, "s2": true, "s3": true, "s4": true, "s5": true,
+	"m1": true, "m2": true, "m3": true, "m4": true,
+}
+
+// decimalizePrices rewrites the number values of decimalKeys in compact
+// JSON (as produced by json.Marshal) to fixed-point decimal strings, so
+// 1.23e-7 becomes "0.000000123". Other values are left untouched.
+func decimalizePrices(b []byte) []byte {
+	out := make([]byte, 0, len(b)+len(b)/8)
+	for i := 0; i < len(b); i++ {
+		if b[i] != '"' {
+			out = append(out, b[i])
+			continue
+		}
+
+		// Find the closing quote, skipping escaped characters
+		end := i + 1
+		for ; end < len(b); end++ {
+			if b[end] == '\\' {
+				end++
+				continue
+			}
+			if b[end] == '"' {
+				break
+			}
+		}
+		if end >= len(b) {
+			return append(out, b[i:]...)
+		}
+		key := b[i+1 : end]
+		out = append(out, b[i:end+1]...)
+		i = end
+
+		if end+1 >= len(b) || b[end+1] != ':' || !decimalKeys[string(key)] {
+			continue
+		}
+		num := end + 2
+		stop := num
+		for stop < len(b) && isNumberByte(b[stop]) {
+			stop++
+		}
+		f, err := strconv.ParseFloat(string(b[num:stop]), 64)
+		if stop == num || err != nil {
+			continue
+		}
+		out = append(out, ':', '"')
+		out = strconv.AppendFloat(out, f, 'f', -1, 64)
+		out = append(out, '"')
+		i = stop - 1
+	}
+	return out
+}
+
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONCase selects the casing of JSON object keys in API responses.
+type JSONCase string
+
+const (
+	JSONCaseSnake JSONCase = "snake" // As declared in struct tags (default)
+	JSONCaseCamel JSONCase = "camel" // snake_case keys rewritten to camelCase
+)
+
+// ParseJSONCase parses a casing name; empty means JSONCaseSnake.
+func ParseJSONCase(s string) (JSONCase, error) {
+	switch JSONCase(strings.ToLower(strings.TrimSpace(s))) {
+	case "", JSONCaseSnake:
+		return JSONCaseSnake, nil
+	case JSONCaseCamel:
+		return JSONCaseCamel, nil
+	default:
+		return "", fmt.Errorf("unknown json case %q", s)
+	}
+}
+
+// marshalJSON encodes v and applies the configured key casing and price
+// encoding. Both are rewritten on the encoded bytes, so every nested struct,
+// map and slice element is transformed consistently without extra struct tags.
+func (s *Server) marshalJSON(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return s.rewriteJSON(b), nil
+}
+
+// rewriteJSON applies the configured price encoding and key casing to
+// compact JSON encoded elsewhere, e.g. streamed from a store.
+func (s *Server) rewriteJSON(b []byte) []byte {
+	if s.PriceDecimalString {
+		b = decimalizePrices(b)
+	}
+	if s.JSONCase == JSONCaseCamel {
+		b = camelizeKeys(b)
+	}
+	return b
+}
+
+// writeJSON writes v as a newline-terminated JSON document, like json.Encoder.
+func (s *Server) writeJSON(w io.Writer, v any) error {
+	b, err := s.marshalJSON(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// camelizeKeys rewrites object keys in compact JSON (as produced by
+// json.Marshal) from snake_case to camelCase. String values are left untouched:
+// a string is a key only when it is followed by ':'.
+func camelizeKeys(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] != '"' {
+			out = append(out, b[i])
+			continue
+		}
+
+		// Find the closing quote, skipping escaped characters
+		end := i + 1
+		escaped := false
+		for ; end < len(b); end++ {
+			if b[end] == '\\' {
+				end++
+				escaped = true
+				continue
+			}
+			if b[end] == '"' {
+				break
+			}
+		}
+		if end >= len(b) {
+			return append(out, b[i:]...)
+		}
+
+		str := b[i : end+1]
+		if !escaped && end+1 < len(b) && b[end+1] == ':' {
+			str = []byte(`"` + snakeToCamel(string(b[i+1:end])) + `"`)
+		}
+		out = append(out, str...)
+		i = end
+	}
+	return out
+}
+
+// snakeToCamel converts "price_change" to "priceChange". Keys without
+// underscores are returned unchanged.
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	upper := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' {
+			upper = sb.Len() > 0
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
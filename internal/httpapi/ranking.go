@@ -1,12 +1,15 @@
 package httpapi
 
 import (
-	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/binance"
 	"example.com/binance-pivot-monitor/internal/ranking"
 )
 
@@ -36,11 +39,42 @@ func parseCompareDuration(s string) (time.Duration, bool) {
 	}
 }
 
+// rankingNotModified sets an ETag derived from the latest snapshot time and
+// the request path and query, and answers 304 when If-None-Match carries it.
+// Ranking responses only change when a snapshot is added, so polling
+// clients skip the body in between. Call it after validating parameters.
+func (s *Server) rankingNotModified(w http.ResponseWriter, r *http.Request) bool {
+	if s.RankingStore == nil {
+		return false
+	}
+	var ts int64
+	if latest := s.RankingStore.Latest(); latest != nil {
+		ts = latest.Timestamp.UnixNano()
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery))
+	etag := fmt.Sprintf(`"%x-%x"`, ts, h.Sum64())
+
+	w.Header().Set("ETag", etag)
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
 // handleRankingCurrent handles GET /api/ranking/current
 // Query params:
 //   - type: volume|trades (default: volume)
 //   - compare: 5m|15m|30m|1h|6h|24h (default: previous snapshot)
 //   - limit: int (default: 0 = all)
+//   - sort: rank|price_change|volume_change|trade_change (default: rank)
+//   - order: asc|desc (default: asc for rank, desc for changes)
+//   - include_prev: true to include prev_rank/prev_price/prev_volume
+//   - min_volume: float, drop symbols with a lower quote volume (ranks stay global)
 func (s *Server) handleRankingCurrent(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -83,10 +117,49 @@ func (s *Server) handleRankingCurrent(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Parse sort and order parameters
+	sortBy := strings.ToLower(q.Get("sort"))
+	switch sortBy {
+	case "", ranking.SortRank, ranking.SortPriceChange, ranking.SortVolumeChange, ranking.SortTradeChange:
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid sort parameter (rank, price_change, volume_change or trade_change)"}`))
+		return
+	}
+	order := strings.ToLower(q.Get("order"))
+	if order != "" && order != ranking.OrderAsc && order != ranking.OrderDesc {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid order parameter (asc or desc)"}`))
+		return
+	}
+
+	minVolume := 0.0
+	if v := q.Get("min_volume"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid min_volume parameter"}`))
+			return
+		}
+		minVolume = f
+	}
+
 	opts := ranking.CurrentOptions{
 		Type:    rankType,
 		Compare: compare,
 		Limit:   limit,
+		Sort:    sortBy,
+		Order:   order,
+
+		IncludePrev: q.Get("include_prev") == "true",
+		MinVolume:   minVolume,
+	}
+
+	if s.rankingNotModified(w, r) {
+		return
 	}
 
 	var resp *ranking.CurrentResponse
@@ -94,13 +167,16 @@ func (s *Server) handleRankingCurrent(w http.ResponseWriter, r *http.Request) {
 		resp = &ranking.CurrentResponse{Items: []ranking.RankingItem{}}
 	} else {
 		resp = s.RankingStore.GetCurrent(opts)
+		resp.Items = s.aliasRankingItems(resp.Items)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_ = s.writeJSON(w, resp)
 }
 
 // handleRankingHistory handles GET /api/ranking/history/{symbol}
+// Query params:
+//   - interval: duration like 30m; keeps the last snapshot per interval (default: all)
 func (s *Server) handleRankingHistory(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -113,7 +189,7 @@ func (s *Server) handleRankingHistory(w http.ResponseWriter, r *http.Request) {
 
 	// Extract symbol from path: /api/ranking/history/{symbol}
 	path := strings.TrimPrefix(r.URL.Path, "/api/ranking/history/")
-	symbol := strings.ToUpper(strings.TrimSpace(path))
+	symbol := binance.NormalizeSymbol(path)
 	if symbol == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Header().Set("Content-Type", "application/json")
@@ -121,15 +197,35 @@ func (s *Server) handleRankingHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Parse interval parameter
+	var interval time.Duration
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid interval parameter"}`))
+			return
+		}
+		interval = d
+	}
+
+	if s.rankingNotModified(w, r) {
+		return
+	}
+
 	var resp *ranking.HistoryResponse
 	if s.RankingStore == nil {
 		resp = &ranking.HistoryResponse{Symbol: symbol, Snapshots: []ranking.SymbolSnapshot{}}
 	} else {
-		resp = s.RankingStore.GetHistory(symbol)
+		resp = s.RankingStore.GetHistoryDownsampled(symbol, interval)
+	}
+	if s.SymbolAliases != nil {
+		resp.Alias = s.aliasFor(symbol)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_ = s.writeJSON(w, resp)
 }
 
 // handleRankingMovers handles GET /api/ranking/movers
@@ -138,6 +234,9 @@ func (s *Server) handleRankingHistory(w http.ResponseWriter, r *http.Request) {
 //   - direction: up|down (required)
 //   - compare: 5m|15m|30m|1h|6h|24h (default: previous snapshot)
 //   - limit: int (default: 20)
+//   - symbols: comma-separated watchlist; only these symbols are considered (ranks stay global)
+//   - include_prev: true to include prev_rank/prev_price/prev_volume
+//   - min_change: int, skip symbols whose rank moved fewer positions (default: 0 = all)
 func (s *Server) handleRankingMovers(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -189,11 +288,40 @@ func (s *Server) handleRankingMovers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Parse symbols parameter
+	var symbols []string
+	for _, sym := range strings.Split(q.Get("symbols"), ",") {
+		if sym = binance.NormalizeSymbol(sym); sym != "" {
+			symbols = append(symbols, sym)
+		}
+	}
+
+	// Parse min_change parameter
+	minChange := 0
+	if v := q.Get("min_change"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid min_change parameter"}`))
+			return
+		}
+		minChange = n
+	}
+
 	opts := ranking.MoversOptions{
 		Type:      rankType,
 		Direction: direction,
 		Compare:   compare,
 		Limit:     limit,
+		Symbols:   symbols,
+
+		IncludePrev: q.Get("include_prev") == "true",
+		MinChange:   minChange,
+	}
+
+	if s.rankingNotModified(w, r) {
+		return
 	}
 
 	var resp *ranking.MoversResponse
@@ -201,8 +329,9 @@ func (s *Server) handleRankingMovers(w http.ResponseWriter, r *http.Request) {
 		resp = &ranking.MoversResponse{Direction: direction, Items: []ranking.RankingItem{}}
 	} else {
 		resp = s.RankingStore.GetMovers(opts)
+		resp.Items = s.aliasRankingItems(resp.Items)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	_ = s.writeJSON(w, resp)
 }
@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"sync"
+	"time"
+)
+
+// readyState tracks the one-time startup-complete event for SSE clients.
+type readyState struct {
+	mu sync.Mutex
+	ch chan struct{}
+	at time.Time
+}
+
+func (r *readyState) wait() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ch == nil {
+		r.ch = make(chan struct{})
+	}
+	return r.ch
+}
+
+// MarkReady records that startup (pivots and kline warm-up) is complete.
+// Connected SSE clients receive a one-time "ready" event; clients that
+// connect later get it right away. Calls after the first are no-ops.
+func (s *Server) MarkReady() {
+	ch := s.ready.wait()
+	s.ready.mu.Lock()
+	defer s.ready.mu.Unlock()
+	if !s.ready.at.IsZero() {
+		return
+	}
+	s.ready.at = time.Now().UTC()
+	close(ch)
+}
+
+// readyAt returns when MarkReady was called, or the zero time.
+func (s *Server) readyAt() time.Time {
+	s.ready.mu.Lock()
+	defer s.ready.mu.Unlock()
+	return s.ready.at
+}
@@ -1,16 +1,22 @@
 package httpapi
 
 import (
+	"bytes"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"math"
 	"net/http"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/binance"
 	"example.com/binance-pivot-monitor/internal/kline"
 	"example.com/binance-pivot-monitor/internal/pattern"
 	"example.com/binance-pivot-monitor/internal/pivot"
@@ -28,6 +34,7 @@ type Server struct {
 	History        *signalpkg.History
 	AllowedOrigins []string
 	PivotStatus    PivotStatusProvider
+	PivotFiles     PivotFileProvider
 	PivotStore     *pivot.Store
 	TickerStore    *ticker.Store
 	TickerMonitor  *ticker.Monitor
@@ -40,8 +47,72 @@ type Server struct {
 
 	// Ranking monitor
 	RankingStore *ranking.Store
+
+	// JSONCase selects response key casing; empty means JSONCaseSnake.
+	JSONCase JSONCase
+
+	// PriceDecimalString encodes prices and pivot levels in responses as
+	// fixed-point decimal strings ("0.000000123") instead of JSON numbers,
+	// which Go writes in scientific notation below 1e-6.
+	PriceDecimalString bool
+
+	// MaxSSEConnections caps concurrent /api/sse streams (signal broker
+	// subscribers); further connections get 503. Zero means unlimited.
+	MaxSSEConnections int
+
+	// SSEWriteTimeout bounds each SSE frame write and flush; a client that
+	// cannot take a frame in time is disconnected. Zero uses
+	// DefaultSSEWriteTimeout; negative disables the deadline.
+	SSEWriteTimeout time.Duration
+
+	// HistoryEnrichLimit caps how many signals of a /api/history response
+	// get a related_pattern lookup; later ones are returned without it.
+	// Zero uses DefaultHistoryEnrichLimit; negative disables the cap.
+	HistoryEnrichLimit int
+
+	// HistoryEnrichTimeout bounds the time /api/history spends on pattern
+	// enrichment; signals not reached in time are returned without it.
+	// Zero uses DefaultHistoryEnrichTimeout; negative disables the budget.
+	HistoryEnrichTimeout time.Duration
+
+	// SymbolAliases maps symbols to display names, e.g. "BTCUSDT" -> "BTC".
+	// When set, signal, pattern, ticker and ranking responses carry an alias
+	// field (the symbol itself when unmapped) and pattern summaries an
+	// aliases list. Set before Handler is called.
+	SymbolAliases map[string]string
+
+	// AdminToken enables /api/admin/* endpoints, which require
+	// "Authorization: Bearer <AdminToken>". Empty disables them.
+	AdminToken string
+
+	// SignalActivity, when set, serves /api/signals/activity;
+	// *monitor.Monitor implements it.
+	SignalActivity SignalActivityProvider
+
+	// PatternSwitch, when set, reports and toggles pattern detection via
+	// /api/runtime and /api/admin/patterns; *monitor.Monitor implements it.
+	PatternSwitch PatternSwitch
+
+	// Debug enables /api/debug/* endpoints.
+	Debug    bool
+	Cooldown *signalpkg.Cooldown
+
+	ready readyState
 }
 
+// DefaultSSEWriteTimeout is the default deadline for one SSE frame write.
+const DefaultSSEWriteTimeout = 10 * time.Second
+
+const (
+	// DefaultHistoryEnrichLimit is the default number of /api/history
+	// signals enriched with a related pattern.
+	DefaultHistoryEnrichLimit = 500
+
+	// DefaultHistoryEnrichTimeout is the default time budget for
+	// /api/history pattern enrichment.
+	DefaultHistoryEnrichTimeout = 2 * time.Second
+)
+
 func New(signalBroker *sse.Broker[signalpkg.Signal], history *signalpkg.History, allowedOrigins []string) *Server {
 	return &Server{SignalBroker: signalBroker, History: history, AllowedOrigins: allowedOrigins}
 }
@@ -50,19 +121,55 @@ type PivotStatusProvider interface {
 	PivotStatus() pivot.PivotStatusResponse
 }
 
+// PivotFileProvider exposes the pivot files on disk; *pivot.Refresher implements it.
+type PivotFileProvider interface {
+	RawFile(period pivot.Period) ([]byte, error)
+}
+
+// SignalActivityProvider reports how many distinct pivot levels each
+// symbol crossed since the start of the current day.
+type SignalActivityProvider interface {
+	SignalActivity(now time.Time) (dayStart time.Time, counts map[string]int)
+}
+
+// PatternSwitch turns pattern detection on and off at runtime.
+type PatternSwitch interface {
+	PatternsEnabled() bool
+	SetPatternsEnabled(enabled bool)
+}
+
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleDashboard)
 	mux.HandleFunc("/healthz", s.handleHealth)
 	mux.HandleFunc("/api/sse", s.handleSSE)
 	mux.HandleFunc("/api/history", s.handleHistory)
+	mux.HandleFunc("/api/history/near", s.handleHistoryNear)
+	mux.HandleFunc("/api/history/clusters", s.handleHistoryClusters)
+	mux.HandleFunc("/api/signals/", s.handleSignalPatterns)
+	mux.HandleFunc("/api/signals/activity", s.handleSignalActivity)
+	mux.HandleFunc("/api/combiner/recent", s.handleCombinerRecent)
 	mux.HandleFunc("/api/pivot-status", s.handlePivotStatus)
 	mux.HandleFunc("/api/pivots/", s.handlePivots)
+	mux.HandleFunc("/api/pivots/batch", s.handlePivotsBatch)
+	mux.HandleFunc("/api/pivots/coverage", s.handlePivotCoverage)
+	mux.HandleFunc("/api/pivots/calc", s.handlePivotCalc)
+	mux.HandleFunc("/api/pivots/raw", s.handlePivotRaw)
 	mux.HandleFunc("/api/tickers", s.handleTickers)
+	mux.HandleFunc("/api/ticker/", s.handleTicker)
 	mux.HandleFunc("/api/patterns", s.handlePatterns)
+	mux.HandleFunc("/api/patterns/types", s.handlePatternTypes)
+	mux.HandleFunc("/api/patterns/summary", s.handlePatternSummary)
+	mux.HandleFunc("/api/patterns/", s.handlePatternLatest)
 	mux.HandleFunc("/api/klines", s.handleKlines)
 	mux.HandleFunc("/api/klines/stats", s.handleKlineStats)
+	mux.HandleFunc("/api/klines/current", s.handleKlineCurrent)
 	mux.HandleFunc("/api/runtime", s.handleRuntime)
+	mux.HandleFunc("/api/version", s.handleVersion)
+	mux.HandleFunc("/api/export", s.handleExport)
+	mux.HandleFunc("/api/debug/cooldown", s.handleDebugCooldown)
+	mux.HandleFunc("/api/admin/clear", s.handleAdminClear)
+	mux.HandleFunc("/api/admin/patterns", s.handleAdminPatterns)
 
 	// Ranking API
 	mux.HandleFunc("/api/ranking/current", s.handleRankingCurrent)
@@ -76,6 +183,13 @@ func (s *Server) Handler() http.Handler {
 	return s.cors(mux)
 }
 
+// handleTickers returns the latest 24h tickers keyed by symbol.
+// GET /api/tickers?symbols=BTCUSDT,ETHUSDT
+// With sort or limit the response is an array instead:
+// GET /api/tickers?sort=change&order=desc&limit=50
+//   - sort: change|volume|trades|price|symbol (default: change)
+//   - order: asc|desc (default: asc for symbol, desc otherwise)
+//   - limit: int (default: 0 = all)
 func (s *Server) handleTickers(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -86,14 +200,42 @@ func (s *Server) handleTickers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	q := r.URL.Query()
+	sortBy := strings.ToLower(q.Get("sort"))
+	switch sortBy {
+	case "", "change", "volume", "trades", "price", "symbol":
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid sort parameter (change, volume, trades, price or symbol)"}`))
+		return
+	}
+	order := strings.ToLower(q.Get("order"))
+	if order != "" && order != "asc" && order != "desc" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid order parameter (asc or desc)"}`))
+		return
+	}
+	limit := 0
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 {
+			limit = v
+		}
+	}
+	asList := sortBy != "" || limit > 0
+
 	if s.TickerStore == nil {
 		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte("{}"))
+		if asList {
+			_, _ = w.Write([]byte("[]"))
+		} else {
+			_, _ = w.Write([]byte("{}"))
+		}
 		return
 	}
 
 	// 可选：按 symbols 过滤
-	q := r.URL.Query()
 	symbolsParam := q.Get("symbols")
 
 	var data map[string]*ticker.Ticker
@@ -103,13 +245,111 @@ func (s *Server) handleTickers(w http.ResponseWriter, r *http.Request) {
 	} else {
 		data = s.TickerStore.GetAll()
 	}
+	data = s.aliasTickers(data)
+
+	w.Header().Set("Content-Type", "application/json")
+	if !asList {
+		_ = s.writeJSON(w, data)
+		return
+	}
+
+	list := sortTickers(data, sortBy, order)
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	_ = s.writeJSON(w, list)
+}
+
+// handleTicker returns the latest 24h ticker of one symbol, 404 if unknown.
+// GET /api/ticker/{symbol}
+func (s *Server) handleTicker(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := binance.NormalizeSymbol(strings.TrimPrefix(r.URL.Path, "/api/ticker/"))
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+
+	if s.TickerStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	t, ok := s.TickerStore.Get(symbol)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"no ticker data found for symbol"}`))
+		return
+	}
+	if s.SymbolAliases != nil {
+		t.Alias = s.aliasFor(t.Symbol)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(data)
+	_ = s.writeJSON(w, t)
+}
+
+// sortTickers flattens tickers into a slice ordered by sortBy, ties broken by symbol.
+func sortTickers(data map[string]*ticker.Ticker, sortBy, order string) []*ticker.Ticker {
+	if sortBy == "" {
+		sortBy = "change"
+	}
+	if order == "" {
+		order = "desc"
+		if sortBy == "symbol" {
+			order = "asc"
+		}
+	}
+
+	key := func(t *ticker.Ticker) float64 {
+		switch sortBy {
+		case "volume":
+			return t.QuoteVolume
+		case "trades":
+			return float64(t.TradeCount)
+		case "price":
+			return t.LastPrice
+		case "symbol":
+			return 0
+		default:
+			return t.PricePercent
+		}
+	}
+
+	list := make([]*ticker.Ticker, 0, len(data))
+	for _, t := range data {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if ka, kb := key(a), key(b); ka != kb {
+			if order == "asc" {
+				return ka < kb
+			}
+			return ka > kb
+		}
+		if order == "asc" || sortBy != "symbol" {
+			return a.Symbol < b.Symbol
+		}
+		return a.Symbol > b.Symbol
+	})
+	return list
 }
 
-// handlePatterns returns pattern signal history.
-// GET /api/patterns?limit=100&symbol=BTCUSDT&pattern=hammer&direction=bullish
+// handlePatterns returns pattern signal history (newest first).
+// GET /api/patterns?limit=100&offset=0&symbol=BTCUSDT&pattern=hammer&direction=bullish
+// The total number of matches is returned in the X-Total-Count header.
 func (s *Server) handlePatterns(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -127,7 +367,7 @@ func (s *Server) handlePatterns(w http.ResponseWriter, r *http.Request) {
 	}
 
 	q := r.URL.Query()
-	symbol := q.Get("symbol")
+	symbol := binance.NormalizeSymbol(q.Get("symbol"))
 	patternType := q.Get("pattern")
 	direction := q.Get("direction")
 	limitStr := q.Get("limit")
@@ -139,16 +379,146 @@ func (s *Server) handlePatterns(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	minRank := ""
+	if v := q.Get("min_rank"); v != "" {
+		// An unescaped "+" (min_rank=B+) decodes as a space
+		rank, err := pattern.ParseEfficiencyRank(strings.ReplaceAll(v, " ", "+"))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid min_rank (A+ to J-)"}`))
+			return
+		}
+		minRank = rank
+	}
+
 	opts := pattern.QueryOptions{
 		Symbol:    symbol,
 		Pattern:   pattern.PatternType(patternType),
 		Direction: pattern.Direction(direction),
 		Limit:     limit,
+		Offset:    parseOffset(q.Get("offset")),
+		MinRank:   minRank,
+	}
+
+	res, total := s.PatternHistory.QueryWithTotal(opts)
+	s.aliasPatterns(res)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, res)
+}
+
+// handlePatternTypes returns all known pattern types with their statistics.
+// GET /api/patterns/types
+func (s *Server) handlePatternTypes(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, pattern.AllPatternStats())
+}
+
+// PatternSummaryResponse is the response of /api/patterns/summary.
+type PatternSummaryResponse struct {
+	Window   string                   `json:"window"`
+	Patterns []pattern.PatternSummary `json:"patterns"`
+}
+
+// handlePatternSummary returns per-pattern detection counts and symbols
+// within a recent window, most frequent first.
+// GET /api/patterns/summary?window=1h
+func (s *Server) handlePatternSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	window := time.Hour
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid window"}`))
+			return
+		}
+		window = d
+	}
+
+	resp := PatternSummaryResponse{
+		Window:   window.String(),
+		Patterns: []pattern.PatternSummary{},
+	}
+	if s.PatternHistory != nil {
+		resp.Patterns = s.PatternHistory.Summary(window)
+		s.aliasSummaries(resp.Patterns)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
+
+// PatternLatestResponse is the response of /api/patterns/{symbol}/latest.
+// Each field is the symbol's most recent pattern in that direction, or null.
+type PatternLatestResponse struct {
+	Bullish *pattern.Signal `json:"bullish"`
+	Bearish *pattern.Signal `json:"bearish"`
+	Neutral *pattern.Signal `json:"neutral"`
+}
+
+// handlePatternLatest returns the latest pattern per direction for a symbol.
+// GET /api/patterns/{symbol}/latest
+func (s *Server) handlePatternLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/patterns/")
+	rest, ok := strings.CutSuffix(path, "/latest")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	symbol := binance.NormalizeSymbol(rest)
+	if symbol == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+
+	var resp PatternLatestResponse
+	if s.PatternHistory != nil {
+		latest := func(dir pattern.Direction) *pattern.Signal {
+			res := s.PatternHistory.Query(pattern.QueryOptions{Symbol: symbol, Direction: dir, Limit: 1})
+			if len(res) == 0 {
+				return nil
+			}
+			s.aliasPattern(&res[0])
+			return &res[0]
+		}
+		resp.Bullish = latest(pattern.DirectionBullish)
+		resp.Bearish = latest(pattern.DirectionBearish)
+		resp.Neutral = latest(pattern.DirectionNeutral)
 	}
 
-	res := s.PatternHistory.Query(opts)
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(res)
+	_ = s.writeJSON(w, resp)
 }
 
 // handleKlines returns kline data for a symbol (for debugging).
@@ -170,22 +540,60 @@ func (s *Server) handleKlines(w http.ResponseWriter, r *http.Request) {
 	}
 
 	q := r.URL.Query()
-	symbol := q.Get("symbol")
+	symbol := binance.NormalizeSymbol(q.Get("symbol"))
 	if symbol == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
 		return
 	}
 
-	klines, ok := s.KlineStore.GetAllKlines(symbol)
-	if !ok {
+	// Encode straight from the store; the buffer keeps slow clients from
+	// holding the store's read lock.
+	var buf bytes.Buffer
+	ok, err := s.KlineStore.WriteAllKlinesJSON(&buf, symbol)
+	if !ok || err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte("[]"))
 		return
 	}
+	b := s.rewriteJSON(buf.Bytes())
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(b, '\n'))
+}
+
+// handleKlineCurrent returns the current forming kline and its time to close.
+// GET /api/klines/current?symbol=BTCUSDT
+func (s *Server) handleKlineCurrent(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := binance.NormalizeSymbol(r.URL.Query().Get("symbol"))
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+
+	if s.KlineStore == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	current, ok := s.KlineStore.CurrentWithMeta(symbol)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(klines)
+	_ = s.writeJSON(w, current)
 }
 
 // handleKlineStats returns statistics about kline data in memory.
@@ -208,26 +616,65 @@ func (s *Server) handleKlineStats(w http.ResponseWriter, r *http.Request) {
 
 	stats := s.KlineStore.Stats()
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(stats)
+	_ = s.writeJSON(w, stats)
 }
 
 // RuntimeStats contains runtime statistics.
 type RuntimeStats struct {
-	Goroutines     int     `json:"goroutines"`
-	HeapMB         float64 `json:"heap_mb"`
-	SysMB          float64 `json:"sys_mb"`
-	NumGC          uint32  `json:"num_gc"`
-	KlineSymbols   int     `json:"kline_symbols"`
-	Patterns       int     `json:"patterns"`
-	Signals        int     `json:"signals"`
-	Symbols        int     `json:"symbols"` // unique symbols in signal history
-	Uptime         string  `json:"uptime"`
-	SSESubscribers int     `json:"sse_subscribers"`
-	Version        string  `json:"version"`
-}
-
-// Version can be set at build time via -ldflags
-var Version = "dev"
+	Goroutines           int     `json:"goroutines"`
+	HeapMB               float64 `json:"heap_mb"`
+	SysMB                float64 `json:"sys_mb"`
+	NumGC                uint32  `json:"num_gc"`
+	KlineSymbols         int     `json:"kline_symbols"`
+	Patterns             int     `json:"patterns"`
+	PatternsDegraded     bool    `json:"patterns_degraded"`          // pattern history persistence suspended after write failures
+	PatternsEnabled      *bool   `json:"patterns_enabled,omitempty"` // pattern detection switch; absent without a PatternSwitch
+	Signals              int     `json:"signals"`
+	SignalsPendingWrites int     `json:"signals_pending_writes"` // failed history appends awaiting retry
+	SignalsDeadLettered  int64   `json:"signals_dead_lettered"`  // history appends given up on, kept in the dead-letter file
+	Symbols              int     `json:"symbols"`                // unique symbols in signal history
+	Uptime               string  `json:"uptime"`
+	SSESubscribers       int     `json:"sse_subscribers"`
+	TickerDropped        uint64  `json:"ticker_broadcast_dropped"` // ticker batches dropped for slow SSE subscribers
+	Version              string  `json:"version"`
+}
+
+// Build metadata, set at build time via -ldflags, e.g.
+// -X example.com/binance-pivot-monitor/internal/httpapi.Version=1.2.0 (see build.sh)
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// VersionInfo is the build metadata of the running binary.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// handleVersion returns the build metadata, for deploy scripts.
+// GET /api/version
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, VersionInfo{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	})
+}
 
 var startTime = time.Now()
 
@@ -243,37 +690,621 @@ func (s *Server) handleRuntime(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
-	stats := RuntimeStats{
-		Goroutines: runtime.NumGoroutine(),
-		HeapMB:     float64(m.HeapAlloc) / 1024 / 1024,
-		SysMB:      float64(m.Sys) / 1024 / 1024,
-		NumGC:      m.NumGC,
-		Uptime:     time.Since(startTime).Round(time.Second).String(),
-		Version:    Version,
+	stats := s.runtimeStats()
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, stats)
+}
+
+// runtimeStats collects runtime statistics from the configured subsystems.
+func (s *Server) runtimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		HeapMB:     float64(m.HeapAlloc) / 1024 / 1024,
+		SysMB:      float64(m.Sys) / 1024 / 1024,
+		NumGC:      m.NumGC,
+		Uptime:     time.Since(startTime).Round(time.Second).String(),
+		Version:    Version,
+	}
+
+	if s.KlineStore != nil {
+		stats.KlineSymbols = s.KlineStore.SymbolCount()
+	}
+	if s.PatternHistory != nil {
+		stats.Patterns = s.PatternHistory.Count()
+		stats.PatternsDegraded = s.PatternHistory.Degraded()
+	}
+	if s.PatternSwitch != nil {
+		enabled := s.PatternSwitch.PatternsEnabled()
+		stats.PatternsEnabled = &enabled
+	}
+	if s.History != nil {
+		stats.Signals = s.History.Count()
+		stats.SignalsPendingWrites = s.History.PendingWrites()
+		stats.SignalsDeadLettered = s.History.DeadLettered()
+		stats.Symbols = s.History.SymbolCount()
+	}
+	if s.SignalBroker != nil {
+		stats.SSESubscribers = s.SignalBroker.SubscriberCount()
+	}
+	if s.TickerMonitor != nil {
+		stats.TickerDropped = s.TickerMonitor.Dropped()
+	}
+	return stats
+}
+
+// ExportSignals summarizes the pivot signal history.
+type ExportSignals struct {
+	Count   int `json:"count"`
+	Symbols int `json:"symbols"`
+}
+
+// ExportPatterns summarizes the pattern history.
+type ExportPatterns struct {
+	Count    int  `json:"count"`
+	Degraded bool `json:"degraded"`
+}
+
+// ExportResponse is a full state snapshot for debugging.
+// Sections for subsystems that are not configured are omitted.
+type ExportResponse struct {
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Runtime     RuntimeStats               `json:"runtime"`
+	PivotStatus *pivot.PivotStatusResponse `json:"pivot_status,omitempty"`
+	Klines      *kline.StoreStats          `json:"klines,omitempty"`
+	Signals     *ExportSignals             `json:"signals,omitempty"`
+	Patterns    *ExportPatterns            `json:"patterns,omitempty"`
+}
+
+// handleExport returns pivot status, kline stats, signal/pattern counts and
+// runtime stats in one document.
+// GET /api/export
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := ExportResponse{
+		GeneratedAt: time.Now().UTC(),
+		Runtime:     s.runtimeStats(),
+	}
+	if s.PivotStatus != nil {
+		status := s.PivotStatus.PivotStatus()
+		resp.PivotStatus = &status
+	}
+	if s.KlineStore != nil {
+		stats := s.KlineStore.Stats()
+		resp.Klines = &stats
+	}
+	if s.History != nil {
+		resp.Signals = &ExportSignals{
+			Count:   s.History.Count(),
+			Symbols: s.History.SymbolCount(),
+		}
+	}
+	if s.PatternHistory != nil {
+		resp.Patterns = &ExportPatterns{
+			Count:    s.PatternHistory.Count(),
+			Degraded: s.PatternHistory.Degraded(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
+
+func (s *Server) handlePivotStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.PivotStatus == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := s.PivotStatus.PivotStatus()
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
+
+// PivotResponse is the response for /api/pivots/{symbol}
+type PivotResponse struct {
+	Symbol    string           `json:"symbol"`
+	Daily     *pivot.Levels    `json:"daily,omitempty"`
+	Weekly    *pivot.Levels    `json:"weekly,omitempty"`
+	DailyMid  *pivot.MidPivots `json:"daily_mid,omitempty"`
+	WeeklyMid *pivot.MidPivots `json:"weekly_mid,omitempty"`
+}
+
+// handlePivots returns pivot levels for a specific symbol.
+// GET /api/pivots/{symbol}?period=1d|1w (optional, returns both if omitted)
+// GET /api/pivots/{symbol}/distance is served by handlePivotDistance.
+// GET /api/pivots/{symbol}/history is served by handlePivotHistory.
+func (s *Server) handlePivots(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.PivotStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"pivot store not available"}`))
+		return
+	}
+
+	// Extract symbol from path: /api/pivots/{symbol}
+	path := strings.TrimPrefix(r.URL.Path, "/api/pivots/")
+	if rest, ok := strings.CutSuffix(path, "/distance"); ok {
+		s.handlePivotDistance(w, binance.NormalizeSymbol(rest))
+		return
+	}
+	if rest, ok := strings.CutSuffix(path, "/history"); ok {
+		s.handlePivotHistory(w, r, binance.NormalizeSymbol(rest))
+		return
+	}
+	symbol := binance.NormalizeSymbol(path)
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+
+	q := r.URL.Query()
+	period := strings.ToLower(q.Get("period"))
+
+	resp := PivotResponse{Symbol: symbol}
+
+	// Get daily levels
+	if period == "" || period == "1d" || period == "daily" {
+		if levels, ok := s.PivotStore.GetLevels(pivot.PeriodDaily, symbol); ok {
+			mid := levels.MidPivots()
+			resp.Daily = &levels
+			resp.DailyMid = &mid
+		}
+	}
+
+	// Get weekly levels
+	if period == "" || period == "1w" || period == "weekly" {
+		if levels, ok := s.PivotStore.GetLevels(pivot.PeriodWeekly, symbol); ok {
+			mid := levels.MidPivots()
+			resp.Weekly = &levels
+			resp.WeeklyMid = &mid
+		}
+	}
+
+	// Return 404 if no data found
+	if resp.Daily == nil && resp.Weekly == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"error":"no pivot data found for symbol"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
+
+// maxPivotBatchSymbols caps the number of symbols per /api/pivots/batch request.
+const maxPivotBatchSymbols = 500
+
+// PivotBatchRequest is the body of POST /api/pivots/batch.
+type PivotBatchRequest struct {
+	Symbols []string `json:"symbols"`
+	Period  string   `json:"period"` // 1d|1w, default 1d
+}
+
+// PivotBatchResponse maps symbols to their levels for one period.
+// Symbols without pivot data are listed in Missing.
+type PivotBatchResponse struct {
+	Period  string                  `json:"period"`
+	Levels  map[string]pivot.Levels `json:"levels"`
+	Missing []string                `json:"missing"`
+}
+
+// handlePivotsBatch returns pivot levels for many symbols in one request.
+// POST /api/pivots/batch {"symbols":["BTCUSDT",...],"period":"1d"}
+func (s *Server) handlePivotsBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.PivotStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"pivot store not available"}`))
+		return
+	}
+
+	var req PivotBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid request body"}`))
+		return
+	}
+	if len(req.Symbols) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbols required"}`))
+		return
+	}
+	if len(req.Symbols) > maxPivotBatchSymbols {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, `{"error":"too many symbols (max %d)"}`, maxPivotBatchSymbols)
+		return
+	}
+
+	var period pivot.Period
+	switch strings.ToLower(strings.TrimSpace(req.Period)) {
+	case "", "1d", "daily":
+		period = pivot.PeriodDaily
+	case "1w", "weekly":
+		period = pivot.PeriodWeekly
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid period (1d or 1w)"}`))
+		return
+	}
+
+	resp := PivotBatchResponse{
+		Period:  string(period),
+		Levels:  make(map[string]pivot.Levels, len(req.Symbols)),
+		Missing: []string{},
+	}
+	for _, sym := range req.Symbols {
+		sym = binance.NormalizeSymbol(sym)
+		if sym == "" {
+			continue
+		}
+		if _, seen := resp.Levels[sym]; seen {
+			continue
+		}
+		if levels, ok := s.PivotStore.GetLevels(period, sym); ok {
+			resp.Levels[sym] = levels
+		} else {
+			resp.Missing = append(resp.Missing, sym)
+		}
+	}
+
+	_ = s.writeJSON(w, resp)
+}
+
+// PivotCoverageResponse reports how many actively traded symbols (those in
+// the ticker store) have pivot levels for a period. Missing lists the ones
+// without, for which no level signals can fire.
+type PivotCoverageResponse struct {
+	Period      string   `json:"period"`
+	Active      int      `json:"active"`
+	Covered     int      `json:"covered"`
+	CoveragePct float64  `json:"coverage_pct"` // 100 when there are no active symbols
+	Missing     []string `json:"missing"`      // Sorted
+}
+
+// handlePivotCoverage lists ticker symbols lacking pivot levels, e.g. after
+// a partial refresh failure.
+// GET /api/pivots/coverage?period=1d
+func (s *Server) handlePivotCoverage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.PivotStore == nil || s.TickerStore == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"pivot or ticker store not available"}`))
+		return
+	}
+
+	var period pivot.Period
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("period"))) {
+	case "", "1d", "daily":
+		period = pivot.PeriodDaily
+	case "1w", "weekly":
+		period = pivot.PeriodWeekly
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid period (1d or 1w)"}`))
+		return
+	}
+
+	resp := PivotCoverageResponse{Period: string(period), Missing: []string{}}
+	for sym := range s.TickerStore.GetAll() {
+		resp.Active++
+		if _, ok := s.PivotStore.GetLevels(period, sym); ok {
+			resp.Covered++
+		} else {
+			resp.Missing = append(resp.Missing, sym)
+		}
+	}
+	sort.Strings(resp.Missing)
+	resp.CoveragePct = 100
+	if resp.Active > 0 {
+		resp.CoveragePct = float64(resp.Covered) * 100 / float64(resp.Active)
+	}
+
+	_ = s.writeJSON(w, resp)
+}
+
+// PivotDistance is the distance from the latest price to one period's levels.
+type PivotDistance struct {
+	Resistance *pivot.LevelDistance  `json:"resistance,omitempty"` // Nearest level above the price
+	Support    *pivot.LevelDistance  `json:"support,omitempty"`    // Nearest level at or below the price
+	Levels     []pivot.LevelDistance `json:"levels"`               // All levels, nearest first
+}
+
+// PivotCalcResponse is the response for GET /api/pivots/calc.
+type PivotCalcResponse struct {
+	Method string       `json:"method"`
+	Levels pivot.Levels `json:"levels"`
+}
+
+// handlePivotRaw serves the pivot file of a period exactly as the refresher
+// wrote it, for auditing.
+// GET /api/pivots/raw?period=1d|1w (default 1d)
+func (s *Server) handlePivotRaw(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var period pivot.Period
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("period"))) {
+	case "", "1d", "daily":
+		period = pivot.PeriodDaily
+	case "1w", "weekly":
+		period = pivot.PeriodWeekly
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid period (1d or 1w)"}`))
+		return
+	}
+
+	if s.PivotFiles == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"pivot file not written yet"}`))
+		return
+	}
+	b, err := s.PivotFiles.RawFile(period)
+	if errors.Is(err, fs.ErrNotExist) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"pivot file not written yet"}`))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":"failed to read pivot file"}`))
+		return
+	}
+	_, _ = w.Write(b)
+}
+
+// handlePivotCalc computes levels from caller-supplied OHLC for what-if analysis.
+// GET /api/pivots/calc?high=105&low=95&close=100&method=classic
+// method is camarilla (the default, as used by the monitor) or classic.
+func (s *Server) handlePivotCalc(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	method := strings.ToLower(q.Get("method"))
+	if method == "" {
+		method = pivot.MethodCamarilla
+	}
+	if method != pivot.MethodCamarilla && method != pivot.MethodClassic {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"unsupported method (camarilla or classic)"}`))
+		return
+	}
+
+	var ohlc [3]float64
+	for i, name := range []string{"high", "low", "close"} {
+		v, err := strconv.ParseFloat(q.Get(name), 64)
+		if err != nil || !(v > 0) || math.IsInf(v, 0) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"` + name + ` must be a positive number"}`))
+			return
+		}
+		ohlc[i] = v
+	}
+	high, low, closePrice := ohlc[0], ohlc[1], ohlc[2]
+	if high < low {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"high must be >= low"}`))
+		return
+	}
+
+	levels, err := pivot.CalculateWithMethod(method, high, low, closePrice)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid high/low/close"}`))
+		return
+	}
+
+	_ = s.writeJSON(w, PivotCalcResponse{Method: method, Levels: levels})
+}
+
+// PivotDistanceResponse is the response for GET /api/pivots/{symbol}/distance.
+type PivotDistanceResponse struct {
+	Symbol      string         `json:"symbol"`
+	Price       float64        `json:"price"`
+	PriceSource string         `json:"price_source"` // "ticker" or "kline"
+	Daily       *PivotDistance `json:"daily,omitempty"`
+	Weekly      *PivotDistance `json:"weekly,omitempty"`
+}
+
+// PivotHistoryResponse is the response for /api/pivots/{symbol}/history.
+type PivotHistoryResponse struct {
+	Symbol string                   `json:"symbol"`
+	Period string                   `json:"period"`
+	Levels []pivot.HistoricalLevels `json:"levels"` // Newest first, current period included
+}
+
+// handlePivotHistory returns a symbol's levels for the current and past periods.
+// GET /api/pivots/{symbol}/history?period=1d|1w (default 1d)&n=7 (default all kept)
+func (s *Server) handlePivotHistory(w http.ResponseWriter, r *http.Request, symbol string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+
+	q := r.URL.Query()
+	var period pivot.Period
+	switch strings.ToLower(q.Get("period")) {
+	case "", "1d", "daily":
+		period = pivot.PeriodDaily
+	case "1w", "weekly":
+		period = pivot.PeriodWeekly
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid period (1d or 1w)"}`))
+		return
+	}
+	n := 0
+	if v, err := strconv.Atoi(q.Get("n")); err == nil && v > 0 {
+		n = v
+	}
+
+	levels := s.PivotStore.LevelsHistory(period, symbol, n)
+	if len(levels) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"no pivot data found for symbol"}`))
+		return
+	}
+
+	_ = s.writeJSON(w, PivotHistoryResponse{Symbol: symbol, Period: string(period), Levels: levels})
+}
+
+// handlePivotDistance returns the nearest resistance/support levels for a symbol
+// relative to its latest price, for both periods.
+// GET /api/pivots/{symbol}/distance
+func (s *Server) handlePivotDistance(w http.ResponseWriter, symbol string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+
+	price, source := s.latestPrice(symbol)
+	if price <= 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"no price available for symbol"}`))
+		return
+	}
+
+	resp := PivotDistanceResponse{Symbol: symbol, Price: price, PriceSource: source}
+	if levels, ok := s.PivotStore.GetLevels(pivot.PeriodDaily, symbol); ok {
+		resp.Daily = newPivotDistance(levels, price)
+	}
+	if levels, ok := s.PivotStore.GetLevels(pivot.PeriodWeekly, symbol); ok {
+		resp.Weekly = newPivotDistance(levels, price)
 	}
 
-	if s.KlineStore != nil {
-		stats.KlineSymbols = s.KlineStore.SymbolCount()
+	if resp.Daily == nil && resp.Weekly == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"no pivot data found for symbol"}`))
+		return
 	}
-	if s.PatternHistory != nil {
-		stats.Patterns = s.PatternHistory.Count()
+
+	_ = s.writeJSON(w, resp)
+}
+
+// latestPrice returns the latest price for a symbol from the ticker store,
+// falling back to the close of the current kline. Returns 0 if neither is available.
+func (s *Server) latestPrice(symbol string) (float64, string) {
+	if s.TickerStore != nil {
+		if t, ok := s.TickerStore.Get(symbol); ok && t.LastPrice > 0 {
+			return t.LastPrice, "ticker"
+		}
 	}
-	if s.History != nil {
-		stats.Signals = s.History.Count()
-		stats.Symbols = s.History.SymbolCount()
+	if s.KlineStore != nil {
+		if k, ok := s.KlineStore.GetCurrentKline(symbol); ok && k.Close > 0 {
+			return k.Close, "kline"
+		}
 	}
-	if s.SignalBroker != nil {
-		stats.SSESubscribers = s.SignalBroker.SubscriberCount()
+	return 0, ""
+}
+
+func newPivotDistance(levels pivot.Levels, price float64) *PivotDistance {
+	pd := &PivotDistance{Levels: levels.Nearest(price)}
+	for i := range pd.Levels {
+		ld := &pd.Levels[i]
+		if ld.Distance > 0 && pd.Resistance == nil {
+			pd.Resistance = ld
+		}
+		if ld.Distance <= 0 && pd.Support == nil {
+			pd.Support = ld
+		}
 	}
+	return pd
+}
 
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(stats)
+	_, _ = w.Write([]byte(`{"ok":true}`))
 }
 
-func (s *Server) handlePivotStatus(w http.ResponseWriter, r *http.Request) {
+// parseOffset parses an offset query parameter; invalid or negative values yield 0.
+func parseOffset(v string) int {
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// handleHistoryNear returns signals for a symbol within pct percent of a price (newest first).
+// GET /api/history/near?symbol=BTCUSDT&price=50000&pct=1&limit=200
+func (s *Server) handleHistoryNear(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -283,26 +1314,51 @@ func (s *Server) handlePivotStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if s.PivotStatus == nil {
+	if s.History == nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		return
 	}
 
-	resp := s.PivotStatus.PivotStatus()
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
-}
+	q := r.URL.Query()
+	symbol := binance.NormalizeSymbol(q.Get("symbol"))
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
+		return
+	}
+	price, err := strconv.ParseFloat(q.Get("price"), 64)
+	if err != nil || price <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"price must be a positive number"}`))
+		return
+	}
+	pct := 1.0
+	if v := q.Get("pct"); v != "" {
+		pct, err = strconv.ParseFloat(v, 64)
+		if err != nil || pct < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"pct must be a non-negative number"}`))
+			return
+		}
+	}
+	limit := 200
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
 
-// PivotResponse is the response for /api/pivots/{symbol}
-type PivotResponse struct {
-	Symbol string        `json:"symbol"`
-	Daily  *pivot.Levels `json:"daily,omitempty"`
-	Weekly *pivot.Levels `json:"weekly,omitempty"`
+	res := s.History.QueryNearPrice(symbol, price, pct, limit)
+	s.aliasSignals(res)
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, res)
 }
 
-// handlePivots returns pivot levels for a specific symbol.
-// GET /api/pivots/{symbol}?period=1d|1w (optional, returns both if omitted)
-func (s *Server) handlePivots(w http.ResponseWriter, r *http.Request) {
+// handleHistoryClusters groups recent signals into per-symbol clusters whose
+// consecutive triggers are within gap, newest cluster first.
+// GET /api/history/clusters?symbol=BTCUSDT&period=1d&gap=10m&limit=1000
+// limit caps the number of signals clustered (newest first).
+func (s *Server) handleHistoryClusters(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -312,58 +1368,46 @@ func (s *Server) handlePivots(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if s.PivotStore == nil {
+	if s.History == nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
-		_, _ = w.Write([]byte(`{"error":"pivot store not available"}`))
-		return
-	}
-
-	// Extract symbol from path: /api/pivots/{symbol}
-	path := strings.TrimPrefix(r.URL.Path, "/api/pivots/")
-	symbol := strings.ToUpper(strings.TrimSpace(path))
-	if symbol == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
 		return
 	}
 
 	q := r.URL.Query()
-	period := strings.ToLower(q.Get("period"))
-
-	resp := PivotResponse{Symbol: symbol}
-
-	// Get daily levels
-	if period == "" || period == "1d" || period == "daily" {
-		if levels, ok := s.PivotStore.GetLevels(pivot.PeriodDaily, symbol); ok {
-			resp.Daily = &levels
+	gap := 10 * time.Minute
+	if v := q.Get("gap"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid gap parameter"}`))
+			return
 		}
+		gap = d
 	}
-
-	// Get weekly levels
-	if period == "" || period == "1w" || period == "weekly" {
-		if levels, ok := s.PivotStore.GetLevels(pivot.PeriodWeekly, symbol); ok {
-			resp.Weekly = &levels
+	limit := 1000
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
 		}
 	}
 
-	// Return 404 if no data found
-	if resp.Daily == nil && resp.Weekly == nil {
-		w.WriteHeader(http.StatusNotFound)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte(`{"error":"no pivot data found for symbol"}`))
-		return
+	res := s.History.Query(binance.NormalizeSymbol(q.Get("symbol")), q.Get("period"), "", "", "", limit)
+	clusters := signalpkg.ClusterSignals(res, gap)
+	if clusters == nil {
+		clusters = []signalpkg.Cluster{}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
-}
-
-func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	_, _ = w.Write([]byte(`{"ok":true}`))
+	_ = s.writeJSON(w, clusters)
 }
 
+// handleHistory returns pivot signal history (newest first, or oldest first
+// with order=asc; limit and offset always count from the newest).
+// GET /api/history?symbol=BTC&period=1d&level=R3,S3&direction=up&source=markPrice&limit=200&offset=0&order=desc
+// The total number of matches is returned in the X-Total-Count header.
+// When pattern enrichment stops early (HistoryEnrichLimit or
+// HistoryEnrichTimeout), the remaining signals carry no related_pattern and
+// the X-Enrichment-Truncated header is set to true.
 func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -401,7 +1445,7 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 		return strings.Join(all, ",")
 	}
 
-	symbol := getFirstCI("symbol")
+	symbol := binance.NormalizeSymbol(getFirstCI("symbol"))
 	period := getFirstCI("period")
 	level := getAllCI("level")
 	if level == "" {
@@ -417,7 +1461,19 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	res := s.History.Query(symbol, period, level, direction, source, limit)
+	offset := parseOffset(getFirstCI("offset"))
+
+	order := strings.ToLower(getFirstCI("order"))
+	if order != "" && order != "asc" && order != "desc" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid order parameter (asc or desc)"}`))
+		return
+	}
+
+	res, total := s.History.QueryPageOrdered(symbol, period, level, direction, source, offset, limit, order == "asc")
+	s.aliasSignals(res)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 
 	// Enrich signals with related pattern information from PatternHistory
 	if s.PatternHistory != nil {
@@ -426,9 +1482,30 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 			RelatedPattern *RelatedPatternInfo `json:"related_pattern,omitempty"`
 		}
 
+		enrichLimit := s.HistoryEnrichLimit
+		if enrichLimit == 0 {
+			enrichLimit = DefaultHistoryEnrichLimit
+		}
+		enrichTimeout := s.HistoryEnrichTimeout
+		if enrichTimeout == 0 {
+			enrichTimeout = DefaultHistoryEnrichTimeout
+		}
+		var deadline time.Time
+		if enrichTimeout > 0 {
+			deadline = time.Now().Add(enrichTimeout)
+		}
+
 		enriched := make([]EnrichedSignal, len(res))
+		truncated := false
 		for i, sig := range res {
 			enriched[i] = EnrichedSignal{Signal: sig}
+			if truncated {
+				continue
+			}
+			if (enrichLimit > 0 && i >= enrichLimit) || (!deadline.IsZero() && time.Now().After(deadline)) || r.Context().Err() != nil {
+				truncated = true
+				continue
+			}
 
 			// Find related patterns for this symbol within 60 minutes (before or after signal)
 			patterns := s.PatternHistory.QueryBySymbolAndTime(sig.Symbol, sig.TriggeredAt, 60*time.Minute)
@@ -470,13 +1547,199 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if truncated {
+			w.Header().Set("X-Enrichment-Truncated", "true")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = s.writeJSON(w, enriched)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, res)
+}
+
+// SignalPatternsResponse is the response of /api/signals/{id}/patterns.
+type SignalPatternsResponse struct {
+	Signal   signalpkg.Signal     `json:"signal"`
+	Window   string               `json:"window"`
+	Patterns []RelatedPatternInfo `json:"patterns"`
+}
+
+// handleSignalPatterns returns all patterns correlated with a pivot signal.
+// GET /api/signals/{id}/patterns?window=60m
+// Patterns are ordered by time proximity to the signal (closest first).
+func (s *Server) handleSignalPatterns(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/signals/")
+	id, sub, _ := strings.Cut(rest, "/")
+	if id == "" || sub != "patterns" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if s.History == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	window := 60 * time.Minute
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid window"}`))
+			return
+		}
+		window = d
+	}
+
+	sig, ok := s.History.Get(id)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"signal not found"}`))
+		return
+	}
+
+	s.aliasSignal(&sig)
+	resp := SignalPatternsResponse{
+		Signal:   sig,
+		Window:   window.String(),
+		Patterns: []RelatedPatternInfo{},
+	}
+	if s.PatternHistory != nil {
+		patterns := s.PatternHistory.QueryBySymbolAndTime(sig.Symbol, sig.TriggeredAt, window)
+		for _, pat := range patterns {
+			resp.Patterns = append(resp.Patterns, RelatedPatternInfo{
+				ID:             pat.ID,
+				Pattern:        string(pat.Pattern),
+				PatternCN:      pat.PatternCN,
+				Direction:      string(pat.Direction),
+				Confidence:     pat.Confidence,
+				UpPercent:      pat.UpPercent,
+				DownPercent:    pat.DownPercent,
+				EfficiencyRank: pat.EfficiencyRank,
+				Correlation:    string(signalpkg.Correlate(sig, pat)),
+				DetectedAt:     pat.DetectedAt,
+				Count:          len(patterns),
+				TimeDiff:       formatTimeDiff(sig.TriggeredAt.Sub(pat.DetectedAt)),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
+
+// SymbolActivity is one symbol's entry in /api/signals/activity.
+type SymbolActivity struct {
+	Symbol string `json:"symbol"`
+	Alias  string `json:"alias,omitempty"`
+	Count  int    `json:"count"` // Distinct levels (per period) crossed today
+}
+
+// SignalActivityResponse is the response for /api/signals/activity.
+type SignalActivityResponse struct {
+	Since   time.Time        `json:"since"` // Start of the current activity day
+	Symbols []SymbolActivity `json:"symbols"`
+}
+
+// handleSignalActivity returns how many distinct pivot levels each symbol
+// crossed today, most active first.
+// GET /api/signals/activity
+func (s *Server) handleSignalActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.SignalActivity == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"signal activity not available"}`))
+		return
+	}
+
+	since, counts := s.SignalActivity.SignalActivity(time.Now())
+	resp := SignalActivityResponse{Since: since, Symbols: make([]SymbolActivity, 0, len(counts))}
+	for symbol, n := range counts {
+		a := SymbolActivity{Symbol: symbol, Count: n}
+		if s.SymbolAliases != nil {
+			a.Alias = s.aliasFor(symbol)
+		}
+		resp.Symbols = append(resp.Symbols, a)
+	}
+	sort.Slice(resp.Symbols, func(i, j int) bool {
+		if resp.Symbols[i].Count != resp.Symbols[j].Count {
+			return resp.Symbols[i].Count > resp.Symbols[j].Count
+		}
+		return resp.Symbols[i].Symbol < resp.Symbols[j].Symbol
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = s.writeJSON(w, resp)
+}
+
+// CombinerRecentResponse is the response for /api/combiner/recent.
+type CombinerRecentResponse struct {
+	Symbol   string             `json:"symbol"`
+	Window   string             `json:"window"`   // Current correlation window
+	Pivots   []signalpkg.Signal `json:"pivots"`   // Pivot signals held for correlation
+	Patterns []pattern.Signal   `json:"patterns"` // Pattern signals held for correlation
+}
+
+// handleCombinerRecent returns the pivot and pattern signals the combiner
+// currently holds for a symbol, to debug why signals did or didn't correlate.
+// GET /api/combiner/recent?symbol=BTCUSDT
+func (s *Server) handleCombinerRecent(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.SignalCombiner == nil {
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(enriched)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"signal combiner not enabled"}`))
+		return
+	}
+
+	symbol := binance.NormalizeSymbol(r.URL.Query().Get("symbol"))
+	if symbol == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"symbol parameter required"}`))
 		return
 	}
 
+	resp := CombinerRecentResponse{
+		Symbol:   symbol,
+		Window:   s.SignalCombiner.Window().String(),
+		Pivots:   s.SignalCombiner.GetRecentPivots(symbol),
+		Patterns: s.SignalCombiner.GetRecentPatterns(symbol),
+	}
+	s.aliasSignals(resp.Pivots)
+	s.aliasPatterns(resp.Patterns)
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(res)
+	_ = s.writeJSON(w, resp)
 }
 
 // RelatedPatternInfo contains pattern information for enriched signals.
@@ -539,6 +1802,19 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(data)
 }
 
+// writeSignalEvent writes a signal SSE frame with its ID as the event id,
+// so clients can resume with Last-Event-ID.
+func (s *Server) writeSignalEvent(w io.Writer, sig signalpkg.Signal) {
+	s.aliasSignal(&sig)
+	b, err := s.marshalJSON(sig)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "id: %s\n", sig.ID)
+	_, _ = fmt.Fprintf(w, "event: signal\n")
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(string(b), "\n", ""))
+}
+
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
@@ -554,21 +1830,27 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
+	if _, ok := w.(http.Flusher); !ok {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	// 订阅信号（超过 MaxSSEConnections 时拒绝）
+	signalCh, ok := s.SignalBroker.TrySubscribe(256, s.MaxSSEConnections)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"too many SSE connections"}`))
+		return
+	}
+	defer s.SignalBroker.Unsubscribe(signalCh)
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 
-	// 订阅信号
-	signalCh := s.SignalBroker.Subscribe(256)
-	defer s.SignalBroker.Unsubscribe(signalCh)
-
 	// 订阅 ticker（如果可用）
 	var tickerCh chan ticker.TickerBatch
 	if s.TickerMonitor != nil {
@@ -583,58 +1865,120 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 		defer s.PatternBroker.Unsubscribe(patternCh)
 	}
 
+	// Every frame is written under a deadline so a stalled client (full TCP
+	// buffer) cannot block the handler; a failed flush ends the stream.
+	rc := http.NewResponseController(w)
+	writeTimeout := s.SSEWriteTimeout
+	if writeTimeout == 0 {
+		writeTimeout = DefaultSSEWriteTimeout
+	}
+	armDeadline := func() {
+		if writeTimeout > 0 {
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+		}
+	}
+
+	armDeadline()
 	_, _ = fmt.Fprintf(w, ": connected %s\n\n", time.Now().UTC().Format(time.RFC3339))
-	flusher.Flush()
+
+	// Resume: replay signals newer than Last-Event-ID (or ?since=) from history.
+	// Subscribed above first, so nothing is lost; replayed IDs are skipped when
+	// they also arrive live. Unknown IDs just go live.
+	var replayed map[string]struct{}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("since")
+	}
+	if lastID != "" && s.History != nil {
+		if missed, ok := s.History.Since(lastID); ok {
+			replayed = make(map[string]struct{}, len(missed))
+			for _, sig := range missed {
+				replayed[sig.ID] = struct{}{}
+				s.writeSignalEvent(w, sig)
+			}
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
 
 	keepAlive := time.NewTicker(15 * time.Second)
 	defer keepAlive.Stop()
 
+	// Closed by MarkReady; nil once the ready event has been sent.
+	readyCh := s.ready.wait()
+
 	for {
 		select {
 		case <-r.Context().Done():
 			return
 
+		case <-readyCh:
+			readyCh = nil
+			b, err := s.marshalJSON(map[string]time.Time{"ready_at": s.readyAt()})
+			if err != nil {
+				continue
+			}
+			armDeadline()
+			_, _ = fmt.Fprintf(w, "event: ready\n")
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", b)
+			if rc.Flush() != nil {
+				return
+			}
+
 		case <-keepAlive.C:
+			armDeadline()
 			_, _ = fmt.Fprint(w, ": ping\n\n")
-			flusher.Flush()
+			if rc.Flush() != nil {
+				return
+			}
 
 		case sig, ok := <-signalCh:
 			if !ok {
 				return
 			}
-			b, err := json.Marshal(sig)
-			if err != nil {
+			if _, dup := replayed[sig.ID]; dup {
 				continue
 			}
-			_, _ = fmt.Fprintf(w, "event: signal\n")
-			_, _ = fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(string(b), "\n", ""))
-			flusher.Flush()
+			armDeadline()
+			s.writeSignalEvent(w, sig)
+			if rc.Flush() != nil {
+				return
+			}
 
 		case batch, ok := <-tickerCh:
 			if !ok {
 				tickerCh = nil
 				continue
 			}
-			b, err := json.Marshal(batch)
+			batch.Tickers = s.aliasTickers(batch.Tickers)
+			b, err := s.marshalJSON(batch)
 			if err != nil {
 				continue
 			}
+			armDeadline()
 			_, _ = fmt.Fprintf(w, "event: ticker\n")
 			_, _ = fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(string(b), "\n", ""))
-			flusher.Flush()
+			if rc.Flush() != nil {
+				return
+			}
 
 		case pat, ok := <-patternCh:
 			if !ok {
 				patternCh = nil
 				continue
 			}
-			b, err := json.Marshal(pat)
+			s.aliasPattern(&pat)
+			b, err := s.marshalJSON(pat)
 			if err != nil {
 				continue
 			}
+			armDeadline()
 			_, _ = fmt.Fprintf(w, "event: pattern\n")
 			_, _ = fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(string(b), "\n", ""))
-			flusher.Flush()
+			if rc.Flush() != nil {
+				return
+			}
 		}
 	}
 }
@@ -687,8 +2031,9 @@ func (s *Server) cors(next http.Handler) http.Handler {
 		if allowOrigin != "" {
 			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 			w.Header().Add("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Enrichment-Truncated")
 		}
 
 		if r.Method == http.MethodOptions {
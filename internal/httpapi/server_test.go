@@ -0,0 +1,1033 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/pattern"
+	"example.com/binance-pivot-monitor/internal/pivot"
+	"example.com/binance-pivot-monitor/internal/ranking"
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+	"example.com/binance-pivot-monitor/internal/sse"
+	"example.com/binance-pivot-monitor/internal/ticker"
+)
+
+func TestHandleSSE_MaxConnections(t *testing.T) {
+	broker := sse.NewBroker[signalpkg.Signal]()
+	s := New(broker, nil, nil)
+	s.MaxSSEConnections = 2
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// connect opens a stream and waits for the greeting, so the subscription is live.
+	connect := func() (*http.Response, context.CancelFunc) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/sse", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			cancel()
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			line, err := bufio.NewReader(resp.Body).ReadString('\n')
+			if err != nil || !strings.HasPrefix(line, ": connected") {
+				t.Fatalf("unexpected greeting %q: %v", line, err)
+			}
+		}
+		return resp, cancel
+	}
+
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, c := range cancels {
+			c()
+		}
+	}()
+	for i := 0; i < 2; i++ {
+		resp, cancel := connect()
+		cancels = append(cancels, cancel)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("connection %d: status %d, want 200", i, resp.StatusCode)
+		}
+	}
+
+	resp, cancel := connect()
+	cancel()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("connection over cap: status %d, want 503", resp.StatusCode)
+	}
+	if got := broker.SubscriberCount(); got != 2 {
+		t.Errorf("SubscriberCount = %d after rejection, want 2", got)
+	}
+
+	// Closing a stream frees its slot
+	cancels[0]()
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.SubscriberCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("SubscriberCount = %d after disconnect, want 1", broker.SubscriberCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, cancel = connect()
+	cancels = append(cancels, cancel)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("connection after disconnect: status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestHandleSSE_WriteTimeout(t *testing.T) {
+	broker := sse.NewBroker[signalpkg.Signal]()
+	s := New(broker, nil, nil)
+	s.SSEWriteTimeout = 100 * time.Millisecond
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// A client that sends the request and never reads the stream
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if tc, ok := conn.(*net.TCPConn); ok {
+		_ = tc.SetReadBuffer(4096)
+	}
+	_, _ = conn.Write([]byte("GET /api/sse HTTP/1.1\r\nHost: test\r\n\r\n"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for broker.SubscriberCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("stream did not subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Publish large signals until the socket buffers fill and the handler
+	// gives up on the stalled client, releasing its subscription
+	big := strings.Repeat("X", 64<<10)
+	deadline = time.Now().Add(10 * time.Second)
+	for broker.SubscriberCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("handler still blocked on a stalled client")
+		}
+		broker.Publish(signalpkg.Signal{ID: "x", Symbol: big})
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandleSSE_Ready(t *testing.T) {
+	broker := sse.NewBroker[signalpkg.Signal]()
+	s := New(broker, nil, nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// connect opens a stream and returns a reader past the greeting.
+	connect := func() (*bufio.Reader, context.CancelFunc) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/sse", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			cancel()
+			t.Fatalf("request failed: %v", err)
+		}
+		br := bufio.NewReader(resp.Body)
+		if line, err := br.ReadString('\n'); err != nil || !strings.HasPrefix(line, ": connected") {
+			t.Fatalf("unexpected greeting %q: %v", line, err)
+		}
+		return br, cancel
+	}
+
+	// nextEvent skips blank lines and returns the next "event:" name.
+	nextEvent := func(br *bufio.Reader) string {
+		t.Helper()
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if strings.HasPrefix(line, "event: ") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+			}
+		}
+	}
+
+	early, cancel := connect()
+	defer cancel()
+
+	// Not ready yet: the first event is the signal
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.SubscriberCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("stream did not subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	broker.Publish(signalpkg.Signal{ID: "a", Symbol: "BTCUSDT"})
+	if got := nextEvent(early); got != "signal" {
+		t.Fatalf("first event = %q, want signal", got)
+	}
+
+	s.MarkReady()
+	s.MarkReady()
+	if got := nextEvent(early); got != "ready" {
+		t.Fatalf("event after MarkReady = %q, want ready", got)
+	}
+	if line, _ := early.ReadString('\n'); !strings.Contains(line, `"ready_at"`) {
+		t.Errorf("ready data = %q", line)
+	}
+
+	// Late joiners get it right away
+	late, cancel2 := connect()
+	defer cancel2()
+	if got := nextEvent(late); got != "ready" {
+		t.Fatalf("late joiner first event = %q, want ready", got)
+	}
+}
+
+func TestSymbolAliases(t *testing.T) {
+	store := ticker.NewStore()
+	store.Update("BTCUSDT", 50000, 1.5, 100, 3e6)
+	store.Update("ETHUSDT", 3000, -2.0, 50, 2e6)
+
+	history := signalpkg.NewHistory(100)
+	history.Add(signalpkg.Signal{ID: "s1", Symbol: "BTCUSDT", Period: "1d", Level: "R3", Direction: "up", TriggeredAt: time.Now()})
+
+	rankings := ranking.NewStore("", 0)
+	rankings.Add(&ranking.Snapshot{Timestamp: time.Now(), Items: map[string]*ranking.SnapshotItem{
+		"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, TradesRank: 1, Price: 50000, Volume: 3e6},
+		"ETHUSDT": {Symbol: "ETHUSDT", VolumeRank: 2, TradesRank: 2, Price: 3000, Volume: 2e6},
+	}})
+	patterns, _ := pattern.NewHistory("", 100)
+	_ = patterns.Add(pattern.NewSignal("BTCUSDT", pattern.PatternHammer, pattern.DirectionBullish, 70, time.Now()))
+	_ = patterns.Add(pattern.NewSignal("ETHUSDT", pattern.PatternHammer, pattern.DirectionBullish, 70, time.Now()))
+
+	s := New(nil, history, nil)
+	s.TickerStore = store
+	s.RankingStore = rankings
+	s.PatternHistory = patterns
+	h := s.Handler()
+
+	get := func(path string, v any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	// No aliases configured: the field is omitted
+	var plain []signalpkg.Signal
+	get("/api/history", &plain)
+	if len(plain) != 1 || plain[0].Alias != "" {
+		t.Fatalf("history without aliases = %+v", plain)
+	}
+
+	s.SymbolAliases = map[string]string{"BTCUSDT": "BTC"}
+	var sigs []signalpkg.Signal
+	get("/api/history", &sigs)
+	if len(sigs) != 1 || sigs[0].Alias != "BTC" {
+		t.Errorf("history alias = %+v, want BTC", sigs)
+	}
+
+	var tickers map[string]ticker.Ticker
+	get("/api/tickers", &tickers)
+	if got := tickers["BTCUSDT"].Alias; got != "BTC" {
+		t.Errorf("BTCUSDT ticker alias = %q, want BTC", got)
+	}
+	if got := tickers["ETHUSDT"].Alias; got != "ETHUSDT" {
+		t.Errorf("unmapped ticker alias = %q, want ETHUSDT", got)
+	}
+
+	var current ranking.CurrentResponse
+	get("/api/ranking/current", &current)
+	if len(current.Items) != 2 || current.Items[0].Alias != "BTC" || current.Items[1].Alias != "ETHUSDT" {
+		t.Errorf("ranking items = %+v, want aliases BTC and ETHUSDT", current.Items)
+	}
+	var hist ranking.HistoryResponse
+	get("/api/ranking/history/BTCUSDT", &hist)
+	if hist.Alias != "BTC" {
+		t.Errorf("ranking history alias = %q, want BTC", hist.Alias)
+	}
+
+	var summary PatternSummaryResponse
+	get("/api/patterns/summary", &summary)
+	if len(summary.Patterns) != 1 || strings.Join(summary.Patterns[0].Aliases, ",") != "BTC,ETHUSDT" {
+		t.Errorf("pattern summary = %+v, want aliases [BTC ETHUSDT]", summary.Patterns)
+	}
+
+	// The stores are not modified
+	if tk := store.GetAll()["BTCUSDT"]; tk.Alias != "" {
+		t.Errorf("store ticker alias = %q, want empty", tk.Alias)
+	}
+	if items := rankings.GetCurrent(ranking.CurrentOptions{Type: ranking.RankingTypeVolume}).Items; len(items) == 0 || items[0].Alias != "" {
+		t.Errorf("store ranking items = %+v, want no alias", items)
+	}
+}
+
+func TestHandleTickers_Sort(t *testing.T) {
+	store := ticker.NewStore()
+	store.Update("BTCUSDT", 50000, 1.5, 100, 3e6)
+	store.Update("ETHUSDT", 3000, -2.0, 50, 2e6)
+	store.Update("SOLUSDT", 150, 6.0, 80, 1e6)
+
+	s := New(nil, nil, nil)
+	s.TickerStore = store
+	h := s.Handler()
+
+	get := func(query string) []ticker.Ticker {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickers?"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", query, rec.Code)
+		}
+		var out []ticker.Ticker
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return out
+	}
+	symbols := func(list []ticker.Ticker) string {
+		var out []string
+		for _, tk := range list {
+			out = append(out, tk.Symbol)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := symbols(get("sort=change&order=desc&limit=2")); got != "SOLUSDT,BTCUSDT" {
+		t.Errorf("change desc limit 2 = %s", got)
+	}
+	if got := symbols(get("sort=change&order=asc")); got != "ETHUSDT,BTCUSDT,SOLUSDT" {
+		t.Errorf("change asc = %s", got)
+	}
+	if got := symbols(get("sort=volume")); got != "BTCUSDT,ETHUSDT,SOLUSDT" {
+		t.Errorf("volume default order = %s", got)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickers?sort=bogus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid sort: status %d, want 400", rec.Code)
+	}
+}
+
+func TestHandlePivotCalc(t *testing.T) {
+	s := New(sse.NewBroker[signalpkg.Signal](), nil, nil)
+	h := s.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pivots/calc?high=110&low=90&close=100", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp PivotCalcResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want, _ := pivot.Calculate(110, 90, 100)
+	if resp.Method != "camarilla" || resp.Levels.R3 != want.R3 || resp.Levels.S3 != want.S3 {
+		t.Errorf("resp = %+v, want camarilla levels %+v", resp, want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pivots/calc?high=110&low=90&close=100&method=classic", nil))
+	resp = PivotCalcResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("classic: status = %d, err = %v: %s", rec.Code, err, rec.Body.String())
+	}
+	if want, _ := pivot.CalculateClassic(110, 90, 100); resp.Method != "classic" || resp.Levels.R1 != want.R1 || resp.Levels.S3 != want.S3 {
+		t.Errorf("resp = %+v, want classic levels %+v", resp, want)
+	}
+
+	for _, query := range []string{
+		"high=90&low=110&close=100",
+		"high=110&low=-1&close=100",
+		"high=110&low=90",
+		"high=abc&low=90&close=100",
+		"high=NaN&low=90&close=100",
+		"high=110&low=90&close=100&method=fibonacci",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pivots/calc?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestHandlePatterns_MinRank(t *testing.T) {
+	history, _ := pattern.NewHistory("", 100)
+	now := time.Now()
+	for i, pt := range []pattern.PatternType{pattern.PatternKicking, pattern.PatternHammer, pattern.PatternHangingMan, pattern.PatternDoji} {
+		_ = history.Add(pattern.NewSignal("BTCUSDT", pt, pattern.DirectionBullish, 70, now.Add(time.Duration(i)*time.Minute)))
+	}
+	s := New(nil, nil, nil)
+	s.PatternHistory = history
+	h := s.Handler()
+
+	get := func(query string) ([]pattern.Signal, int) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/patterns"+query, nil))
+		var sigs []pattern.Signal
+		_ = json.Unmarshal(rec.Body.Bytes(), &sigs)
+		return sigs, rec.Code
+	}
+
+	// A raw "+" decodes as a space; both spellings mean B+
+	for _, q := range []string{"?min_rank=B+", "?min_rank=b%2B"} {
+		sigs, code := get(q)
+		if code != http.StatusOK || len(sigs) != 2 {
+			t.Fatalf("%s: status %d, %d patterns; want 200 and kicking (A+), hammer (B+)", q, code, len(sigs))
+		}
+		for _, sig := range sigs {
+			if sig.Pattern != pattern.PatternKicking && sig.Pattern != pattern.PatternHammer {
+				t.Errorf("%s: %s (rank %s) passed", q, sig.Pattern, sig.EfficiencyRank)
+			}
+		}
+	}
+
+	if sigs, _ := get(""); len(sigs) != 4 {
+		t.Errorf("without min_rank: %d patterns, want 4", len(sigs))
+	}
+	if _, code := get("?min_rank=Z"); code != http.StatusBadRequest {
+		t.Errorf("unknown rank: status = %d, want 400", code)
+	}
+}
+
+func TestHandlePatternLatest(t *testing.T) {
+	history, err := pattern.NewHistory("", 100)
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i, sig := range []pattern.Signal{
+		pattern.NewSignal("BTCUSDT", pattern.PatternHammer, pattern.DirectionBullish, 70, base),
+		pattern.NewSignal("BTCUSDT", pattern.PatternMorningStar, pattern.DirectionBullish, 80, base.Add(15*time.Minute)),
+		pattern.NewSignal("ETHUSDT", pattern.PatternShootingStar, pattern.DirectionBearish, 75, base),
+	} {
+		sig.DetectedAt = base.Add(time.Duration(i) * time.Minute)
+		_ = history.Add(sig)
+	}
+
+	s := New(sse.NewBroker[signalpkg.Signal](), nil, nil)
+	s.PatternHistory = history
+	h := s.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/patterns/btcusdt/latest", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp PatternLatestResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Bullish == nil || resp.Bullish.Pattern != pattern.PatternMorningStar {
+		t.Errorf("bullish = %+v, want morning star", resp.Bullish)
+	}
+	if resp.Bearish != nil || resp.Neutral != nil {
+		t.Errorf("bearish = %+v, neutral = %+v, want null", resp.Bearish, resp.Neutral)
+	}
+	if !strings.Contains(rec.Body.String(), `"bearish":null`) {
+		t.Errorf("body = %s, want explicit nulls", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/patterns/BTCUSDT", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without /latest: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandlePivotRaw(t *testing.T) {
+	dir := t.TempDir()
+	s := New(sse.NewBroker[signalpkg.Signal](), nil, nil)
+	s.PivotFiles = pivot.NewRefresher(dir, pivot.NewStore(), nil)
+	h := s.Handler()
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pivots/raw"+query, nil))
+		return rec
+	}
+
+	if rec := get("?period=1d"); rec.Code != http.StatusNotFound {
+		t.Errorf("before write: status = %d, want 404", rec.Code)
+	}
+
+	raw := `{"period":"1d","symbols":{}}`
+	if err := os.MkdirAll(filepath.Join(dir, "pivots"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pivots", "daily.json"), []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A half-written refresh must not be served
+	if err := os.WriteFile(filepath.Join(dir, "pivots", "daily.json.tmp"), []byte(`{"per`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK || rec.Body.String() != raw {
+		t.Errorf("status = %d body = %q, want 200 %q", rec.Code, rec.Body.String(), raw)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	if rec := get("?period=1w"); rec.Code != http.StatusNotFound {
+		t.Errorf("weekly: status = %d, want 404", rec.Code)
+	}
+	if rec := get("?period=1m"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid period: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandleAdminClear(t *testing.T) {
+	history := signalpkg.NewHistory(100)
+	history.Add(signalpkg.Signal{ID: "s1", Symbol: "BTCUSDT", Period: "1d", Level: "R3", Direction: "up", TriggeredAt: time.Now()})
+
+	s := New(nil, history, nil)
+	post := func(query, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/clear"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("?what=signals", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("no AdminToken: status = %d, want 404", rec.Code)
+	}
+
+	s.AdminToken = "secret"
+	if rec := post("?what=signals", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", rec.Code)
+	}
+	if rec := post("?what=everything", "secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid what: status = %d, want 400", rec.Code)
+	}
+	if rec := post("?what=ranking", "secret"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no ranking store: status = %d, want 503", rec.Code)
+	}
+	if history.Count() != 1 {
+		t.Fatalf("rejected requests cleared history")
+	}
+
+	rec := post("?what=signals", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp ClearResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Cleared != "signals" || resp.Count != 1 {
+		t.Errorf("response = %+v, want signals/1", resp)
+	}
+	if history.Count() != 0 {
+		t.Errorf("Count = %d after clear, want 0", history.Count())
+	}
+}
+
+type fakeSignalActivity map[string]int
+
+func (f fakeSignalActivity) SignalActivity(now time.Time) (time.Time, map[string]int) {
+	return now.UTC().Truncate(24 * time.Hour), f
+}
+
+func TestHandleSignalActivity(t *testing.T) {
+	s := New(nil, nil, nil)
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/signals/activity", nil))
+		return rec
+	}
+	if rec := get(); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no provider: status = %d, want 503", rec.Code)
+	}
+
+	s.SignalActivity = fakeSignalActivity{"ETHUSDT": 1, "BTCUSDT": 3, "SOLUSDT": 3}
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp SignalActivityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var order []string
+	for _, a := range resp.Symbols {
+		order = append(order, fmt.Sprintf("%s=%d", a.Symbol, a.Count))
+	}
+	if got := strings.Join(order, ","); got != "BTCUSDT=3,SOLUSDT=3,ETHUSDT=1" {
+		t.Errorf("symbols = %s, want BTCUSDT=3,SOLUSDT=3,ETHUSDT=1", got)
+	}
+}
+
+type fakePatternSwitch struct{ enabled bool }
+
+func (f *fakePatternSwitch) PatternsEnabled() bool           { return f.enabled }
+func (f *fakePatternSwitch) SetPatternsEnabled(enabled bool) { f.enabled = enabled }
+
+func TestHandleAdminPatterns(t *testing.T) {
+	s := New(nil, nil, nil)
+	s.AdminToken = "secret"
+	post := func(query, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/patterns"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("?enabled=false", "secret"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no PatternSwitch: status = %d, want 503", rec.Code)
+	}
+
+	sw := &fakePatternSwitch{enabled: true}
+	s.PatternSwitch = sw
+	if rec := post("?enabled=false", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", rec.Code)
+	}
+	if rec := post("?enabled=maybe", "secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid enabled: status = %d, want 400", rec.Code)
+	}
+	if !sw.enabled {
+		t.Fatal("rejected requests changed the switch")
+	}
+
+	rec := post("?enabled=false", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp PatternSwitchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Enabled || sw.enabled {
+		t.Errorf("response = %+v, switch = %v; want disabled", resp, sw.enabled)
+	}
+	if stats := s.runtimeStats(); stats.PatternsEnabled == nil || *stats.PatternsEnabled {
+		t.Errorf("runtime patterns_enabled = %v, want false", stats.PatternsEnabled)
+	}
+}
+
+func TestMarshalJSON_PriceDecimalString(t *testing.T) {
+	prevPrice := 0.00000121
+	v := map[string]any{
+		"signal": signalpkg.Signal{ID: "price", Symbol: "PEPEUSDT", Level: "R3", Price: 0.000000123},
+		"levels": pivot.Levels{PP: 0.0000001, R3: 0.0000015, S3: 12345.5},
+		"ticker": ticker.Ticker{Symbol: "PEPEUSDT", LastPrice: 0.00000123},
+		"item":   ranking.RankingItem{Symbol: "PEPEUSDT", Price: 0.00000124, PrevPrice: &prevPrice},
+		"count":  3e-7,
+	}
+
+	s := New(nil, nil, nil)
+	b, err := s.marshalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"price":1.23e-7`) {
+		t.Errorf("default encoding changed: %s", b)
+	}
+
+	s.PriceDecimalString = true
+	s.JSONCase = JSONCaseCamel
+	b, err = s.marshalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"price":"0.000000123"`, `"pp":"0.0000001"`, `"r3":"0.0000015"`, `"s3":"12345.5"`, `"r1":"0"`, `"lastPrice":"0.00000123"`, `"prevPrice":"0.00000121"`, `"price":"0.00000124"`, `"id":"price"`, `"count":3e-7`, `"triggeredAt"`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("missing %s in %s", want, b)
+		}
+	}
+	var back struct {
+		Signal struct {
+			Price string `json:"price"`
+		} `json:"signal"`
+	}
+	if err := json.Unmarshal(b, &back); err != nil || back.Signal.Price != "0.000000123" {
+		t.Errorf("round trip price = %q, %v", back.Signal.Price, err)
+	}
+}
+
+func TestHandleCombinerRecent(t *testing.T) {
+	s := New(nil, nil, nil)
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/combiner/recent"+query, nil))
+		return rec
+	}
+	if rec := get("?symbol=BTCUSDT"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no combiner: status = %d, want 503", rec.Code)
+	}
+
+	s.SignalCombiner = signalpkg.NewCombiner(15 * time.Minute)
+	now := time.Now()
+	s.SignalCombiner.AddPivotSignal(signalpkg.Signal{ID: "p1", Symbol: "BTCUSDT", Level: "R3", Direction: "up", TriggeredAt: now})
+	s.SignalCombiner.AddPatternSignal(pattern.Signal{ID: "k1", Symbol: "BTCUSDT", Pattern: pattern.PatternHammer, DetectedAt: now})
+
+	if rec := get(""); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing symbol: status = %d, want 400", rec.Code)
+	}
+
+	var resp CombinerRecentResponse
+	rec := get("?symbol=btcusdt")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Window != "15m0s" || len(resp.Pivots) != 1 || resp.Pivots[0].ID != "p1" || len(resp.Patterns) != 1 || resp.Patterns[0].ID != "k1" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	rec = get("?symbol=ETHUSDT")
+	if body := rec.Body.String(); !strings.Contains(body, `"pivots":[]`) || !strings.Contains(body, `"patterns":[]`) {
+		t.Errorf("unknown symbol: body = %s, want empty arrays", body)
+	}
+}
+
+func TestHandleRankingCurrent_ETag(t *testing.T) {
+	store := ranking.NewStore("", 0)
+	add := func(ts time.Time) {
+		store.Add(&ranking.Snapshot{Timestamp: ts, Items: map[string]*ranking.SnapshotItem{
+			"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, TradesRank: 1, Price: 50000, Volume: 1e9},
+		}})
+	}
+	now := time.Now()
+	add(now)
+
+	s := New(nil, nil, nil)
+	s.RankingStore = store
+	get := func(target, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/ranking/current?limit=10", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q; want 200 with an ETag", rec.Code, etag)
+	}
+	if rec := get("/api/ranking/current?limit=10", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("conditional re-request: status = %d, body = %q; want empty 304", rec.Code, rec.Body.String())
+	}
+	if rec := get("/api/ranking/current?limit=5", etag); rec.Code != http.StatusOK {
+		t.Errorf("other query: status = %d, want 200", rec.Code)
+	}
+	if rec := get("/api/ranking/history/BTCUSDT", etag); rec.Code != http.StatusOK {
+		t.Errorf("other endpoint: status = %d, want 200", rec.Code)
+	}
+
+	// A new snapshot changes the ETag
+	add(now.Add(time.Minute))
+	if rec := get("/api/ranking/current?limit=10", etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("after new snapshot: status = %d, ETag = %q; want 200 with a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestHandlePivotCoverage(t *testing.T) {
+	tickers := ticker.NewStore()
+	for _, sym := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT"} {
+		tickers.Update(sym, 1, 0, 1, 1e6)
+	}
+	pivots := pivot.NewStore()
+	_ = pivots.Swap(pivot.PeriodDaily, &pivot.Snapshot{Period: pivot.PeriodDaily, UpdatedAt: time.Now(), Symbols: map[string]pivot.Levels{
+		"BTCUSDT": {PP: 100}, "ETHUSDT": {PP: 10}, "DOGEUSDT": {PP: 1},
+	}})
+
+	s := New(nil, nil, nil)
+	h := s.Handler()
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pivots/coverage"+query, nil))
+		return rec
+	}
+
+	if rec := get(""); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without stores: status = %d, want 503", rec.Code)
+	}
+	s.PivotStore = pivots
+	s.TickerStore = tickers
+
+	if rec := get("?period=1h"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad period: status = %d, want 400", rec.Code)
+	}
+
+	rec := get("?period=1d")
+	var resp PivotCoverageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v (%s)", err, rec.Body.String())
+	}
+	if resp.Active != 4 || resp.Covered != 2 || resp.CoveragePct != 50 {
+		t.Errorf("daily coverage = %+v, want 2 of 4 (50%%)", resp)
+	}
+	if len(resp.Missing) != 2 || resp.Missing[0] != "SOLUSDT" || resp.Missing[1] != "XRPUSDT" {
+		t.Errorf("daily missing = %v, want [SOLUSDT XRPUSDT]", resp.Missing)
+	}
+
+	// No weekly snapshot: every active symbol is missing
+	resp = PivotCoverageResponse{}
+	_ = json.Unmarshal(get("?period=1w").Body.Bytes(), &resp)
+	if resp.Covered != 0 || len(resp.Missing) != 4 || resp.CoveragePct != 0 {
+		t.Errorf("weekly coverage = %+v, want 0 of 4", resp)
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	oldVersion, oldCommit, oldDate := Version, GitCommit, BuildDate
+	defer func() { Version, GitCommit, BuildDate = oldVersion, oldCommit, oldDate }()
+	Version, GitCommit, BuildDate = "1.2.3", "abc1234", "2025-01-02T03:04:05Z"
+
+	h := New(nil, nil, nil).Handler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got VersionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := VersionInfo{Version: "1.2.3", GitCommit: "abc1234", BuildDate: "2025-01-02T03:04:05Z", GoVersion: runtime.Version()}
+	if got != want {
+		t.Errorf("version = %+v, want %+v", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+func TestHandleHistory_EnrichLimit(t *testing.T) {
+	now := time.Now()
+	history := signalpkg.NewHistory(100)
+	patterns, _ := pattern.NewHistory("", 100)
+	for i := 0; i < 50; i++ {
+		symbol := fmt.Sprintf("SYM%dUSDT", i)
+		history.Add(signalpkg.Signal{ID: fmt.Sprintf("s%d", i), Symbol: symbol, Period: "1d", Level: "R3", Direction: "up", TriggeredAt: now.Add(time.Duration(i) * time.Second)})
+		_ = patterns.Add(pattern.NewSignal(symbol, pattern.PatternHammer, pattern.DirectionBullish, 70, now))
+	}
+
+	s := New(nil, history, nil)
+	s.PatternHistory = patterns
+	s.HistoryEnrichLimit = 10
+	h := s.Handler()
+
+	get := func() ([]map[string]any, *httptest.ResponseRecorder) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/history?limit=100", nil))
+		var sigs []map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &sigs); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return sigs, rec
+	}
+
+	sigs, rec := get()
+	if len(sigs) != 50 {
+		t.Fatalf("got %d signals, want 50", len(sigs))
+	}
+	for i, sig := range sigs {
+		_, ok := sig["related_pattern"]
+		if ok != (i < 10) {
+			t.Errorf("signal %d (%v): related_pattern present = %v", i, sig["id"], ok)
+		}
+	}
+	if got := rec.Header().Get("X-Enrichment-Truncated"); got != "true" {
+		t.Errorf("X-Enrichment-Truncated = %q, want true", got)
+	}
+
+	// A negative limit enriches every signal
+	s.HistoryEnrichLimit = -1
+	sigs, rec = get()
+	for i, sig := range sigs {
+		if _, ok := sig["related_pattern"]; !ok {
+			t.Errorf("uncapped: signal %d has no related_pattern", i)
+		}
+	}
+	if got := rec.Header().Get("X-Enrichment-Truncated"); got != "" {
+		t.Errorf("uncapped: X-Enrichment-Truncated = %q, want empty", got)
+	}
+}
+
+func TestHandleTicker(t *testing.T) {
+	store := ticker.NewStore()
+	store.Update("BTCUSDT", 50000, 1.5, 100, 3e6)
+
+	s := New(nil, nil, nil)
+	s.TickerStore = store
+	h := s.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ticker/btcusdt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var got ticker.Ticker
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Symbol != "BTCUSDT" || got.LastPrice != 50000 || got.QuoteVolume != 3e6 {
+		t.Errorf("ticker = %+v", got)
+	}
+
+	for path, want := range map[string]int{
+		"/api/ticker/DOGEUSDT": http.StatusNotFound,
+		"/api/ticker/":         http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestCamelizeKeys(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"flat", `{"price_change":1,"symbol":"BTCUSDT"}`, `{"priceChange":1,"symbol":"BTCUSDT"}`},
+		{"nested objects and arrays", `{"top_level":{"inner_key":[{"deep_key":true},{"deep_key":null}]},"list_of":[1,2]}`,
+			`{"topLevel":{"innerKey":[{"deepKey":true},{"deepKey":null}]},"listOf":[1,2]}`},
+		{"string array values untouched", `{"level_names":["r_1","s_1"]}`, `{"levelNames":["r_1","s_1"]}`},
+		{"escaped quotes in a value", `{"note":"say \"snake_case\"","next_key":2}`, `{"note":"say \"snake_case\"","nextKey":2}`},
+		{"value containing a colon after a quote", `{"msg":"a_b\":c_d","x_y":"e_f:"}`, `{"msg":"a_b\":c_d","xY":"e_f:"}`},
+		{"already camelCase", `{"priceChange":1,"isNew":true}`, `{"priceChange":1,"isNew":true}`},
+		{"not an object", `"snake_value"`, `"snake_value"`},
+	}
+	for _, tt := range tests {
+		got := string(camelizeKeys([]byte(tt.in)))
+		if got != tt.want {
+			t.Errorf("%s: camelizeKeys(%s) = %s, want %s", tt.name, tt.in, got, tt.want)
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(got), &v); err != nil {
+			t.Errorf("%s: output is not valid JSON: %v", tt.name, err)
+		}
+	}
+
+	// Round trip: values survive and keys map to their camelCase names
+	in, _ := json.Marshal(map[string]any{"quote_volume": 1.5, "note": `a "b_c": d`, "sub_items": []map[string]int{{"x_y": 1}}})
+	var back map[string]any
+	if err := json.Unmarshal(camelizeKeys(in), &back); err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	if back["quoteVolume"] != 1.5 || back["note"] != `a "b_c": d` {
+		t.Errorf("round trip = %v", back)
+	}
+	if items, ok := back["subItems"].([]any); !ok || len(items) != 1 || items[0].(map[string]any)["xY"] != 1.0 {
+		t.Errorf("round trip subItems = %v", back["subItems"])
+	}
+}
+
+func TestSnakeToCamel(t *testing.T) {
+	for in, want := range map[string]string{
+		"price_change":   "priceChange",
+		"prev_rank_diff": "prevRankDiff",
+		"a__b":           "aB",
+		"trailing_":      "trailing",
+		"r1":             "r1",
+		"priceChange":    "priceChange",
+	} {
+		if got := snakeToCamel(in); got != want {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestHandleSignalPatterns(t *testing.T) {
+	now := time.Now()
+	history := signalpkg.NewHistory(100)
+	history.Add(signalpkg.Signal{ID: "s1", Symbol: "BTCUSDT", Period: "1d", Level: "R3", Direction: "up", TriggeredAt: now})
+
+	patterns, _ := pattern.NewHistory("", 100)
+	add := func(symbol string, pt pattern.PatternType, dir pattern.Direction, at time.Time) {
+		sig := pattern.NewSignal(symbol, pt, dir, 70, at)
+		sig.DetectedAt = at
+		_ = patterns.Add(sig)
+	}
+	add("BTCUSDT", pattern.PatternHammer, pattern.DirectionBullish, now.Add(-10*time.Minute))
+	add("BTCUSDT", pattern.PatternDoji, pattern.DirectionNeutral, now.Add(-2*time.Hour))
+	add("ETHUSDT", pattern.PatternHammer, pattern.DirectionBullish, now)
+
+	s := New(nil, history, nil)
+	s.PatternHistory = patterns
+	h := s.Handler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/api/signals/s1/patterns")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp SignalPatternsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Signal.ID != "s1" || resp.Window != "1h0m0s" {
+		t.Errorf("signal %q window %q, want s1 and 1h0m0s", resp.Signal.ID, resp.Window)
+	}
+	if len(resp.Patterns) != 1 || resp.Patterns[0].Pattern != string(pattern.PatternHammer) || resp.Patterns[0].TimeDiff == "" {
+		t.Errorf("patterns = %+v, want only the BTCUSDT hammer within the hour", resp.Patterns)
+	}
+
+	// A wider window reaches the older pattern
+	rec = get("/api/signals/s1/patterns?window=3h")
+	resp = SignalPatternsResponse{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Patterns) != 2 {
+		t.Errorf("window=3h: %d patterns, want 2", len(resp.Patterns))
+	}
+
+	for target, want := range map[string]int{
+		"/api/signals/s1/patterns?window=bad": http.StatusBadRequest,
+		"/api/signals/s1/patterns?window=-1h": http.StatusBadRequest,
+		"/api/signals/nope/patterns":          http.StatusNotFound,
+		"/api/signals/s1/other":               http.StatusNotFound,
+	} {
+		rec := get(target)
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, want)
+		}
+		if want == http.StatusBadRequest && !strings.Contains(rec.Body.String(), "invalid window") {
+			t.Errorf("%s: body = %s, want an invalid window error", target, rec.Body.String())
+		}
+	}
+}
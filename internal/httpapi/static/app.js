@@ -186,6 +186,9 @@
             label_source_unknown: "未知",
             label_custom: "自定义",
             label_neutral: "中性",
+            label_touch: "触及",
+            label_walk: "连穿",
+            label_confluence: "共振价位",
             label_bullish: "看涨",
             label_bearish: "看跌",
             label_new: "新",
@@ -405,6 +408,9 @@
             label_source_unknown: "unknown",
             label_custom: "Custom",
             label_neutral: "Neutral",
+            label_touch: "Touch",
+            label_walk: "Walk",
+            label_confluence: "Confluent level",
             label_bullish: "Bullish",
             label_bearish: "Bearish",
             label_new: "NEW",
@@ -1199,6 +1205,9 @@
                         <span class="tag">${signal.period}</span>
                         <span class="tag">${signal.level}</span>
                         <span class="tag ${signal.direction}">${directionLabel(signal.direction)}</span>
+                        ${signal.kind === 'touch' ? `<span class="tag">${t("label_touch")}</span>` : ''}
+                        ${signal.kind === 'walk' ? `<span class="tag">${t("label_walk")} ${signal.walk_from}→${signal.level}</span>` : ''}
+                        ${signal.confluence ? `<span class="tag" title="${t("label_confluence")}">+${signal.confluence_period} ${signal.confluence}</span>` : ''}
                         ${patternBadgeHtml}
                     </div>
                 </div>
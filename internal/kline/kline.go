@@ -8,14 +8,20 @@ import (
 
 // Kline represents a single candlestick (K-line) data.
 type Kline struct {
-	Symbol    string    `json:"symbol"`
-	Open      float64   `json:"open"`
-	High      float64   `json:"high"`
-	Low       float64   `json:"low"`
-	Close     float64   `json:"close"`
-	OpenTime  time.Time `json:"open_time"`
-	CloseTime time.Time `json:"close_time"`
-	IsClosed  bool      `json:"is_closed"`
+	Symbol     string    `json:"symbol"`
+	Open       float64   `json:"open"`
+	High       float64   `json:"high"`
+	Low        float64   `json:"low"`
+	Close      float64   `json:"close"`
+	Volume     float64   `json:"volume,omitempty"`      // Only set when a trade stream is enabled
+	TradeCount int64     `json:"trade_count,omitempty"` // Trades (fills, not aggTrade messages), only set when a trade stream is enabled
+	OpenTime   time.Time `json:"open_time"`
+	CloseTime  time.Time `json:"close_time"`
+	IsClosed   bool      `json:"is_closed"`
+
+	// HasGap is set when one or more whole intervals with no prices precede
+	// this kline, i.e. its OpenTime is after the previous kline's CloseTime.
+	HasGap bool `json:"has_gap,omitempty"`
 }
 
 // Body returns the absolute size of the kline body (|Close - Open|).
@@ -57,13 +63,16 @@ func (k *Kline) Range() float64 {
 // Clone returns a deep copy of the kline.
 func (k *Kline) Clone() Kline {
 	return Kline{
-		Symbol:    k.Symbol,
-		Open:      k.Open,
-		High:      k.High,
-		Low:       k.Low,
-		Close:     k.Close,
-		OpenTime:  k.OpenTime,
-		CloseTime: k.CloseTime,
-		IsClosed:  k.IsClosed,
+		Symbol:     k.Symbol,
+		Open:       k.Open,
+		High:       k.High,
+		Low:        k.Low,
+		Close:      k.Close,
+		Volume:     k.Volume,
+		TradeCount: k.TradeCount,
+		OpenTime:   k.OpenTime,
+		CloseTime:  k.CloseTime,
+		IsClosed:   k.IsClosed,
+		HasGap:     k.HasGap,
 	}
 }
@@ -1,7 +1,12 @@
 package kline
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"log"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -12,6 +17,7 @@ type SymbolKlines struct {
 	Current  *Kline  // Current forming kline
 	History  []Kline // Completed historical klines (oldest first, newest last)
 	LastSeen time.Time
+	Gaps     int // Number of times a kline opened after missing intervals
 }
 
 // Store manages kline data for all trading pairs.
@@ -45,8 +51,38 @@ func NewStore(interval time.Duration, maxCount int) *Store {
 	}
 }
 
+// Interval returns the kline interval of the store.
+func (s *Store) Interval() time.Duration {
+	return s.interval
+}
+
+// Timeframe returns the store interval in Binance notation (e.g. "5m", "1h").
+func (s *Store) Timeframe() string {
+	return FormatInterval(s.interval)
+}
+
+// FormatInterval formats a kline interval in Binance notation: "5m", "15m", "1h", "1d".
+// Intervals that are not a whole number of minutes fall back to time.Duration's format.
+func FormatInterval(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	default:
+		return d.String()
+	}
+}
+
 // SetOnClose sets the callback function called when a kline closes.
 // The callback receives a deep copy snapshot of klines, safe for async use.
+// It runs synchronously on the updating goroutine, outside the store lock,
+// so it should hand slow work off (the monitor queues it for its pattern
+// workers) rather than block.
 func (s *Store) SetOnClose(fn func(symbol string, klines []Kline)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -150,6 +186,7 @@ func (s *Store) closeAllKlines() {
 			Close:    sk.Current.Close,
 			OpenTime: currentOpen,
 		}
+		s.markGapLocked(sk)
 	}
 
 	onClose := s.onClose
@@ -163,7 +200,7 @@ func (s *Store) closeAllKlines() {
 	// Call callbacks outside lock
 	if onClose != nil {
 		for _, item := range toClose {
-			go onClose(item.symbol, item.snapshot)
+			onClose(item.symbol, item.snapshot)
 		}
 	}
 
@@ -215,6 +252,36 @@ func shouldClose(current *Kline, ts time.Time, interval time.Duration) bool {
 	return !ts.Before(closeTime)
 }
 
+// markGapLocked flags sk.Current and counts a gap when whole intervals are
+// missing between the last closed kline and the newly opened current one,
+// e.g. after the price stream stalled for the symbol.
+func (s *Store) markGapLocked(sk *SymbolKlines) {
+	if len(sk.History) == 0 || sk.Current == nil {
+		return
+	}
+	prevClose := sk.History[len(sk.History)-1].CloseTime
+	if !sk.Current.OpenTime.After(prevClose) {
+		return
+	}
+	sk.Current.HasGap = true
+	sk.Gaps++
+	missing := int(sk.Current.OpenTime.Sub(prevClose) / s.interval)
+	log.Printf("kline: gap %s %s missing=%d intervals after %s", sk.Symbol, s.Timeframe(), missing, prevClose.Format(time.RFC3339))
+}
+
+// GapCount returns how many times a kline for symbol opened after missing
+// intervals (see Kline.HasGap).
+func (s *Store) GapCount(symbol string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sk, ok := s.klines[symbol]
+	if !ok {
+		return 0
+	}
+	return sk.Gaps
+}
+
 // Update updates the kline data with a new price.
 // Returns true if a kline was closed.
 func (s *Store) Update(symbol string, price float64, ts time.Time) bool {
@@ -255,6 +322,7 @@ func (s *Store) Update(symbol string, price float64, ts time.Time) bool {
 			Close:    price,
 			OpenTime: openTime,
 		}
+		s.markGapLocked(sk)
 
 		// Get callback reference while holding lock
 		onClose := s.onClose
@@ -263,7 +331,7 @@ func (s *Store) Update(symbol string, price float64, ts time.Time) bool {
 
 		// Call callback outside lock to avoid deadlock
 		if onClose != nil {
-			go onClose(symbol, snapshot)
+			onClose(symbol, snapshot)
 		}
 
 		return true
@@ -297,6 +365,91 @@ func (s *Store) Update(symbol string, price float64, ts time.Time) bool {
 	return false
 }
 
+// Seed loads closed klines for a symbol, e.g. fetched from REST at startup,
+// without invoking the onClose callback. Klines that open at or after the
+// symbol's earliest existing kline are ignored, so seeding never overwrites
+// live data. Only the newest maxCount klines are kept.
+// Returns the number of klines added.
+func (s *Store) Seed(symbol string, klines []Kline) int {
+	if len(klines) == 0 {
+		return 0
+	}
+
+	sorted := make([]Kline, len(klines))
+	copy(sorted, klines)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].OpenTime.Before(sorted[j].OpenTime) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sk := s.getOrCreate(symbol)
+	var limit time.Time
+	if len(sk.History) > 0 {
+		limit = sk.History[0].OpenTime
+	} else if sk.Current != nil {
+		limit = sk.Current.OpenTime
+	}
+
+	seeded := make([]Kline, 0, len(sorted))
+	for _, k := range sorted {
+		if !limit.IsZero() && !k.OpenTime.Before(limit) {
+			break
+		}
+		if n := len(seeded); n > 0 && seeded[n-1].OpenTime.Equal(k.OpenTime) {
+			continue
+		}
+		k.Symbol = symbol
+		k.IsClosed = true
+		if k.CloseTime.IsZero() {
+			k.CloseTime = getKlineCloseTime(k.OpenTime, s.interval)
+		}
+		seeded = append(seeded, k)
+	}
+	if len(seeded) == 0 {
+		return 0
+	}
+
+	// Existing history never exceeds maxCount, so trimming only drops seeded klines
+	history := append(seeded, sk.History...)
+	added := len(seeded)
+	if drop := len(history) - s.maxCount; drop > 0 {
+		history = history[drop:]
+		added -= drop
+	}
+	sk.History = history
+	if sk.LastSeen.IsZero() {
+		sk.LastSeen = time.Now()
+	}
+	return added
+}
+
+// UpdateVolume accumulates traded quantity and the number of trades it
+// spans (one aggTrade bundles several fills; values below 1 count as 1) into
+// the current forming kline. Trades outside the current kline's interval are
+// ignored; price updates remain responsible for opening and closing klines.
+func (s *Store) UpdateVolume(symbol string, qty float64, trades int64, ts time.Time) {
+	if qty <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sk, ok := s.klines[symbol]
+	if !ok || sk.Current == nil {
+		return
+	}
+	if ts.Before(sk.Current.OpenTime) || shouldClose(sk.Current, ts, s.interval) {
+		return
+	}
+
+	if trades < 1 {
+		trades = 1
+	}
+	sk.Current.Volume += qty
+	sk.Current.TradeCount += trades
+}
+
 // GetKlines returns a deep copy of historical klines for a symbol.
 // Returns klines in time order (oldest first, newest last).
 func (s *Store) GetKlines(symbol string) ([]Kline, bool) {
@@ -328,6 +481,38 @@ func (s *Store) GetCurrentKline(symbol string) (*Kline, bool) {
 	return &clone, true
 }
 
+// CurrentKline is the current forming kline plus its expected close time.
+type CurrentKline struct {
+	Kline          Kline     `json:"kline"`
+	CloseTime      time.Time `json:"close_time"`
+	SecondsToClose int64     `json:"seconds_to_close"`
+}
+
+// CurrentWithMeta returns a deep copy of the current forming kline together
+// with its close time (based on the store interval) and the seconds remaining
+// until then. SecondsToClose is never negative.
+func (s *Store) CurrentWithMeta(symbol string) (CurrentKline, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sk, ok := s.klines[symbol]
+	if !ok || sk.Current == nil {
+		return CurrentKline{}, false
+	}
+
+	closeTime := getKlineCloseTime(sk.Current.OpenTime, s.interval)
+	remaining := int64(time.Until(closeTime) / time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return CurrentKline{
+		Kline:          sk.Current.Clone(),
+		CloseTime:      closeTime,
+		SecondsToClose: remaining,
+	}, true
+}
+
 // GetAllKlines returns historical klines plus current kline (deep copy).
 func (s *Store) GetAllKlines(symbol string) ([]Kline, bool) {
 	s.mu.RLock()
@@ -350,6 +535,53 @@ func (s *Store) GetAllKlines(symbol string) ([]Kline, bool) {
 	return result, true
 }
 
+// WriteAllKlinesJSON writes the klines returned by GetAllKlines to w as a
+// compact JSON array, encoding one element at a time straight from the store
+// instead of copying the slice first. The read lock is held while writing, so
+// w should be an in-memory buffer, not a network connection.
+// Returns false (and writes nothing) if the symbol has no klines.
+func (s *Store) WriteAllKlinesJSON(w io.Writer, symbol string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sk, ok := s.klines[symbol]
+	if !ok || (len(sk.History) == 0 && sk.Current == nil) {
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	n := 0
+	writeOne := func(k *Kline) error {
+		buf.Reset()
+		if n == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+		n++
+		if err := enc.Encode(k); err != nil {
+			return err
+		}
+		// Drop the newline added by Encode
+		_, err := w.Write(buf.Bytes()[:buf.Len()-1])
+		return err
+	}
+
+	for i := range sk.History {
+		if err := writeOne(&sk.History[i]); err != nil {
+			return true, err
+		}
+	}
+	if sk.Current != nil {
+		if err := writeOne(sk.Current); err != nil {
+			return true, err
+		}
+	}
+	_, err := w.Write([]byte{']'})
+	return true, err
+}
+
 // CleanupStale removes symbols that haven't been updated for staleThreshold.
 // Returns the number of symbols removed.
 func (s *Store) CleanupStale(staleThreshold time.Duration) int {
@@ -405,6 +637,7 @@ type SymbolStats struct {
 	LastSeen     time.Time `json:"last_seen"`
 	CurrentOpen  float64   `json:"current_open,omitempty"`
 	CurrentClose float64   `json:"current_close,omitempty"`
+	Gaps         int       `json:"gaps,omitempty"`
 }
 
 // Stats returns statistics about the kline store.
@@ -426,6 +659,7 @@ func (s *Store) Stats() StoreStats {
 			KlineCount: len(sk.History),
 			HasCurrent: sk.Current != nil,
 			LastSeen:   sk.LastSeen,
+			Gaps:       sk.Gaps,
 		}
 		if sk.Current != nil {
 			ss.CurrentOpen = sk.Current.Open
@@ -1,6 +1,8 @@
 package kline
 
 import (
+	"bytes"
+	"encoding/json"
 	"sync"
 	"testing"
 	"time"
@@ -40,6 +42,27 @@ func TestGetKlineOpenTime(t *testing.T) {
 	}
 }
 
+func TestFormatInterval(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		expected string
+	}{
+		{5 * time.Minute, "5m"},
+		{15 * time.Minute, "15m"},
+		{time.Hour, "1h"},
+		{4 * time.Hour, "4h"},
+		{24 * time.Hour, "1d"},
+		{90 * time.Second, "1m30s"},
+		{0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := FormatInterval(tt.interval); got != tt.expected {
+			t.Errorf("FormatInterval(%v) = %q, want %q", tt.interval, got, tt.expected)
+		}
+	}
+}
+
 func TestStore_Update_NewSymbol(t *testing.T) {
 	store := NewStore(5*time.Minute, 12)
 	ts := time.Date(2024, 1, 1, 10, 2, 30, 0, time.UTC)
@@ -147,6 +170,98 @@ func TestStore_Update_KlineClose(t *testing.T) {
 	}
 }
 
+func TestStore_CloseAllKlines_Synchronous(t *testing.T) {
+	store := NewStore(5*time.Minute, 12)
+	var closed []string
+	store.SetOnClose(func(symbol string, klines []Kline) {
+		closed = append(closed, symbol)
+	})
+
+	past := time.Now().UTC().Add(-time.Hour)
+	store.Update("BTCUSDT", 50000.0, past)
+	store.Update("ETHUSDT", 3000.0, past)
+
+	// Callbacks have run by the time the close returns, one after another
+	store.closeAllKlines()
+	if len(closed) != 2 {
+		t.Errorf("closed %v before closeAllKlines returned, want both symbols", closed)
+	}
+}
+
+func TestStore_Update_Gap(t *testing.T) {
+	store := NewStore(5*time.Minute, 12)
+	baseTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	store.Update("BTCUSDT", 50000.0, baseTime)
+	store.Update("BTCUSDT", 50100.0, baseTime.Add(5*time.Minute)) // contiguous
+	if got := store.GapCount("BTCUSDT"); got != 0 {
+		t.Fatalf("GapCount after contiguous close = %d, want 0", got)
+	}
+
+	// Stream stalls: next price arrives three intervals later
+	store.Update("BTCUSDT", 50200.0, baseTime.Add(20*time.Minute))
+	if got := store.GapCount("BTCUSDT"); got != 1 {
+		t.Errorf("GapCount after jump = %d, want 1", got)
+	}
+	current, _ := store.GetCurrentKline("BTCUSDT")
+	if !current.HasGap || !current.OpenTime.Equal(baseTime.Add(20*time.Minute)) {
+		t.Errorf("current = %+v, want HasGap opening at +20m", current)
+	}
+
+	// The flag is kept when the gapped kline closes
+	store.Update("BTCUSDT", 50300.0, baseTime.Add(25*time.Minute))
+	history, _ := store.GetKlines("BTCUSDT")
+	if len(history) != 3 || history[1].HasGap || !history[2].HasGap {
+		t.Errorf("history gap flags = %v, want only the last kline flagged", history)
+	}
+	if got := store.GapCount("ETHUSDT"); got != 0 {
+		t.Errorf("GapCount of unknown symbol = %d, want 0", got)
+	}
+}
+
+func TestStore_Seed(t *testing.T) {
+	store := NewStore(5*time.Minute, 4)
+	store.SetOnClose(func(symbol string, klines []Kline) {
+		t.Error("Seed must not invoke the onClose callback")
+	})
+
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	seed := func(i int) Kline {
+		return Kline{Open: float64(100 + i), High: float64(101 + i), Low: float64(99 + i), Close: float64(100 + i), OpenTime: base.Add(time.Duration(i) * 5 * time.Minute)}
+	}
+
+	// Live data already started at 10:25
+	store.Update("BTCUSDT", 200, base.Add(25*time.Minute))
+
+	// Unordered input; the 10:25 kline overlaps live data and is ignored
+	added := store.Seed("BTCUSDT", []Kline{seed(4), seed(2), seed(3), seed(5), seed(1), seed(0)})
+	if added != 4 {
+		t.Errorf("Seed added %d, want 4 (capped at maxCount)", added)
+	}
+
+	got, ok := store.GetKlines("BTCUSDT")
+	if !ok || len(got) != 4 {
+		t.Fatalf("GetKlines = %d klines, want 4", len(got))
+	}
+	for i, k := range got {
+		want := base.Add(time.Duration(i+1) * 5 * time.Minute)
+		if !k.OpenTime.Equal(want) || !k.IsClosed || k.Symbol != "BTCUSDT" {
+			t.Errorf("kline %d = %+v, want closed BTCUSDT kline at %s", i, k, want.Format("15:04"))
+		}
+		if !k.CloseTime.Equal(want.Add(5 * time.Minute)) {
+			t.Errorf("kline %d CloseTime = %v, want %v", i, k.CloseTime, want.Add(5*time.Minute))
+		}
+	}
+	if cur, ok := store.GetCurrentKline("BTCUSDT"); !ok || cur.Open != 200 {
+		t.Error("Seed must not touch the forming kline")
+	}
+
+	// Seeding again adds nothing older than what is already held
+	if added := store.Seed("BTCUSDT", []Kline{seed(1), seed(2)}); added != 0 {
+		t.Errorf("second Seed added %d, want 0", added)
+	}
+}
+
 func TestStore_RollingWindow(t *testing.T) {
 	maxCount := 3
 	store := NewStore(5*time.Minute, maxCount)
@@ -203,6 +318,78 @@ func TestStore_InvalidPrice(t *testing.T) {
 	}
 }
 
+func TestStore_UpdateVolume(t *testing.T) {
+	store := NewStore(5*time.Minute, 12)
+	baseTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// No current kline yet: volume is dropped
+	store.UpdateVolume("BTCUSDT", 1.0, 1, baseTime)
+
+	store.Update("BTCUSDT", 50000.0, baseTime)
+	store.UpdateVolume("BTCUSDT", 1.5, 3, baseTime.Add(1*time.Minute))             // One aggTrade of 3 fills
+	store.UpdateVolume("BTCUSDT", 2.5, 0, baseTime.Add(2*time.Minute))             // Unknown span counts as 1
+	store.UpdateVolume("BTCUSDT", 0, 1, baseTime.Add(2*time.Minute))               // Ignored
+	store.UpdateVolume("BTCUSDT", 9.0, 1, baseTime.Add(-1*time.Minute))            // Before open, ignored
+	store.UpdateVolume("BTCUSDT", 9.0, 1, baseTime.Add(5*time.Minute+time.Second)) // Next interval, ignored
+
+	current, _ := store.GetCurrentKline("BTCUSDT")
+	if current.Volume != 4.0 {
+		t.Errorf("Volume = %v, want 4.0", current.Volume)
+	}
+	if current.TradeCount != 4 {
+		t.Errorf("TradeCount = %v, want 4", current.TradeCount)
+	}
+
+	// Closing the kline carries volume into history; the new kline starts at zero
+	store.Update("BTCUSDT", 50100.0, baseTime.Add(5*time.Minute))
+	klines, ok := store.GetKlines("BTCUSDT")
+	if !ok || len(klines) != 1 {
+		t.Fatalf("Expected 1 historical kline, got %d", len(klines))
+	}
+	if klines[0].Volume != 4.0 || klines[0].TradeCount != 4 {
+		t.Errorf("Closed kline volume=%v trades=%v, want 4.0/4", klines[0].Volume, klines[0].TradeCount)
+	}
+	current, _ = store.GetCurrentKline("BTCUSDT")
+	if current.Volume != 0 || current.TradeCount != 0 {
+		t.Errorf("New kline volume=%v trades=%v, want 0/0", current.Volume, current.TradeCount)
+	}
+}
+
+func TestStore_CurrentWithMeta(t *testing.T) {
+	store := NewStore(5*time.Minute, 12)
+
+	if _, ok := store.CurrentWithMeta("BTCUSDT"); ok {
+		t.Error("Expected no current kline for unknown symbol")
+	}
+
+	// Current interval: close time lies in the future
+	now := time.Now().UTC()
+	store.Update("BTCUSDT", 50000.0, now)
+
+	current, ok := store.CurrentWithMeta("BTCUSDT")
+	if !ok {
+		t.Fatal("Expected current kline")
+	}
+	wantClose := getKlineOpenTime(now, 5*time.Minute).Add(5 * time.Minute)
+	if !current.CloseTime.Equal(wantClose) {
+		t.Errorf("CloseTime = %v, want %v", current.CloseTime, wantClose)
+	}
+	if current.SecondsToClose < 0 || current.SecondsToClose > 300 {
+		t.Errorf("SecondsToClose = %d, want within [0, 300]", current.SecondsToClose)
+	}
+	if current.Kline.Close != 50000.0 {
+		t.Errorf("Kline.Close = %v, want 50000", current.Kline.Close)
+	}
+
+	// Past interval that hasn't been closed yet: never negative
+	past := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	store.Update("ETHUSDT", 3000.0, past)
+	current, _ = store.CurrentWithMeta("ETHUSDT")
+	if current.SecondsToClose != 0 {
+		t.Errorf("SecondsToClose = %d, want 0 for overdue kline", current.SecondsToClose)
+	}
+}
+
 func TestStore_CleanupStale(t *testing.T) {
 	store := NewStore(5*time.Minute, 12)
 	now := time.Now()
@@ -459,3 +646,64 @@ func TestProperty_NewStoreNeverPanics(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+func TestStore_WriteAllKlinesJSON(t *testing.T) {
+	store := NewStore(5*time.Minute, 10)
+	baseTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		store.Update("BTCUSDT", float64(50000+i*100), baseTime.Add(time.Duration(i*5)*time.Minute))
+	}
+	store.UpdateVolume("BTCUSDT", 1.5, 1, baseTime.Add(15*time.Minute))
+
+	var buf bytes.Buffer
+	ok, err := store.WriteAllKlinesJSON(&buf, "BTCUSDT")
+	if !ok || err != nil {
+		t.Fatalf("WriteAllKlinesJSON = %v, %v", ok, err)
+	}
+
+	klines, _ := store.GetAllKlines("BTCUSDT")
+	want, _ := json.Marshal(klines)
+	if buf.String() != string(want) {
+		t.Errorf("streamed JSON differs from json.Marshal:\n got %s\nwant %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if ok, _ := store.WriteAllKlinesJSON(&buf, "ETHUSDT"); ok || buf.Len() != 0 {
+		t.Errorf("unknown symbol: ok=%v wrote %q", ok, buf.String())
+	}
+}
+
+// newFullStore returns a store holding maxCount closed klines plus a forming one.
+func newFullStore(maxCount int) *Store {
+	store := NewStore(time.Minute, maxCount)
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= maxCount; i++ {
+		store.Update("BTCUSDT", float64(50000+i), baseTime.Add(time.Duration(i)*time.Minute))
+	}
+	return store
+}
+
+func BenchmarkKlinesJSON_CopyThenMarshal(b *testing.B) {
+	store := newFullStore(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		klines, _ := store.GetAllKlines("BTCUSDT")
+		if _, err := json.Marshal(klines); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKlinesJSON_Stream(b *testing.B) {
+	store := newFullStore(5000)
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if _, err := store.WriteAllKlinesJSON(&buf, "BTCUSDT"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ActiveHours is a daily window of wall-clock time in Location. Start and
+// End are offsets from local midnight; an End before Start wraps past
+// midnight (e.g. 22:00-06:00).
+type ActiveHours struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location // Nil means UTC
+}
+
+// ParseActiveHours parses a "HH:MM-HH:MM" window in the named timezone
+// (empty means UTC). An empty spec returns nil, meaning always active.
+func ParseActiveHours(spec, tz string) (*ActiveHours, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid active hours %q (want HH:MM-HH:MM)", spec)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid active hours %q: %v", spec, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid active hours %q: %v", spec, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid active hours %q: empty window", spec)
+	}
+	loc := time.UTC
+	if tz = strings.TrimSpace(tz); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid active hours timezone %q: %v", tz, err)
+		}
+	}
+	return &ActiveHours{Start: start, End: end, Location: loc}, nil
+}
+
+// parseClock parses "HH:MM" (00:00-24:00) into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || h == 24 && m > 0 {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Contains reports whether t falls within the window. Start is inclusive,
+// End exclusive.
+func (a *ActiveHours) Contains(t time.Time) bool {
+	loc := a.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	at := t.Sub(midnight)
+	if a.Start < a.End {
+		return at >= a.Start && at < a.End
+	}
+	return at >= a.Start || at < a.End
+}
+
+// String formats the window as "HH:MM-HH:MM TZ".
+func (a *ActiveHours) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	loc := a.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return clock(a.Start) + "-" + clock(a.End) + " " + loc.String()
+}
+
+// inactive reports whether ActiveHours is set and ts falls outside it.
+func (m *Monitor) inactive(ts time.Time) bool {
+	return m.ActiveHours != nil && !m.ActiveHours.Contains(ts)
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+)
+
+// activityCounter tracks the distinct levels each symbol crossed in the
+// current day. The zero value is ready to use.
+type activityCounter struct {
+	mu     sync.Mutex
+	day    time.Time                      // start of the day the levels belong to
+	levels map[string]map[string]struct{} // symbol -> "period|level"
+}
+
+// activityDayStart returns the start of the activity day containing t:
+// UTC midnight shifted by ActivityDayOffset. Zero matches the Binance daily
+// close (00:00 UTC, 08:00 Asia/Shanghai).
+func (m *Monitor) activityDayStart(t time.Time) time.Time {
+	return t.UTC().Add(-m.ActivityDayOffset).Truncate(24 * time.Hour).Add(m.ActivityDayOffset)
+}
+
+// rollLocked drops the counts when day is later than the counted day.
+func (a *activityCounter) rollLocked(day time.Time) {
+	if a.levels == nil || day.After(a.day) {
+		a.day = day
+		a.levels = make(map[string]map[string]struct{})
+	}
+}
+
+// recordActivity counts the level of a cross signal towards its symbol's
+// daily activity. Touches are not crossings and walks repeat crosses
+// already counted, so both are ignored.
+func (m *Monitor) recordActivity(sig signalpkg.Signal) {
+	if sig.Kind == signalpkg.KindTouch || sig.Kind == signalpkg.KindWalk {
+		return
+	}
+	day := m.activityDayStart(sig.TriggeredAt)
+
+	a := &m.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rollLocked(day)
+	if day.Before(a.day) {
+		return // late signal from the previous day
+	}
+	set := a.levels[sig.Symbol]
+	if set == nil {
+		set = make(map[string]struct{})
+		a.levels[sig.Symbol] = set
+	}
+	set[sig.Period+"|"+sig.Level] = struct{}{}
+}
+
+// SignalActivity returns the start of the activity day containing now and
+// the number of distinct pivot levels (per period) each symbol crossed
+// since then.
+func (m *Monitor) SignalActivity(now time.Time) (time.Time, map[string]int) {
+	day := m.activityDayStart(now)
+
+	a := &m.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rollLocked(day)
+	counts := make(map[string]int, len(a.levels))
+	for symbol, set := range a.levels {
+		counts[symbol] = len(set)
+	}
+	return a.day, counts
+}
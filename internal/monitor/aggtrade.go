@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/backoff"
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/kline"
+	"github.com/gorilla/websocket"
+)
+
+// AggTradeFeed subscribes to aggTrade streams and accumulates traded volume
+// into the kline store. Mark price still drives kline OHLC and closing.
+type AggTradeFeed struct {
+	KlineStore *kline.Store
+	Symbols    []string
+	Backoff    backoff.Policy // Reconnect delays; zero uses backoff defaults
+}
+
+// NewAggTradeFeed creates a new aggTrade feed for the given symbols.
+func NewAggTradeFeed(store *kline.Store, symbols []string) *AggTradeFeed {
+	return &AggTradeFeed{KlineStore: store, Symbols: symbols}
+}
+
+// Run starts one connection per MaxStreamsPerConn symbols and blocks until ctx is done.
+func (f *AggTradeFeed) Run(ctx context.Context) {
+	if f.KlineStore == nil || len(f.Symbols) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	chunks := 0
+	for start := 0; start < len(f.Symbols); start += binance.MaxStreamsPerConn {
+		end := start + binance.MaxStreamsPerConn
+		if end > len(f.Symbols) {
+			end = len(f.Symbols)
+		}
+		chunks++
+		go func(symbols []string) {
+			defer func() { done <- struct{}{} }()
+			f.runConn(ctx, symbols)
+		}(f.Symbols[start:end])
+	}
+
+	for i := 0; i < chunks; i++ {
+		<-done
+	}
+}
+
+func (f *AggTradeFeed) runConn(ctx context.Context, symbols []string) {
+	bo := f.Backoff.New()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		conn, _, err := binance.DialAggTrade(ctx, symbols)
+		if err != nil {
+			log.Printf("aggtrade ws dial failed: %v", err)
+			if !bo.Wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		log.Printf("aggtrade ws connected symbols=%d", len(symbols))
+		bo.Reset()
+
+		err = f.readLoop(ctx, conn)
+		_ = conn.Close()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("aggtrade ws read loop exit: %v", err)
+		}
+
+		if !bo.Wait(ctx) {
+			return
+		}
+	}
+}
+
+func (f *AggTradeFeed) readLoop(ctx context.Context, conn *websocket.Conn) error {
+	_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(20 * time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				_ = conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(5*time.Second))
+			}
+		}
+	}()
+	defer close(done)
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+		ev, ok := binance.ParseAggTradeMessage(b)
+		if !ok {
+			continue
+		}
+		ts := time.Now().UTC()
+		if ev.TradeTime > 0 {
+			ts = time.UnixMilli(ev.TradeTime).UTC()
+		}
+		f.KlineStore.UpdateVolume(ev.Symbol, ev.Quantity, ev.Trades(), ts)
+	}
+}
@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"math"
+
+	"example.com/binance-pivot-monitor/internal/pivot"
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+)
+
+// applyConfluence tags sig with the nearest level of the other period
+// (weekly for a daily signal and vice versa) within ConfluencePct of the
+// level sig fired on. Collapsed and missing levels are ignored.
+func (m *Monitor) applyConfluence(sig *signalpkg.Signal, period pivot.Period) {
+	if m.ConfluencePct <= 0 || m.PivotStore == nil {
+		return
+	}
+
+	lv, ok := m.PivotStore.GetLevels(period, sig.Symbol)
+	if !ok {
+		return
+	}
+	levelPrice := 0.0
+	for _, ld := range append(lv.Named(), lv.MidPivots().Named()...) {
+		if ld.Name == sig.Level {
+			levelPrice = ld.Price
+			break
+		}
+	}
+	if levelPrice <= 0 {
+		return
+	}
+
+	other := pivot.PeriodWeekly
+	if period == pivot.PeriodWeekly {
+		other = pivot.PeriodDaily
+	}
+	olv, ok := m.PivotStore.GetLevels(other, sig.Symbol)
+	if !ok {
+		return
+	}
+
+	best := ""
+	bestPct := m.ConfluencePct
+	for _, ld := range olv.Named() {
+		if ld.Price <= 0 || olv.IsCollapsed(ld.Name) {
+			continue
+		}
+		pct := math.Abs(ld.Price-levelPrice) / levelPrice * 100
+		if pct <= bestPct {
+			best, bestPct = ld.Name, pct
+		}
+	}
+	if best != "" {
+		sig.Confluence = best
+		sig.ConfluencePeriod = string(other)
+	}
+}
@@ -7,30 +7,128 @@ import (
 	"compress/zlib"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/backoff"
 	"example.com/binance-pivot-monitor/internal/binance"
 	"example.com/binance-pivot-monitor/internal/kline"
 	"example.com/binance-pivot-monitor/internal/pattern"
 	"example.com/binance-pivot-monitor/internal/pivot"
 	signalpkg "example.com/binance-pivot-monitor/internal/signal"
 	"example.com/binance-pivot-monitor/internal/sse"
+	"example.com/binance-pivot-monitor/internal/ticker"
 	"github.com/gorilla/websocket"
 )
 
+// CooldownScope controls which signals share a cooldown.
+type CooldownScope string
+
+const (
+	CooldownScopeLevel        CooldownScope = "level"         // symbol|period|level (default)
+	CooldownScopeSymbolPeriod CooldownScope = "symbol-period" // symbol|period
+	CooldownScopeSymbol       CooldownScope = "symbol"        // symbol
+)
+
+// ParseCooldownScope parses a scope name; empty means CooldownScopeLevel.
+func ParseCooldownScope(s string) (CooldownScope, error) {
+	switch CooldownScope(strings.ToLower(strings.TrimSpace(s))) {
+	case "", CooldownScopeLevel:
+		return CooldownScopeLevel, nil
+	case CooldownScopeSymbolPeriod:
+		return CooldownScopeSymbolPeriod, nil
+	case CooldownScopeSymbol:
+		return CooldownScopeSymbol, nil
+	default:
+		return "", fmt.Errorf("unknown cooldown scope %q", s)
+	}
+}
+
 type Monitor struct {
 	PivotStore     *pivot.Store
 	Broker         *sse.Broker[signalpkg.Signal]
 	History        *signalpkg.History
 	Cooldown       *signalpkg.Cooldown
+	CooldownScope  CooldownScope // Empty means CooldownScopeLevel
 	Source         string
 	HeartbeatEvery time.Duration
 
+	// Backoff controls delays between reconnects/restarts of the price source.
+	// Zero fields use the backoff package defaults (1s..30s, 20% jitter).
+	Backoff backoff.Policy
+
+	// TrendAwareLevels only watches resistance levels (R1-R5) while the
+	// recent klines trend up and support levels (S1-S5) while they trend
+	// down; PP and trendless symbols watch every level. Signals carry the trend.
+	TrendAwareLevels bool
+
+	// WatchMidPivots also emits signals for the mid-pivots M1-M4
+	// (see pivot.Levels.MidPivots). Off by default.
+	WatchMidPivots bool
+
+	// RearmBand adds hysteresis: once price crosses a level, that
+	// symbol/period/level stays disarmed until price moves at least this
+	// fraction of the level away from it (either side). Zero disables it.
+	RearmBand float64
+
+	// TouchTolerance also emits a "touch" signal when price comes within
+	// this fraction of a level (e.g. 0.001) without crossing it: once per
+	// approach, when price enters the band. Zero disables touches.
+	TouchTolerance float64
+
+	// ConfluencePct marks a signal as confluent when a level of the other
+	// period (weekly for daily, daily for weekly) lies within this
+	// percentage of the crossed level. Zero disables it.
+	ConfluencePct float64
+
+	// WalkWindow emits a "walk" signal when price crosses three or more
+	// consecutive levels in one direction (e.g. R3, R4 then R5), each
+	// within this long of the previous cross. Zero disables walks.
+	WalkWindow time.Duration
+
+	// ActivityDayOffset moves the daily reset of SignalActivity from 00:00
+	// UTC (08:00 Asia/Shanghai, the Binance daily close) by this much.
+	ActivityDayOffset time.Duration
+
+	// ActiveHours, when set, only publishes pivot signals to Broker while
+	// their trigger time lies within the window. Signals outside it are
+	// still recorded to History and the combiner for later analysis.
+	ActiveHours *ActiveHours
+
+	// PriceSource feeds mark prices to Run. Nil means the Binance websocket.
+	PriceSource PriceSource
+
+	// WatchSymbols, when set and PriceSource is nil, streams only these
+	// USDT-margined symbols' mark prices (at most binance.MaxStreamsPerConn)
+	// instead of the all-market array, for lightweight deployments.
+	WatchSymbols []string
+
+	// CoinSymbols, when set and PriceSource is nil, also streams the mark
+	// prices of the COIN-margined symbols it returns (read on each reconnect)
+	// next to the USDT-margined feed. Symbols past binance.MaxStreamsPerConn
+	// are logged and not watched.
+	CoinSymbols func() []string
+
+	// MaxClockSkew drops events whose timestamp is further than this from
+	// the local clock (stale buffered events or a wrong server time would
+	// otherwise open klines far in the future). Zero uses DefaultMaxClockSkew;
+	// negative disables the check.
+	MaxClockSkew time.Duration
+
+	// MaxDecompressedBytes caps how far a compressed websocket frame may
+	// expand. Larger frames are dropped and counted in heartbeat logs.
+	// Zero uses DefaultMaxDecompressedBytes.
+	MaxDecompressedBytes int64
+
 	// K-line pattern recognition
 	KlineStore      *kline.Store
 	PatternDetector *pattern.Detector
@@ -38,11 +136,61 @@ type Monitor struct {
 	PatternBroker   *sse.Broker[pattern.Signal]
 	SignalCombiner  *signalpkg.Combiner
 
-	idCounter   uint64
-	lastPrice   map[string]float64
-	symbolsSeen int64
+	// ExtraKlineStores are additional timeframes (e.g. 5m next to a 15m
+	// KlineStore). Each feeds the same detector; signals carry the timeframe.
+	ExtraKlineStores []*kline.Store
+
+	// Liquidity filter for pattern detection: symbols whose 24h quote volume
+	// is below MinPatternVolume are skipped. Zero disables the check.
+	TickerStore      *ticker.Store
+	MinPatternVolume float64
+
+	// PivotProximityPct boosts pattern signals whose kline closed (or wicked)
+	// within this percentage of a pivot level: Confidence is raised by
+	// PivotConfidenceBoost (capped at 100) and AtPivot names the level.
+	// Zero disables the check; a zero boost uses DefaultPivotConfidenceBoost.
+	PivotProximityPct    float64
+	PivotConfidenceBoost int
+
+	// PatternSSEMinConfidence is the minimum confidence for a pattern signal
+	// to be published to PatternBroker. Lower ones are still recorded to
+	// PatternHistory. Zero publishes everything.
+	PatternSSEMinConfidence int
+
+	// PatternWorkers is the number of goroutines running pattern detection.
+	// Kline closes are queued and dropped (and counted) when the queue is full.
+	PatternWorkers int
+
+	// DetectionWindow is how many of the newest klines are passed to the
+	// pattern detector. The kline stores may retain more (e.g. for charts).
+	// Zero passes the whole retained history.
+	DetectionWindow int
+
+	idCounter    uint64
+	lastPrice    map[string]float64
+	disarmed     map[string]struct{}   // RearmBand state, keyed like CooldownScopeLevel
+	walks        map[string]*walkState // WalkWindow state, keyed symbol|period
+	symbolsSeen  int64
+	skewRejected int64
+
+	patternQueue   chan klineCloseEvent
+	patternDropped uint64
+	patternsOff    uint32 // set by SetPatternsEnabled(false)
+	workersOnce    sync.Once
+	workersWG      sync.WaitGroup
+
+	activity activityCounter
 }
 
+// DefaultMaxClockSkew is the default tolerance for event timestamps.
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// DefaultMaxDecompressedBytes is the default cap on a decompressed frame.
+const DefaultMaxDecompressedBytes = 10 << 20
+
+// DefaultPivotConfidenceBoost is the confidence added to patterns at a pivot level.
+const DefaultPivotConfidenceBoost = 10
+
 func New(pivotStore *pivot.Store, broker *sse.Broker[signalpkg.Signal], history *signalpkg.History, cooldown *signalpkg.Cooldown) *Monitor {
 	return &Monitor{
 		PivotStore: pivotStore,
@@ -60,47 +208,72 @@ type MonitorConfig struct {
 	Broker          *sse.Broker[signalpkg.Signal]
 	History         *signalpkg.History
 	Cooldown        *signalpkg.Cooldown
+	CooldownScope   CooldownScope
 	KlineStore      *kline.Store
 	PatternDetector *pattern.Detector
 	PatternHistory  *pattern.History
 	PatternBroker   *sse.Broker[pattern.Signal]
 	SignalCombiner  *signalpkg.Combiner
+
+	ExtraKlineStores []*kline.Store
+	TickerStore      *ticker.Store
+	MinPatternVolume float64
+	PatternWorkers   int
+	DetectionWindow  int
 }
 
 // NewWithConfig creates a new monitor with full configuration.
 func NewWithConfig(cfg MonitorConfig) *Monitor {
 	m := &Monitor{
-		PivotStore:      cfg.PivotStore,
-		Broker:          cfg.Broker,
-		History:         cfg.History,
-		Cooldown:        cfg.Cooldown,
-		KlineStore:      cfg.KlineStore,
-		PatternDetector: cfg.PatternDetector,
-		PatternHistory:  cfg.PatternHistory,
-		PatternBroker:   cfg.PatternBroker,
-		SignalCombiner:  cfg.SignalCombiner,
-		Source:          "markPrice",
-		lastPrice:       make(map[string]float64),
-	}
-
-	// Set up kline close callback for pattern detection
-	if m.KlineStore != nil && m.PatternDetector != nil {
-		m.KlineStore.SetOnClose(m.onKlineClose)
+		PivotStore:       cfg.PivotStore,
+		Broker:           cfg.Broker,
+		History:          cfg.History,
+		Cooldown:         cfg.Cooldown,
+		CooldownScope:    cfg.CooldownScope,
+		KlineStore:       cfg.KlineStore,
+		PatternDetector:  cfg.PatternDetector,
+		PatternHistory:   cfg.PatternHistory,
+		PatternBroker:    cfg.PatternBroker,
+		SignalCombiner:   cfg.SignalCombiner,
+		ExtraKlineStores: cfg.ExtraKlineStores,
+		TickerStore:      cfg.TickerStore,
+		MinPatternVolume: cfg.MinPatternVolume,
+		PatternWorkers:   cfg.PatternWorkers,
+		DetectionWindow:  cfg.DetectionWindow,
+		Source:           "markPrice",
+		lastPrice:        make(map[string]float64),
+	}
+
+	// Set up kline close callbacks for pattern detection, one per timeframe.
+	// Closes are queued and processed by a bounded worker pool started in Run.
+	if m.PatternDetector != nil {
+		for _, ks := range m.klineStores() {
+			if m.patternQueue == nil {
+				m.patternQueue = make(chan klineCloseEvent, patternQueueSize)
+			}
+			timeframe := ks.Timeframe()
+			ks.SetOnClose(func(symbol string, klines []kline.Kline) {
+				m.enqueueKlineClose(timeframe, symbol, klines)
+			})
+		}
 	}
 
 	return m
 }
 
-func decodeMarkPriceEvents(b []byte) ([]binance.MarkPriceEvent, bool) {
+// decodeMarkPriceEvents parses a frame, decompressing it up to maxDecompressed
+// bytes if needed. tooLarge reports a compressed frame exceeding the cap.
+func decodeMarkPriceEvents(b []byte, maxDecompressed int64) (events []binance.MarkPriceEvent, ok, tooLarge bool) {
 	if ev, ok := parseMarkPriceEventsJSON(b); ok {
-		return ev, true
+		return ev, true, false
 	}
-	if dec, ok := maybeDecompress(b); ok {
+	dec, ok, tooLarge := maybeDecompress(b, maxDecompressed)
+	if ok {
 		if ev, ok := parseMarkPriceEventsJSON(dec); ok {
-			return ev, true
+			return ev, true, false
 		}
 	}
-	return nil, false
+	return nil, false, tooLarge
 }
 
 func parseMarkPriceEventsJSON(b []byte) ([]binance.MarkPriceEvent, bool) {
@@ -134,6 +307,14 @@ func parseMarkPriceEventsJSON(b []byte) ([]binance.MarkPriceEvent, bool) {
 			}
 		}
 
+		// Combined streams of per-symbol events (dapi) wrap one event each
+		var wrappedOne struct {
+			Data binance.MarkPriceEvent `json:"data"`
+		}
+		if err := json.Unmarshal(bb, &wrappedOne); err == nil && wrappedOne.Data.Symbol != "" && wrappedOne.Data.MarkPrice != "" {
+			return []binance.MarkPriceEvent{wrappedOne.Data}, true
+		}
+
 		var single binance.MarkPriceEvent
 		if err := json.Unmarshal(bb, &single); err == nil {
 			if single.Symbol != "" && single.MarkPrice != "" {
@@ -177,213 +358,168 @@ func trimAfterJSONEnd(bb []byte) []byte {
 	return cand
 }
 
-func maybeDecompress(b []byte) ([]byte, bool) {
+// maybeDecompress tries gzip, zlib and raw deflate in turn. A frame that
+// expands beyond limit bytes (zero means DefaultMaxDecompressedBytes) is
+// rejected with tooLarge set.
+func maybeDecompress(b []byte, limit int64) (out []byte, ok, tooLarge bool) {
+	if limit <= 0 {
+		limit = DefaultMaxDecompressedBytes
+	}
+
 	bb := bytes.TrimSpace(b)
 	if len(bb) == 0 {
-		return nil, false
+		return nil, false, false
 	}
 	if bb[0] == '{' || bb[0] == '[' {
-		return nil, false
+		return nil, false, false
 	}
 
 	if len(bb) >= 2 && bb[0] == 0x1f && bb[1] == 0x8b {
-		if out, ok := decompressWith(func() (io.ReadCloser, error) {
+		out, ok, tooLarge := decompressWith(func() (io.ReadCloser, error) {
 			return gzip.NewReader(bytes.NewReader(bb))
-		}); ok {
-			return out, true
+		}, limit)
+		if ok || tooLarge {
+			return out, ok, tooLarge
 		}
 	}
 
 	if len(bb) >= 2 && bb[0] == 0x78 {
-		if out, ok := decompressWith(func() (io.ReadCloser, error) {
+		out, ok, tooLarge := decompressWith(func() (io.ReadCloser, error) {
 			return zlib.NewReader(bytes.NewReader(bb))
-		}); ok {
-			return out, true
+		}, limit)
+		if ok || tooLarge {
+			return out, ok, tooLarge
 		}
 	}
 
-	if out, ok := decompressWith(func() (io.ReadCloser, error) {
+	return decompressWith(func() (io.ReadCloser, error) {
 		return io.NopCloser(flate.NewReader(bytes.NewReader(bb))), nil
-	}); ok {
-		return out, true
-	}
-
-	return nil, false
+	}, limit)
 }
 
-func decompressWith(newReader func() (io.ReadCloser, error)) ([]byte, bool) {
+func decompressWith(newReader func() (io.ReadCloser, error), limit int64) ([]byte, bool, bool) {
 	r, err := newReader()
 	if err != nil {
-		return nil, false
+		return nil, false, false
 	}
 	defer r.Close()
-	out, err := io.ReadAll(io.LimitReader(r, 10<<20))
+	// Read one byte past the limit to tell a frame of exactly limit bytes
+	// from one that would have been truncated.
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(out)) > limit {
+		return nil, false, true
+	}
 	if err != nil || len(out) == 0 {
-		return nil, false
+		return nil, false, false
 	}
-	return out, true
+	return out, true, false
 }
 
+// Run streams prices until ctx is done. It returns once the pattern
+// workers have also stopped, so histories can be closed safely afterwards.
 func (m *Monitor) Run(ctx context.Context) {
-	backoff := 1 * time.Second
+	m.startPatternWorkers(ctx)
+	defer m.workersWG.Wait()
+
+	src := m.PriceSource
+	if src == nil {
+		bs := &BinanceSource{HeartbeatEvery: m.HeartbeatEvery, SymbolsSeen: m.SymbolsSeen, SkewRejected: m.SkewRejected, Backoff: m.Backoff, MaxDecompressedBytes: m.MaxDecompressedBytes}
+		if len(m.WatchSymbols) > 0 {
+			bs.Dial = m.dialWatched
+		}
+		src = bs
+		if m.CoinSymbols != nil {
+			src = MultiSource{src, &BinanceSource{Name: "monitor coin ws", Dial: m.dialCoin, HeartbeatEvery: m.HeartbeatEvery, Backoff: m.Backoff, MaxDecompressedBytes: m.MaxDecompressedBytes}}
+		}
+	}
+
+	bo := m.Backoff.New()
 	for {
 		if ctx.Err() != nil {
 			return
 		}
 
-		conn, _, err := binance.DialMarkPriceArr1s(ctx)
+		events, err := src.Stream(ctx)
 		if err != nil {
-			log.Printf("monitor ws dial failed: %v", err)
-			if !sleepContext(ctx, backoff) {
+			log.Printf("monitor price source failed: %v", err)
+			if !bo.Wait(ctx) {
 				return
 			}
-			backoff = minDuration(backoff*2, 30*time.Second)
 			continue
 		}
+		bo.Reset()
 
-		log.Printf("monitor ws connected")
-		backoff = 1 * time.Second
-
-		err = m.readLoop(ctx, conn)
-		_ = conn.Close()
-		if err != nil && ctx.Err() == nil {
-			log.Printf("monitor ws read loop exit: %v", err)
+		for batch := range events {
+			m.handleEvents(batch)
 		}
 
-		if !sleepContext(ctx, backoff) {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("monitor price source closed, restarting")
+		if !bo.Wait(ctx) {
 			return
 		}
-		backoff = minDuration(backoff*2, 30*time.Second)
 	}
 }
 
-func (m *Monitor) readLoop(ctx context.Context, conn *websocket.Conn) error {
-	_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-	conn.SetPongHandler(func(string) error {
-		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
+// SymbolsSeen returns the number of distinct symbols that have received a price.
+func (m *Monitor) SymbolsSeen() int64 {
+	return atomic.LoadInt64(&m.symbolsSeen)
+}
 
-	hbEvery := m.HeartbeatEvery
-	var hbMsgs int64
-	var hbEvents int64
-	var hbUnmarshalErr int64
-	var hbLastMsgUnixNano int64
-	atomic.StoreInt64(&hbLastMsgUnixNano, time.Now().UnixNano())
-
-	hbDone := make(chan struct{})
-	if hbEvery > 0 {
-		go func() {
-			t := time.NewTicker(hbEvery)
-			defer t.Stop()
-			for {
-				select {
-				case <-hbDone:
-					return
-				case <-ctx.Done():
-					return
-				case <-t.C:
-					msgs := atomic.SwapInt64(&hbMsgs, 0)
-					events := atomic.SwapInt64(&hbEvents, 0)
-					bad := atomic.SwapInt64(&hbUnmarshalErr, 0)
-					last := time.Unix(0, atomic.LoadInt64(&hbLastMsgUnixNano))
-					symbols := atomic.LoadInt64(&m.symbolsSeen)
-					log.Printf("monitor ws heartbeat msgs=%d events=%d unmarshal_err=%d last_msg_ago=%s symbols_seen=%d", msgs, events, bad, time.Since(last).Round(time.Second), symbols)
-				}
-			}
-		}()
-	}
-	defer close(hbDone)
+// SkewRejected returns the number of events dropped for exceeding MaxClockSkew.
+func (m *Monitor) SkewRejected() int64 {
+	return atomic.LoadInt64(&m.skewRejected)
+}
 
-	done := make(chan struct{})
-	go func() {
-		t := time.NewTicker(20 * time.Second)
-		defer t.Stop()
-		for {
-			select {
-			case <-done:
-				return
-			case <-ctx.Done():
-				return
-			case <-t.C:
-				_ = conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(5*time.Second))
-			}
-		}
-	}()
-	defer close(done)
+// dialWatched dials the per-symbol mark price streams of WatchSymbols.
+func (m *Monitor) dialWatched(ctx context.Context) (*websocket.Conn, *http.Response, error) {
+	symbols := m.WatchSymbols
+	if len(symbols) > binance.MaxStreamsPerConn {
+		symbols = symbols[:binance.MaxStreamsPerConn]
+	}
+	return binance.DialMarkPrice(ctx, symbols)
+}
 
-	unmarshalSampleLogged := 0
-	for {
-		if ctx.Err() != nil {
-			return ctx.Err()
-		}
+// dialCoin dials the COIN-margined mark price streams for CoinSymbols.
+func (m *Monitor) dialCoin(ctx context.Context) (*websocket.Conn, *http.Response, error) {
+	symbols := m.CoinSymbols()
+	if len(symbols) == 0 {
+		return nil, nil, errors.New("no coin-margined symbols yet")
+	}
+	if len(symbols) > binance.MaxStreamsPerConn {
+		dropped := symbols[binance.MaxStreamsPerConn:]
+		log.Printf("monitor coin ws: %d coin-margined symbols exceed %d streams per connection, not watching %s",
+			len(symbols), binance.MaxStreamsPerConn, strings.Join(dropped, ","))
+		symbols = symbols[:binance.MaxStreamsPerConn]
+	}
+	return binance.DialCoinMarkPrice1s(ctx, symbols)
+}
 
-		mt, b, err := conn.ReadMessage()
+// handleEvents applies a batch of decoded mark price events.
+func (m *Monitor) handleEvents(events []binance.MarkPriceEvent) {
+	now := time.Now().UTC()
+	maxSkew := m.MaxClockSkew
+	if maxSkew == 0 {
+		maxSkew = DefaultMaxClockSkew
+	}
+	for _, ev := range events {
+		price, err := strconv.ParseFloat(ev.MarkPrice, 64)
 		if err != nil {
-			return err
-		}
-		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		if hbEvery > 0 {
-			atomic.AddInt64(&hbMsgs, 1)
-			atomic.StoreInt64(&hbLastMsgUnixNano, time.Now().UnixNano())
-		}
-
-		events, ok := decodeMarkPriceEvents(b)
-		if !ok {
-			if hbEvery > 0 {
-				atomic.AddInt64(&hbUnmarshalErr, 1)
-			}
-			if unmarshalSampleLogged < 3 {
-				unmarshalSampleLogged += 1
-				head := b
-				if len(head) > 32 {
-					head = head[:32]
-				}
-				tail := b
-				if len(tail) > 32 {
-					tail = tail[len(tail)-32:]
-				}
-				log.Printf("monitor ws unmarshal sample mt=%d len=%d head_hex=%x tail_hex=%x", mt, len(b), head, tail)
-				trimmed := bytes.TrimSpace(b)
-				if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
-					prefix := string(trimmed)
-					if len(prefix) > 160 {
-						prefix = prefix[:160]
-					}
-					log.Printf("monitor ws unmarshal sample prefix=%q", prefix)
-				}
-
-				bb := cleanJSONBytes(b)
-				if len(bb) > 0 && (bb[0] == '[' || bb[0] == '{') {
-					var tmp []binance.MarkPriceEvent
-					if err0 := json.Unmarshal(bb, &tmp); err0 != nil {
-						log.Printf("monitor ws unmarshal err_clean=%v", err0)
-					}
-					if cand := trimAfterJSONEnd(bb); cand != nil {
-						if err1 := json.Unmarshal(cand, &tmp); err1 != nil {
-							log.Printf("monitor ws unmarshal err_trim=%v", err1)
-						}
-					}
-				}
-			}
 			continue
 		}
-		if hbEvery > 0 {
-			atomic.AddInt64(&hbEvents, int64(len(events)))
-		}
-
-		now := time.Now().UTC()
-		for _, ev := range events {
-			price, err := strconv.ParseFloat(ev.MarkPrice, 64)
-			if err != nil {
-				continue
-			}
-			ts := now
-			if ev.EventTime > 0 {
-				ts = time.UnixMilli(ev.EventTime).UTC()
+		ts := now
+		if ev.EventTime > 0 {
+			ts = time.UnixMilli(ev.EventTime).UTC()
+			if maxSkew > 0 {
+				if d := ts.Sub(now); d > maxSkew || d < -maxSkew {
+					atomic.AddInt64(&m.skewRejected, 1)
+					continue
+				}
 			}
-			m.onPrice(ev.Symbol, price, ts)
 		}
+		m.onPrice(ev.Symbol, price, ts)
 	}
 }
 
@@ -395,8 +531,8 @@ func (m *Monitor) onPrice(symbol string, price float64, ts time.Time) {
 	}
 
 	// Update kline data (if enabled)
-	if m.KlineStore != nil {
-		m.KlineStore.Update(symbol, price, ts)
+	for _, ks := range m.klineStores() {
+		ks.Update(symbol, price, ts)
 	}
 
 	// Check pivot levels (only if we have previous price)
@@ -404,76 +540,172 @@ func (m *Monitor) onPrice(symbol string, price float64, ts time.Time) {
 		return
 	}
 
-	m.checkPeriod(symbol, pivot.PeriodDaily, prev, price, ts)
-	m.checkPeriod(symbol, pivot.PeriodWeekly, prev, price, ts)
+	trend := m.levelTrend(symbol)
+	m.checkPeriod(symbol, pivot.PeriodDaily, prev, price, ts, trend)
+	m.checkPeriod(symbol, pivot.PeriodWeekly, prev, price, ts, trend)
 }
 
-func (m *Monitor) checkPeriod(symbol string, period pivot.Period, prev, price float64, ts time.Time) {
+// trendLookback is the number of closed klines used to classify the trend,
+// matching the prior-trend window of the reversal patterns.
+const trendLookback = 3
+
+// levelTrend returns the recent kline trend for symbol ("up", "down" or "")
+// when TrendAwareLevels is enabled.
+func (m *Monitor) levelTrend(symbol string) string {
+	if !m.TrendAwareLevels || m.KlineStore == nil {
+		return ""
+	}
+	klines, ok := m.KlineStore.GetKlines(symbol)
+	if !ok || len(klines) < trendLookback {
+		return ""
+	}
+	return pattern.Trend(klines[len(klines)-trendLookback:])
+}
+
+func (m *Monitor) checkPeriod(symbol string, period pivot.Period, prev, price float64, ts time.Time, trend string) {
 	lv, ok := m.PivotStore.GetLevels(period, symbol)
 	if !ok {
 		return
 	}
 
-	// Check all 11 pivot levels: PP, R1-R5, S1-S5
-	m.checkLevel(symbol, period, "PP", lv.PP, prev, price, ts)
-
-	m.checkLevel(symbol, period, "R1", lv.R1, prev, price, ts)
-	m.checkLevel(symbol, period, "R2", lv.R2, prev, price, ts)
-	m.checkLevel(symbol, period, "R3", lv.R3, prev, price, ts)
-	m.checkLevel(symbol, period, "R4", lv.R4, prev, price, ts)
-	m.checkLevel(symbol, period, "R5", lv.R5, prev, price, ts)
-
-	m.checkLevel(symbol, period, "S1", lv.S1, prev, price, ts)
-	m.checkLevel(symbol, period, "S2", lv.S2, prev, price, ts)
-	m.checkLevel(symbol, period, "S3", lv.S3, prev, price, ts)
-	m.checkLevel(symbol, period, "S4", lv.S4, prev, price, ts)
-	m.checkLevel(symbol, period, "S5", lv.S5, prev, price, ts)
+	// Check all 11 pivot levels: PP, R1-R5, S1-S5, skipping collapsed duplicates
+	for _, ld := range lv.Named() {
+		if lv.IsCollapsed(ld.Name) {
+			continue
+		}
+		// Skip the side opposite the trend
+		if (trend == "up" && ld.Name[0] == 'S') || (trend == "down" && ld.Name[0] == 'R') {
+			continue
+		}
+		m.checkLevel(symbol, period, ld.Name, ld.Price, prev, price, ts, trend)
+	}
+	if m.WatchMidPivots {
+		for _, ld := range lv.MidPivots().Named() {
+			m.checkLevel(symbol, period, ld.Name, ld.Price, prev, price, ts, trend)
+		}
+	}
 }
 
-func (m *Monitor) checkLevel(symbol string, period pivot.Period, levelName string, levelPrice float64, prev, price float64, ts time.Time) {
+func (m *Monitor) checkLevel(symbol string, period pivot.Period, levelName string, levelPrice float64, prev, price float64, ts time.Time, trend string) {
 	if levelPrice <= 0 {
 		return
 	}
 
-	if prev < levelPrice && price >= levelPrice {
-		m.emit(symbol, period, levelName, price, "up", ts)
-		return
+	key := symbol + "|" + string(period) + "|" + levelName
+	if m.RearmBand > 0 {
+		if _, ok := m.disarmed[key]; ok {
+			if math.Abs(prev-levelPrice) < m.RearmBand*levelPrice {
+				return
+			}
+			delete(m.disarmed, key)
+		}
 	}
 
-	if prev > levelPrice && price <= levelPrice {
-		m.emit(symbol, period, levelName, price, "down", ts)
+	direction := ""
+	if prev < levelPrice && price >= levelPrice {
+		direction = "up"
+	} else if prev > levelPrice && price <= levelPrice {
+		direction = "down"
+	}
+	if direction == "" {
+		m.checkTouch(symbol, period, levelName, levelPrice, prev, price, ts, trend)
 		return
 	}
-}
 
-func (m *Monitor) emit(symbol string, period pivot.Period, levelName string, price float64, direction string, ts time.Time) {
-	key := symbol + "|" + string(period) + "|" + levelName
-	if m.Cooldown != nil {
-		if !m.Cooldown.Allow(key, ts) {
-			return
+	if m.RearmBand > 0 {
+		if m.disarmed == nil {
+			m.disarmed = make(map[string]struct{})
 		}
+		m.disarmed[key] = struct{}{}
 	}
+	m.emit(symbol, period, levelName, price, direction, signalpkg.KindCross, ts, trend)
+	m.trackWalk(symbol, period, levelName, price, direction, ts, trend)
+}
 
-	log.Printf("signal %s %s %s %s price=%g", symbol, period, levelName, direction, price)
+// checkTouch emits a touch when price enters the TouchTolerance band around
+// a level it did not cross. Direction is the side price approaches from:
+// "up" from below, "down" from above.
+func (m *Monitor) checkTouch(symbol string, period pivot.Period, levelName string, levelPrice float64, prev, price float64, ts time.Time, trend string) {
+	if m.TouchTolerance <= 0 || price == levelPrice {
+		return
+	}
+	band := m.TouchTolerance * levelPrice
+	if math.Abs(price-levelPrice) > band || math.Abs(prev-levelPrice) <= band {
+		return
+	}
+	direction := "up"
+	if price > levelPrice {
+		direction = "down"
+	}
+	m.emit(symbol, period, levelName, price, direction, signalpkg.KindTouch, ts, trend)
+}
 
-	seq := atomic.AddUint64(&m.idCounter, 1)
-	id := fmt.Sprintf("%d-%d", ts.UnixNano(), seq)
+// cooldownKey returns the key passed to Cooldown.Allow for the configured scope.
+func (m *Monitor) cooldownKey(symbol string, period pivot.Period, levelName string) string {
+	switch m.CooldownScope {
+	case CooldownScopeSymbol:
+		return symbol
+	case CooldownScopeSymbolPeriod:
+		return symbol + "|" + string(period)
+	default:
+		return symbol + "|" + string(period) + "|" + levelName
+	}
+}
 
-	sig := signalpkg.Signal{
-		ID:          id,
+func (m *Monitor) emit(symbol string, period pivot.Period, levelName string, price float64, direction, kind string, ts time.Time, trend string) {
+	m.publish(signalpkg.Signal{
 		Symbol:      symbol,
 		Period:      string(period),
 		Level:       levelName,
 		Price:       price,
 		Direction:   direction,
 		TriggeredAt: ts,
-		Source:      m.Source,
+		Kind:        kind,
+		Trend:       trend,
+	})
+}
+
+// publish assigns sig its ID, source and contract, then records and
+// broadcasts it unless its cooldown suppresses it. Outside ActiveHours it is
+// recorded but not broadcast.
+func (m *Monitor) publish(sig signalpkg.Signal) {
+	period := pivot.Period(sig.Period)
+	key := m.cooldownKey(sig.Symbol, period, sig.Level)
+	if sig.Kind == signalpkg.KindTouch || sig.Kind == signalpkg.KindWalk {
+		// Touches and walks cool down separately so they never suppress a cross
+		key += "|" + sig.Kind
 	}
+	if m.Cooldown != nil {
+		if !m.Cooldown.Allow(key, sig.TriggeredAt) {
+			return
+		}
+	}
+
+	seq := atomic.AddUint64(&m.idCounter, 1)
+	sig.ID = fmt.Sprintf("%d-%d", sig.TriggeredAt.UnixNano(), seq)
+	sig.Source = m.Source
+	sig.Contract = binance.ContractType(sig.Symbol)
+	m.applyConfluence(&sig, period)
+
+	extra := ""
+	if sig.WalkFrom != "" {
+		extra += " walk_from=" + sig.WalkFrom
+	}
+	if sig.Confluence != "" {
+		extra += " confluence=" + sig.ConfluencePeriod + "/" + sig.Confluence
+	}
+	inactive := m.inactive(sig.TriggeredAt)
+	if inactive {
+		extra += " inactive_hours"
+	}
+	log.Printf("signal %s %s %s %s %s price=%g%s", sig.Symbol, period, sig.Level, sig.Kind, sig.Direction, sig.Price, extra)
+
+	m.recordActivity(sig)
 
 	if m.History != nil {
 		m.History.Add(sig)
 	}
-	if m.Broker != nil {
+	if m.Broker != nil && !inactive {
 		m.Broker.Publish(sig)
 	}
 
@@ -483,33 +715,26 @@ func (m *Monitor) emit(symbol string, period pivot.Period, levelName string, pri
 	}
 }
 
-func sleepContext(ctx context.Context, d time.Duration) bool {
-	if d <= 0 {
-		return true
-	}
-	t := time.NewTimer(d)
-	defer t.Stop()
-	select {
-	case <-ctx.Done():
-		return false
-	case <-t.C:
-		return true
+// klineStores returns KlineStore followed by ExtraKlineStores, skipping nils.
+func (m *Monitor) klineStores() []*kline.Store {
+	stores := make([]*kline.Store, 0, 1+len(m.ExtraKlineStores))
+	if m.KlineStore != nil {
+		stores = append(stores, m.KlineStore)
 	}
-}
-
-func minDuration(a, b time.Duration) time.Duration {
-	if a < b {
-		return a
+	for _, ks := range m.ExtraKlineStores {
+		if ks != nil {
+			stores = append(stores, ks)
+		}
 	}
-	return b
+	return stores
 }
 
-// onKlineClose is called when a kline closes.
-// It triggers pattern detection asynchronously.
+// onKlineClose runs pattern detection for a closed kline on the given timeframe.
+// It is called by the pattern workers for each queued close event.
 // klines is a deep copy snapshot, safe for async use.
-func (m *Monitor) onKlineClose(symbol string, klines []kline.Kline) {
+func (m *Monitor) onKlineClose(timeframe, symbol string, klines []kline.Kline) {
 	// Skip if pattern detection is not enabled
-	if m.PatternDetector == nil {
+	if m.PatternDetector == nil || !m.PatternsEnabled() {
 		return
 	}
 
@@ -528,8 +753,19 @@ func (m *Monitor) onKlineClose(symbol string, klines []kline.Kline) {
 		return
 	}
 
+	// Skip thin symbols: low liquidity produces false doji/engulfing patterns.
+	// Symbols without ticker data yet are not filtered.
+	if !m.hasPatternLiquidity(symbol) {
+		return
+	}
+
+	// The stores may retain more history than the detector needs
+	if m.DetectionWindow > 0 && len(klines) > m.DetectionWindow {
+		klines = klines[len(klines)-m.DetectionWindow:]
+	}
+
 	// Log kline close event for debugging
-	log.Printf("pattern: onKlineClose symbol=%s klines=%d", symbol, len(klines))
+	log.Printf("pattern: onKlineClose symbol=%s timeframe=%s klines=%d", symbol, timeframe, len(klines))
 
 	// Detect patterns with timing (Requirement 7.5: warn if >100ms)
 	startTime := time.Now()
@@ -555,25 +791,106 @@ func (m *Monitor) onKlineClose(symbol string, klines []kline.Kline) {
 
 	// Emit signals for each detected pattern
 	for _, p := range patterns {
-		m.emitPatternSignal(symbol, p, klineTime)
+		sig := pattern.NewSignal(symbol, p.Type, p.Direction, p.Confidence, klineTime)
+		sig.RawScore = p.RawScore
+		sig.SetTimeframe(timeframe)
+		if len(klines) > 0 {
+			m.applyPivotProximity(&sig, klines[len(klines)-1])
+		}
+		m.emitPatternSignal(sig)
 	}
 }
 
-// emitPatternSignal creates and emits a pattern signal.
-func (m *Monitor) emitPatternSignal(symbol string, p pattern.DetectedPattern, klineTime time.Time) {
-	sig := pattern.NewSignal(symbol, p.Type, p.Direction, p.Confidence, klineTime)
+// applyPivotProximity tags sig with the nearest pivot level within
+// PivotProximityPct of the kline and boosts its confidence. Bullish patterns
+// are matched on close/low, bearish on close/high, neutral on all three.
+// Daily levels are checked before weekly ones.
+func (m *Monitor) applyPivotProximity(sig *pattern.Signal, k kline.Kline) {
+	if m.PivotProximityPct <= 0 || m.PivotStore == nil {
+		return
+	}
+
+	prices := []float64{k.Close}
+	switch sig.Direction {
+	case pattern.DirectionBullish:
+		prices = append(prices, k.Low)
+	case pattern.DirectionBearish:
+		prices = append(prices, k.High)
+	default:
+		prices = append(prices, k.Low, k.High)
+	}
+
+	for _, period := range []pivot.Period{pivot.PeriodDaily, pivot.PeriodWeekly} {
+		lv, ok := m.PivotStore.GetLevels(period, sig.Symbol)
+		if !ok {
+			continue
+		}
+
+		best := ""
+		bestPct := m.PivotProximityPct
+		for _, ld := range lv.Named() {
+			if ld.Price <= 0 || lv.IsCollapsed(ld.Name) {
+				continue
+			}
+			for _, p := range prices {
+				if p <= 0 {
+					continue
+				}
+				pct := math.Abs(p-ld.Price) / ld.Price * 100
+				if pct <= bestPct {
+					best, bestPct = ld.Name, pct
+				}
+			}
+		}
+		if best == "" {
+			continue
+		}
+
+		boost := m.PivotConfidenceBoost
+		if boost <= 0 {
+			boost = DefaultPivotConfidenceBoost
+		}
+		sig.Confidence += boost
+		if sig.Confidence > 100 {
+			sig.Confidence = 100
+		}
+		sig.AtPivot = best
+		sig.AtPivotPeriod = string(period)
+		return
+	}
+}
 
-	log.Printf("pattern %s %s %s confidence=%d", symbol, p.Type, p.Direction, p.Confidence)
+// hasPatternLiquidity reports whether a symbol's 24h quote volume meets MinPatternVolume.
+func (m *Monitor) hasPatternLiquidity(symbol string) bool {
+	if m.MinPatternVolume <= 0 || m.TickerStore == nil {
+		return true
+	}
+	t, ok := m.TickerStore.Get(symbol)
+	if !ok {
+		return true
+	}
+	return t.QuoteVolume >= m.MinPatternVolume
+}
 
+// emitPatternSignal records and publishes a pattern signal.
+func (m *Monitor) emitPatternSignal(sig pattern.Signal) {
 	// Record to history
 	if m.PatternHistory != nil {
-		if err := m.PatternHistory.Add(sig); err != nil {
+		if err := m.PatternHistory.Add(sig); errors.Is(err, pattern.ErrDuplicate) {
+			return // already recorded and published
+		} else if err != nil {
 			log.Printf("pattern history add error: %v", err)
 		}
 	}
 
+	if sig.AtPivot != "" {
+		log.Printf("pattern %s %s %s %s confidence=%d at_pivot=%s/%s", sig.Symbol, sig.Timeframe, sig.Pattern, sig.Direction, sig.Confidence, sig.AtPivotPeriod, sig.AtPivot)
+	} else {
+		log.Printf("pattern %s %s %s %s confidence=%d", sig.Symbol, sig.Timeframe, sig.Pattern, sig.Direction, sig.Confidence)
+	}
+
 	// Publish via SSE
-	if m.PatternBroker != nil {
+	if m.PatternBroker != nil && sig.Confidence >= m.PatternSSEMinConfidence {
 		m.PatternBroker.Publish(sig)
 	}
 
@@ -1,14 +1,19 @@
 package monitor
 
 import (
+	"bytes"
+	"compress/gzip"
+	"strings"
 	"testing"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/binance"
 	"example.com/binance-pivot-monitor/internal/kline"
 	"example.com/binance-pivot-monitor/internal/pattern"
 	"example.com/binance-pivot-monitor/internal/pivot"
 	signalpkg "example.com/binance-pivot-monitor/internal/signal"
 	"example.com/binance-pivot-monitor/internal/sse"
+	"example.com/binance-pivot-monitor/internal/ticker"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
@@ -66,7 +71,7 @@ func TestOnKlineClose_SkipsWithoutPivotData(t *testing.T) {
 	}
 
 	// Call onKlineClose for symbol WITHOUT pivot data
-	m.onKlineClose("ETHUSDT", klines)
+	m.onKlineClose("15m", "ETHUSDT", klines)
 
 	// Should not have recorded any patterns (no pivot data for ETHUSDT)
 	if patternHistory.Count() != 0 {
@@ -79,13 +84,186 @@ func TestOnKlineClose_SkipsWithoutPivotData(t *testing.T) {
 		{Symbol: "BTCUSDT", Open: 95, High: 110, Low: 94, Close: 108, IsClosed: true},  // bullish engulfing
 	}
 
-	m.onKlineClose("BTCUSDT", klinesBTC)
+	m.onKlineClose("15m", "BTCUSDT", klinesBTC)
 
 	// Should have recorded patterns (has pivot data for BTCUSDT)
 	// Note: may or may not detect patterns depending on detector config
 	// The key test is that it ATTEMPTS detection (doesn't skip)
 }
 
+// TestOnKlineClose_SkipsLowLiquidity tests that pattern detection is skipped
+// for symbols whose 24h quote volume is below MinPatternVolume.
+func TestOnKlineClose_SkipsLowLiquidity(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "BTCUSDT", pivot.Levels{
+		R3: 50000, R4: 51000, R5: 52000,
+		S3: 48000, S4: 47000, S5: 46000,
+	})
+
+	patternHistory, err := pattern.NewHistory("", 100)
+	if err != nil {
+		t.Fatalf("failed to create pattern history: %v", err)
+	}
+
+	tickerStore := ticker.NewStore()
+	tickerStore.Update("BTCUSDT", 100, 1.5, 1000, 5000)
+
+	m := NewWithConfig(MonitorConfig{
+		PivotStore:       pivotStore,
+		Broker:           sse.NewBroker[signalpkg.Signal](),
+		PatternDetector:  pattern.NewDetector(pattern.DefaultDetectorConfig()),
+		PatternHistory:   patternHistory,
+		PatternBroker:    sse.NewBroker[pattern.Signal](),
+		TickerStore:      tickerStore,
+		MinPatternVolume: 1_000_000,
+	})
+
+	klines := []kline.Kline{
+		{Symbol: "BTCUSDT", Open: 100, High: 105, Low: 95, Close: 96, IsClosed: true},
+		{Symbol: "BTCUSDT", Open: 95, High: 110, Low: 94, Close: 108, IsClosed: true},
+	}
+	m.onKlineClose("15m", "BTCUSDT", klines)
+
+	if patternHistory.Count() != 0 {
+		t.Errorf("expected 0 patterns for low-liquidity symbol, got %d", patternHistory.Count())
+	}
+}
+
+// TestOnKlineClose_PatternsDisabled tests that SetPatternsEnabled(false)
+// stops detection on kline close and re-enabling resumes it.
+func TestOnKlineClose_PatternsDisabled(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "BTCUSDT", pivot.Levels{
+		R3: 50000, R4: 51000, R5: 52000,
+		S3: 48000, S4: 47000, S5: 46000,
+	})
+
+	patternHistory, err := pattern.NewHistory("", 100)
+	if err != nil {
+		t.Fatalf("failed to create pattern history: %v", err)
+	}
+
+	m := NewWithConfig(MonitorConfig{
+		PivotStore:      pivotStore,
+		Broker:          sse.NewBroker[signalpkg.Signal](),
+		PatternDetector: pattern.NewDetector(pattern.DefaultDetectorConfig()),
+		PatternHistory:  patternHistory,
+		PatternBroker:   sse.NewBroker[pattern.Signal](),
+	})
+	if !m.PatternsEnabled() {
+		t.Fatal("patterns disabled by default")
+	}
+
+	klines := []kline.Kline{
+		{Symbol: "BTCUSDT", Open: 100, High: 105, Low: 95, Close: 96, IsClosed: true},
+		{Symbol: "BTCUSDT", Open: 95, High: 110, Low: 94, Close: 108, IsClosed: true},
+	}
+	m.SetPatternsEnabled(false)
+	m.onKlineClose("15m", "BTCUSDT", klines)
+	if patternHistory.Count() != 0 {
+		t.Errorf("expected 0 patterns while disabled, got %d", patternHistory.Count())
+	}
+
+	m.SetPatternsEnabled(true)
+	m.onKlineClose("15m", "BTCUSDT", klines)
+	if patternHistory.Count() == 0 {
+		t.Error("expected patterns after re-enabling detection")
+	}
+}
+
+// TestOnKlineClose_DetectionWindow tests that the kline store can retain more
+// history than the detector receives.
+func TestOnKlineClose_DetectionWindow(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "BTCUSDT", pivot.Levels{
+		R3: 50000, R4: 51000, R5: 52000,
+		S3: 48000, S4: 47000, S5: 46000,
+	})
+
+	// 198 drifting-down klines followed by a bullish engulfing pair
+	ks := kline.NewStore(15*time.Minute, 200)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := make([]kline.Kline, 0, 200)
+	for i := 0; i < 198; i++ {
+		open := 300 - float64(i)
+		seed = append(seed, kline.Kline{Open: open, High: open + 1, Low: open - 1.5, Close: open - 1, OpenTime: start.Add(time.Duration(i) * 15 * time.Minute)})
+	}
+	seed = append(seed,
+		kline.Kline{Open: 100, High: 105, Low: 95, Close: 96, OpenTime: start.Add(198 * 15 * time.Minute)},
+		kline.Kline{Open: 95, High: 110, Low: 94, Close: 108, OpenTime: start.Add(199 * 15 * time.Minute)},
+	)
+	ks.Seed("BTCUSDT", seed)
+	if n := ks.KlineCount("BTCUSDT"); n != 200 {
+		t.Fatalf("store retained %d klines, want 200", n)
+	}
+	klines, _ := ks.GetKlines("BTCUSDT")
+
+	// MinKlines brackets the detector input: 12 klines pass, 13 are required
+	for _, tc := range []struct {
+		minKlines int
+		want      bool
+	}{
+		{12, true},
+		{13, false},
+	} {
+		cfg := pattern.DefaultDetectorConfig()
+		cfg.MinKlines = tc.minKlines
+		patternHistory, err := pattern.NewHistory("", 100)
+		if err != nil {
+			t.Fatalf("failed to create pattern history: %v", err)
+		}
+		m := NewWithConfig(MonitorConfig{
+			PivotStore:      pivotStore,
+			Broker:          sse.NewBroker[signalpkg.Signal](),
+			KlineStore:      ks,
+			PatternDetector: pattern.NewDetector(cfg),
+			PatternHistory:  patternHistory,
+			PatternBroker:   sse.NewBroker[pattern.Signal](),
+			DetectionWindow: 12,
+		})
+
+		m.onKlineClose("15m", "BTCUSDT", klines)
+		if got := patternHistory.Count() > 0; got != tc.want {
+			t.Errorf("MinKlines=%d: detected=%v, want %v", tc.minKlines, got, tc.want)
+		}
+	}
+	if n := ks.KlineCount("BTCUSDT"); n != 200 {
+		t.Errorf("store retained %d klines after detection, want 200", n)
+	}
+}
+
+func TestHasPatternLiquidity(t *testing.T) {
+	tickerStore := ticker.NewStore()
+	tickerStore.Update("BTCUSDT", 100, 1.5, 1000, 2_000_000)
+	tickerStore.Update("LOWUSDT", 1, 0.5, 10, 500)
+
+	tests := []struct {
+		name      string
+		store     *ticker.Store
+		minVolume float64
+		symbol    string
+		want      bool
+	}{
+		{"disabled", tickerStore, 0, "LOWUSDT", true},
+		{"no ticker store", nil, 1_000_000, "LOWUSDT", true},
+		{"no ticker data", tickerStore, 1_000_000, "ETHUSDT", true},
+		{"above threshold", tickerStore, 1_000_000, "BTCUSDT", true},
+		{"below threshold", tickerStore, 1_000_000, "LOWUSDT", false},
+	}
+
+	for _, tt := range tests {
+		m := NewWithConfig(MonitorConfig{
+			PivotStore:       pivot.NewStore(),
+			Broker:           sse.NewBroker[signalpkg.Signal](),
+			TickerStore:      tt.store,
+			MinPatternVolume: tt.minVolume,
+		})
+		if got := m.hasPatternLiquidity(tt.symbol); got != tt.want {
+			t.Errorf("%s: hasPatternLiquidity(%s) = %v, want %v", tt.name, tt.symbol, got, tt.want)
+		}
+	}
+}
+
 // TestOnKlineClose_Property11_DetectionRangeLimit tests that pattern detection
 // is limited to symbols with pivot data using property-based testing.
 func TestOnKlineClose_Property11_DetectionRangeLimit(t *testing.T) {
@@ -128,7 +306,7 @@ func TestOnKlineClose_Property11_DetectionRangeLimit(t *testing.T) {
 
 			// Test symbol WITHOUT pivot data
 			initialCount := patternHistory.Count()
-			m.onKlineClose(symbolWithoutPivot, klines)
+			m.onKlineClose("15m", symbolWithoutPivot, klines)
 			afterWithoutPivot := patternHistory.Count()
 
 			// For symbol without pivot, no patterns should be recorded
@@ -208,6 +386,45 @@ func TestNewWithConfig_SetsOnCloseCallback(t *testing.T) {
 	}
 }
 
+// TestNewWithConfig_MultiTimeframe tests that every kline store is fed prices
+// and queues its closes tagged with its own timeframe.
+func TestNewWithConfig_MultiTimeframe(t *testing.T) {
+	store15m := kline.NewStore(15*time.Minute, 12)
+	store5m := kline.NewStore(5*time.Minute, 12)
+
+	m := NewWithConfig(MonitorConfig{
+		PivotStore:       pivot.NewStore(),
+		Broker:           sse.NewBroker[signalpkg.Signal](),
+		KlineStore:       store15m,
+		ExtraKlineStores: []*kline.Store{store5m},
+		PatternDetector:  pattern.NewDetector(pattern.DefaultDetectorConfig()),
+	})
+
+	ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	m.onPrice("BTCUSDT", 49000, ts)
+	m.onPrice("BTCUSDT", 49100, ts.Add(15*time.Minute))
+
+	if store5m.KlineCount("BTCUSDT") != 1 || store15m.KlineCount("BTCUSDT") != 1 {
+		t.Fatalf("expected one closed kline per store, got 5m=%d 15m=%d",
+			store5m.KlineCount("BTCUSDT"), store15m.KlineCount("BTCUSDT"))
+	}
+
+	// onClose queues each close for the pattern workers before returning
+	got := map[string]bool{}
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case ev := <-m.patternQueue:
+			got[ev.timeframe] = true
+		case <-timeout:
+			t.Fatalf("timed out waiting for queued closes, got %v", got)
+		}
+	}
+	if !got["5m"] || !got["15m"] || len(got) != 2 {
+		t.Errorf("expected queued closes for 5m and 15m, got %v", got)
+	}
+}
+
 
 // =============================================================================
 // Task 1.2: Property Test - Level Crossing Detection
@@ -501,6 +718,336 @@ func TestProperty_CooldownIsolation(t *testing.T) {
 	properties.TestingRun(t)
 }
 
+// TestCooldownScope tests that broader cooldown scopes suppress signals on
+// other levels of the same symbol.
+func TestCooldownScope(t *testing.T) {
+	levels := pivot.Levels{R3: 103, R4: 104, R5: 105}
+
+	run := func(scope CooldownScope) map[string]int {
+		pivotStore := pivot.NewStore()
+		setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", levels)
+		setPivotLevels(pivotStore, pivot.PeriodDaily, "OTHERUSDT", levels)
+		setPivotLevels(pivotStore, pivot.PeriodWeekly, "TESTUSDT", pivot.Levels{R3: 104.5})
+
+		history := signalpkg.NewHistory(100)
+		m := NewWithConfig(MonitorConfig{
+			PivotStore:    pivotStore,
+			Broker:        sse.NewBroker[signalpkg.Signal](),
+			History:       history,
+			Cooldown:      signalpkg.NewCooldown(5 * time.Minute),
+			CooldownScope: scope,
+		})
+
+		ts := time.Now()
+		m.lastPrice["TESTUSDT"] = 102.9
+		m.onPrice("TESTUSDT", 103.1, ts)                     // daily R3
+		m.onPrice("TESTUSDT", 104.6, ts.Add(30*time.Second)) // daily R4 + weekly R3
+		m.lastPrice["OTHERUSDT"] = 102.9
+		m.onPrice("OTHERUSDT", 103.1, ts.Add(time.Minute)) // other symbol is independent
+
+		counts := make(map[string]int)
+		for _, sig := range history.Query("", "", "", "", "", 100) {
+			counts[sig.Symbol+"|"+sig.Period+"|"+sig.Level]++
+		}
+		return counts
+	}
+
+	level := run(CooldownScopeLevel)
+	if level["TESTUSDT|1d|R3"] != 1 || level["TESTUSDT|1d|R4"] != 1 || level["TESTUSDT|1w|R3"] != 1 {
+		t.Errorf("level scope: expected R3, R4 and weekly R3, got %v", level)
+	}
+
+	symbolPeriod := run(CooldownScopeSymbolPeriod)
+	if symbolPeriod["TESTUSDT|1d|R3"] != 1 || symbolPeriod["TESTUSDT|1d|R4"] != 0 || symbolPeriod["TESTUSDT|1w|R3"] != 1 {
+		t.Errorf("symbol-period scope: expected daily R3 and weekly R3 only, got %v", symbolPeriod)
+	}
+
+	symbol := run(CooldownScopeSymbol)
+	if symbol["TESTUSDT|1d|R3"] != 1 || symbol["TESTUSDT|1d|R4"] != 0 || symbol["TESTUSDT|1w|R3"] != 0 {
+		t.Errorf("symbol scope: expected daily R3 only, got %v", symbol)
+	}
+	if symbol["OTHERUSDT|1d|R3"] != 1 {
+		t.Errorf("symbol scope: expected other symbol unaffected, got %v", symbol)
+	}
+}
+
+func TestCheckPeriod_SkipsCollapsedLevels(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	levels := pivot.MergeNearLevels(pivot.Levels{PP: 100, R3: 103, R4: 103.02, R5: 110}, 0.001)
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", levels)
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		Broker:     sse.NewBroker[signalpkg.Signal](),
+		History:    history,
+		Cooldown:   signalpkg.NewCooldown(5 * time.Minute),
+	})
+
+	m.lastPrice["TESTUSDT"] = 102.9
+	m.onPrice("TESTUSDT", 103.1, time.Now()) // crosses R3 and R4
+
+	got := history.Query("", "", "", "", "", 100)
+	if len(got) != 1 || got[0].Level != "R3" {
+		t.Errorf("expected only R3 signal, got %+v", got)
+	}
+}
+
+func TestCheckPeriod_TrendAwareLevels(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", pivot.Levels{PP: 100, R3: 105, S3: 95})
+
+	ks := kline.NewStore(15*time.Minute, 10)
+	open := time.Now().Truncate(15 * time.Minute).Add(-3 * 15 * time.Minute)
+	ks.Seed("TESTUSDT", []kline.Kline{
+		{OpenTime: open, Open: 96, High: 98, Low: 95.5, Close: 97},
+		{OpenTime: open.Add(15 * time.Minute), Open: 97, High: 99, Low: 96.5, Close: 98},
+		{OpenTime: open.Add(30 * time.Minute), Open: 98, High: 100, Low: 97.5, Close: 99},
+	})
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		Broker:     sse.NewBroker[signalpkg.Signal](),
+		History:    history,
+		Cooldown:   signalpkg.NewCooldown(5 * time.Minute),
+		KlineStore: ks,
+	})
+	m.TrendAwareLevels = true
+
+	now := time.Now()
+	m.lastPrice["TESTUSDT"] = 95.1
+	m.onPrice("TESTUSDT", 94.9, now) // crosses S3 against the uptrend
+	if got := history.Query("", "", "", "", "", 100); len(got) != 0 {
+		t.Fatalf("expected support crossing suppressed in uptrend, got %+v", got)
+	}
+
+	m.lastPrice["TESTUSDT"] = 104.9
+	m.onPrice("TESTUSDT", 105.1, now) // crosses R3 with the uptrend
+	got := history.Query("", "", "", "", "", 100)
+	if len(got) != 1 || got[0].Level != "R3" || got[0].Trend != "up" {
+		t.Fatalf("expected R3 signal with trend up, got %+v", got)
+	}
+
+	// Disabled: support levels are watched again and no trend is attached
+	m.TrendAwareLevels = false
+	m.lastPrice["TESTUSDT"] = 95.1
+	m.onPrice("TESTUSDT", 94.9, now)
+	got = history.Query("", "", "", "", "", 100)
+	if len(got) != 2 || got[0].Level != "S3" || got[0].Trend != "" {
+		t.Errorf("expected S3 signal without trend, got %+v", got)
+	}
+}
+
+func TestCheckLevel_RearmBand(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", pivot.Levels{R3: 100})
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		History:    history,
+	})
+	m.RearmBand = 0.01 // 1% band: re-arms below 99 or above 101
+
+	count := func() int { return len(history.Query("", "", "", "", "", 100)) }
+	now := time.Now()
+	feed := func(prices ...float64) {
+		for _, p := range prices {
+			m.onPrice("TESTUSDT", p, now)
+		}
+	}
+
+	feed(99.5, 100.2)
+	if got := count(); got != 1 {
+		t.Fatalf("first crossing: got %d signals, want 1", got)
+	}
+
+	// Oscillation within the band never re-triggers
+	feed(99.8, 100.1, 99.2, 100.5, 99.9, 100.9)
+	if got := count(); got != 1 {
+		t.Fatalf("oscillation within band: got %d signals, want 1", got)
+	}
+
+	// A clean retreat beyond the band re-arms, and the next crossing fires
+	feed(98.5, 100.3)
+	if got := count(); got != 2 {
+		t.Fatalf("retreat and re-cross: got %d signals, want 2", got)
+	}
+}
+
+func TestCheckLevel_TouchTolerance(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", pivot.Levels{R3: 100})
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		History:    history,
+	})
+	m.TouchTolerance = 0.002 // touches within 99.8..100.2
+
+	now := time.Now()
+	feed := func(prices ...float64) []signalpkg.Signal {
+		before := len(history.Query("", "", "", "", "", 100))
+		for _, p := range prices {
+			m.onPrice("TESTUSDT", p, now)
+		}
+		got := history.Query("", "", "", "", "", 100)
+		return got[:len(got)-before] // newest first
+	}
+
+	// A near-miss from below emits one touch, even while price lingers in the band
+	got := feed(99.0, 99.9, 99.85, 99.5)
+	if len(got) != 1 || got[0].Kind != signalpkg.KindTouch || got[0].Direction != "up" {
+		t.Fatalf("near-miss: got %+v, want one up touch", got)
+	}
+
+	// A clean cross from outside the band is a cross only
+	got = feed(100.5)
+	if len(got) != 1 || got[0].Kind != signalpkg.KindCross || got[0].Direction != "up" {
+		t.Fatalf("clean cross: got %+v, want one up cross", got)
+	}
+
+	// Approaching from above touches downward
+	got = feed(101, 100.1)
+	if len(got) != 1 || got[0].Kind != signalpkg.KindTouch || got[0].Direction != "down" {
+		t.Fatalf("touch from above: got %+v, want one down touch", got)
+	}
+
+	// Disabled: near-misses are silent
+	m.TouchTolerance = 0
+	if got = feed(101, 100.1); len(got) != 0 {
+		t.Errorf("touches with zero tolerance: %+v", got)
+	}
+}
+
+func TestCheckLevel_Walk(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", pivot.Levels{R2: 95, R3: 100, R4: 105, R5: 110})
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		History:    history,
+	})
+	m.WalkWindow = 10 * time.Minute
+
+	walks := func() []signalpkg.Signal {
+		var out []signalpkg.Signal
+		for _, s := range history.Query("", "", "", "", "", 100) {
+			if s.Kind == signalpkg.KindWalk {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	now := time.Now()
+	m.onPrice("TESTUSDT", 99, now)
+	m.onPrice("TESTUSDT", 100.5, now.Add(time.Minute))   // R3
+	m.onPrice("TESTUSDT", 105.5, now.Add(3*time.Minute)) // R4
+	if got := walks(); len(got) != 0 {
+		t.Fatalf("two levels: got %+v, want no walk", got)
+	}
+	m.onPrice("TESTUSDT", 110.5, now.Add(5*time.Minute)) // R5
+	got := walks()
+	if len(got) != 1 || got[0].Level != "R5" || got[0].WalkFrom != "R3" || got[0].Direction != "up" {
+		t.Fatalf("R3, R4, R5: got %+v, want an up walk from R3 to R5", got)
+	}
+	if crosses := history.Query("", "", "R5", "", "", 100); len(crosses) != 2 {
+		t.Errorf("R5 signals = %d, want the cross and the walk", len(crosses))
+	}
+
+	// Back down R5, R4 then a pause longer than the window before R3
+	m.onPrice("TESTUSDT", 109.5, now.Add(6*time.Minute))
+	m.onPrice("TESTUSDT", 104.5, now.Add(7*time.Minute))
+	m.onPrice("TESTUSDT", 99.5, now.Add(30*time.Minute))
+	if got := walks(); len(got) != 1 {
+		t.Errorf("slow descent: got %d walks, want still 1", len(got))
+	}
+}
+
+func TestEmit_Confluence(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", pivot.Levels{R3: 100, R4: 110})
+	setPivotLevels(pivotStore, pivot.PeriodWeekly, "TESTUSDT", pivot.Levels{R1: 100.3, R2: 120})
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		History:    history,
+	})
+	m.ConfluencePct = 0.5
+
+	now := time.Now()
+	m.lastPrice["TESTUSDT"] = 99
+	m.onPrice("TESTUSDT", 100.1, now) // crosses daily R3, stacked on weekly R1
+
+	got := history.Query("", "1d", "R3", "", "", 100)
+	if len(got) != 1 || got[0].Confluence != "R1" || got[0].ConfluencePeriod != "1w" {
+		t.Fatalf("daily R3: got %+v, want confluence 1w/R1", got)
+	}
+	got = history.Query("", "1w", "R1", "", "", 100)
+	if len(got) != 0 {
+		t.Fatalf("weekly R1 not crossed yet: %+v", got)
+	}
+
+	// The weekly crossing sees the daily level; a lone level gets nothing
+	m.onPrice("TESTUSDT", 111, now) // crosses weekly R1 and daily R4
+	got = history.Query("", "1w", "R1", "", "", 100)
+	if len(got) != 1 || got[0].Confluence != "R3" || got[0].ConfluencePeriod != "1d" {
+		t.Errorf("weekly R1: got %+v, want confluence 1d/R3", got)
+	}
+	got = history.Query("", "1d", "R4", "", "", 100)
+	if len(got) != 1 || got[0].Confluence != "" {
+		t.Errorf("daily R4: got %+v, want no confluence", got)
+	}
+}
+
+func TestCheckPeriod_WatchMidPivots(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "TESTUSDT", pivot.Levels{PP: 100, R1: 102, S1: 98})
+
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		History:    history,
+	})
+
+	now := time.Now()
+	m.lastPrice["TESTUSDT"] = 100.5
+	m.onPrice("TESTUSDT", 101.5, now) // crosses M3 = 101 only
+	if got := history.Query("", "", "", "", "", 100); len(got) != 0 {
+		t.Fatalf("mid-pivots watched by default: %+v", got)
+	}
+
+	m.WatchMidPivots = true
+	m.lastPrice["TESTUSDT"] = 100.5
+	m.onPrice("TESTUSDT", 101.5, now)
+	got := history.Query("", "", "", "", "", 100)
+	if len(got) != 1 || got[0].Level != "M3" {
+		t.Errorf("expected one M3 signal, got %+v", got)
+	}
+}
+
+func TestParseCooldownScope(t *testing.T) {
+	for in, want := range map[string]CooldownScope{
+		"":              CooldownScopeLevel,
+		"level":         CooldownScopeLevel,
+		"Symbol":        CooldownScopeSymbol,
+		"symbol-period": CooldownScopeSymbolPeriod,
+	} {
+		got, err := ParseCooldownScope(in)
+		if err != nil || got != want {
+			t.Errorf("ParseCooldownScope(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseCooldownScope("global"); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
+
 // =============================================================================
 // Task 1.4: Property Test - First Price Baseline
 // Validates: Requirements 1.8
@@ -590,3 +1137,265 @@ func TestProperty_FirstPriceBaseline(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+// TestOnKlineClose_PivotProximityBoost checks that a pattern forming at S3
+// is tagged with the level and gets a confidence boost.
+func TestOnKlineClose_PivotProximityBoost(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "BTCUSDT", pivot.Levels{
+		R3: 50000, R4: 51000, R5: 52000,
+		S3: 48000, S4: 47000, S5: 46000,
+	})
+
+	detect := func(proximityPct float64) []pattern.Signal {
+		patternHistory, err := pattern.NewHistory("", 100)
+		if err != nil {
+			t.Fatalf("failed to create pattern history: %v", err)
+		}
+		m := NewWithConfig(MonitorConfig{
+			PivotStore:      pivotStore,
+			Broker:          sse.NewBroker[signalpkg.Signal](),
+			PatternDetector: pattern.NewDetector(pattern.DefaultDetectorConfig()),
+			PatternHistory:  patternHistory,
+			PatternBroker:   sse.NewBroker[pattern.Signal](),
+		})
+		m.PivotProximityPct = proximityPct
+
+		// Bullish engulfing whose low wicks to just above S3
+		m.onKlineClose("15m", "BTCUSDT", []kline.Kline{
+			{Symbol: "BTCUSDT", Open: 48500, High: 48600, Low: 48100, Close: 48150, IsClosed: true},
+			{Symbol: "BTCUSDT", Open: 48100, High: 48700, Low: 48010, Close: 48650, IsClosed: true},
+		})
+		return patternHistory.Recent(0)
+	}
+
+	plain := detect(0)
+	if len(plain) == 0 {
+		t.Fatal("expected a pattern to be detected")
+	}
+	for _, sig := range plain {
+		if sig.AtPivot != "" {
+			t.Errorf("%s: AtPivot = %q with proximity disabled", sig.Pattern, sig.AtPivot)
+		}
+	}
+
+	boosted := detect(0.1)
+	if len(boosted) != len(plain) {
+		t.Fatalf("got %d boosted signals, want %d", len(boosted), len(plain))
+	}
+	base := make(map[pattern.PatternType]int, len(plain))
+	for _, sig := range plain {
+		base[sig.Pattern] = sig.Confidence
+	}
+	for _, sig := range boosted {
+		if sig.AtPivot != "S3" || sig.AtPivotPeriod != string(pivot.PeriodDaily) {
+			t.Errorf("%s: AtPivot = %s/%s, want 1d/S3", sig.Pattern, sig.AtPivotPeriod, sig.AtPivot)
+		}
+		want := base[sig.Pattern] + DefaultPivotConfidenceBoost
+		if want > 100 {
+			want = 100
+		}
+		if sig.Confidence != want {
+			t.Errorf("%s: Confidence = %d, want %d", sig.Pattern, sig.Confidence, want)
+		}
+	}
+}
+
+func TestDecodeMarkPriceEvents_DecompressLimit(t *testing.T) {
+	// Pad a valid array with whitespace so it expands well past a small cap
+	payload := `[{"e":"markPriceUpdate","E":1700000000000,"s":"BTCUSDT","p":"50000"}]` + strings.Repeat(" ", 4096)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(payload))
+	_ = zw.Close()
+
+	events, ok, tooLarge := decodeMarkPriceEvents(buf.Bytes(), 1024)
+	if ok || !tooLarge || events != nil {
+		t.Errorf("with 1KB cap: ok=%v tooLarge=%v events=%v, want rejected as too large", ok, tooLarge, events)
+	}
+
+	events, ok, tooLarge = decodeMarkPriceEvents(buf.Bytes(), 0)
+	if !ok || tooLarge || len(events) != 1 || events[0].Symbol != "BTCUSDT" {
+		t.Errorf("with default cap: ok=%v tooLarge=%v events=%v, want one BTCUSDT event", ok, tooLarge, events)
+	}
+
+	// A frame of exactly the cap is not truncated
+	if _, ok, tooLarge := decodeMarkPriceEvents(buf.Bytes(), int64(len(payload))); !ok || tooLarge {
+		t.Errorf("with cap == size: ok=%v tooLarge=%v, want accepted", ok, tooLarge)
+	}
+}
+
+func TestParseMarkPriceEventsJSON_CombinedSingle(t *testing.T) {
+	// dapi per-symbol combined streams wrap one event per message
+	msg := `{"stream":"btcusd_perp@markPrice@1s","data":{"e":"markPriceUpdate","E":1700000000000,"s":"BTCUSD_PERP","p":"50000.1"}}`
+	events, ok := parseMarkPriceEventsJSON([]byte(msg))
+	if !ok || len(events) != 1 || events[0].Symbol != "BTCUSD_PERP" || events[0].MarkPrice != "50000.1" {
+		t.Errorf("parseMarkPriceEventsJSON = %v, %v; want one BTCUSD_PERP event", events, ok)
+	}
+}
+
+func TestDecodeMarkPriceEvents_WatchSymbols(t *testing.T) {
+	// fstream per-symbol combined streams (WatchSymbols) also wrap one event
+	msg := `{"stream":"ethusdt@markPrice@1s","data":{"e":"markPriceUpdate","E":1700000000000,"s":"ETHUSDT","p":"2000.5","i":"2000.1"}}`
+	events, ok, _ := decodeMarkPriceEvents([]byte(msg), DefaultMaxDecompressedBytes)
+	if !ok || len(events) != 1 || events[0].Symbol != "ETHUSDT" || events[0].MarkPrice != "2000.5" || events[0].EventTime != 1700000000000 {
+		t.Errorf("decodeMarkPriceEvents = %v, %v; want one ETHUSDT event", events, ok)
+	}
+}
+
+func TestEmit_TagsContract(t *testing.T) {
+	history := signalpkg.NewHistory(100)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivot.NewStore(),
+		History:    history,
+	})
+
+	now := time.Now()
+	m.emit("BTCUSDT", pivot.PeriodDaily, "R3", 100, "up", signalpkg.KindCross, now, "")
+	m.emit("BTCUSD_PERP", pivot.PeriodDaily, "R3", 100, "up", signalpkg.KindCross, now, "")
+
+	got := history.Query("", "", "", "", "", 100)
+	contracts := map[string]string{}
+	for _, sig := range got {
+		contracts[sig.Symbol] = sig.Contract
+	}
+	if contracts["BTCUSDT"] != binance.ContractUSDT || contracts["BTCUSD_PERP"] != binance.ContractCoin {
+		t.Errorf("contracts = %v, want usdt and coin", contracts)
+	}
+}
+
+func TestSignalActivity_DailyReset(t *testing.T) {
+	m := NewWithConfig(MonitorConfig{PivotStore: pivot.NewStore()})
+
+	// 2024-01-02 07:00 Asia/Shanghai is still the Binance day of Jan 1
+	day1 := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	m.emit("BTCUSDT", pivot.PeriodDaily, "R3", 100, "up", signalpkg.KindCross, day1, "")
+	m.emit("BTCUSDT", pivot.PeriodDaily, "R3", 90, "down", signalpkg.KindCross, day1, "") // same level again
+	m.emit("BTCUSDT", pivot.PeriodWeekly, "R3", 100, "up", signalpkg.KindCross, day1, "")
+	m.emit("BTCUSDT", pivot.PeriodDaily, "R4", 110, "up", signalpkg.KindTouch, day1, "") // touches do not count
+	m.emit("ETHUSDT", pivot.PeriodDaily, "S3", 10, "down", signalpkg.KindCross, day1, "")
+
+	since, counts := m.SignalActivity(day1.Add(30 * time.Minute))
+	if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !since.Equal(want) {
+		t.Errorf("since = %v, want %v", since, want)
+	}
+	if counts["BTCUSDT"] != 2 || counts["ETHUSDT"] != 1 {
+		t.Errorf("counts = %v, want BTCUSDT=2 ETHUSDT=1", counts)
+	}
+
+	// Past 00:00 UTC (08:00 Asia/Shanghai) the counts start over
+	day2 := time.Date(2024, 1, 2, 0, 5, 0, 0, time.UTC)
+	if _, counts := m.SignalActivity(day2); len(counts) != 0 {
+		t.Errorf("counts after day boundary = %v, want none", counts)
+	}
+	m.emit("ETHUSDT", pivot.PeriodDaily, "S4", 9, "down", signalpkg.KindCross, day2, "")
+	m.emit("BTCUSDT", pivot.PeriodDaily, "R5", 120, "up", signalpkg.KindCross, day1, "") // late, previous day
+	if _, counts := m.SignalActivity(day2); len(counts) != 1 || counts["ETHUSDT"] != 1 {
+		t.Errorf("counts on day 2 = %v, want ETHUSDT=1", counts)
+	}
+
+	// A configured offset moves the boundary
+	m = NewWithConfig(MonitorConfig{PivotStore: pivot.NewStore()})
+	m.ActivityDayOffset = 8 * time.Hour
+	if since, _ := m.SignalActivity(day2); !since.Equal(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("since with 8h offset = %v, want 2024-01-01 08:00 UTC", since)
+	}
+}
+
+func TestEmitPatternSignal_SSEMinConfidence(t *testing.T) {
+	patternHistory, _ := pattern.NewHistory("", 100)
+	patternBroker := sse.NewBroker[pattern.Signal]()
+	sub := patternBroker.Subscribe(4)
+	defer patternBroker.Unsubscribe(sub)
+
+	m := NewWithConfig(MonitorConfig{
+		PivotStore:     pivot.NewStore(),
+		PatternHistory: patternHistory,
+		PatternBroker:  patternBroker,
+	})
+	m.PatternSSEMinConfidence = 80
+
+	now := time.Now()
+	m.emitPatternSignal(pattern.NewSignal("BTCUSDT", pattern.PatternHammer, pattern.DirectionBullish, 65, now))
+	m.emitPatternSignal(pattern.NewSignal("ETHUSDT", pattern.PatternEngulfing, pattern.DirectionBullish, 85, now))
+
+	if patternHistory.Count() != 2 {
+		t.Errorf("history count = %d, want 2 (low-confidence patterns are still recorded)", patternHistory.Count())
+	}
+
+	select {
+	case sig := <-sub:
+		if sig.Symbol != "ETHUSDT" {
+			t.Errorf("published %s, want only ETHUSDT", sig.Symbol)
+		}
+	default:
+		t.Fatal("expected the 85-confidence pattern to be published")
+	}
+	select {
+	case sig := <-sub:
+		t.Errorf("unexpected publish of %s (confidence %d)", sig.Symbol, sig.Confidence)
+	default:
+	}
+}
+
+func TestPublish_ActiveHours(t *testing.T) {
+	active, err := ParseActiveHours("09:00-17:00", "Asia/Shanghai")
+	if err != nil {
+		t.Fatalf("ParseActiveHours: %v", err)
+	}
+	history := signalpkg.NewHistory(100)
+	broker := sse.NewBroker[signalpkg.Signal]()
+	sub := broker.Subscribe(4)
+	defer broker.Unsubscribe(sub)
+
+	m := NewWithConfig(MonitorConfig{PivotStore: pivot.NewStore(), Broker: broker, History: history})
+	m.ActiveHours = active
+
+	// 02:00 UTC is 10:00 in Shanghai, 12:00 UTC is 20:00
+	inside := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	outside := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.emit("BTCUSDT", pivot.PeriodDaily, "R3", 100, "up", signalpkg.KindCross, inside, "")
+	m.emit("ETHUSDT", pivot.PeriodDaily, "R3", 10, "up", signalpkg.KindCross, outside, "")
+
+	if history.Count() != 2 {
+		t.Errorf("history count = %d, want 2 (signals outside the window are still recorded)", history.Count())
+	}
+	select {
+	case sig := <-sub:
+		if sig.Symbol != "BTCUSDT" {
+			t.Errorf("published %s, want only BTCUSDT", sig.Symbol)
+		}
+	default:
+		t.Fatal("expected the signal inside the window to be published")
+	}
+	select {
+	case sig := <-sub:
+		t.Errorf("unexpected publish of %s outside active hours", sig.Symbol)
+	default:
+	}
+}
+
+func TestActiveHours_Contains(t *testing.T) {
+	overnight, err := ParseActiveHours("22:00-06:00", "")
+	if err != nil {
+		t.Fatalf("ParseActiveHours: %v", err)
+	}
+	for hour, want := range map[int]bool{21: false, 22: true, 23: true, 0: true, 5: true, 6: false, 12: false} {
+		ts := time.Date(2024, 1, 1, hour, 30, 0, 0, time.UTC)
+		if got := overnight.Contains(ts); got != want {
+			t.Errorf("Contains(%02d:30) = %v, want %v", hour, got, want)
+		}
+	}
+
+	if a, err := ParseActiveHours("", "UTC"); a != nil || err != nil {
+		t.Errorf("empty spec = %v, %v; want nil, nil", a, err)
+	}
+	for _, spec := range []string{"9-17", "09:00-09:00", "25:00-06:00", "09:00-17:60"} {
+		if _, err := ParseActiveHours(spec, ""); err == nil {
+			t.Errorf("ParseActiveHours(%q) succeeded", spec)
+		}
+	}
+	if _, err := ParseActiveHours("09:00-17:00", "Mars/Base"); err == nil {
+		t.Error("unknown timezone accepted")
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"example.com/binance-pivot-monitor/internal/kline"
+)
+
+// DefaultPatternWorkers is the default number of pattern detection workers.
+const DefaultPatternWorkers = 8
+
+// patternQueueSize bounds the number of pending kline close events.
+// Large enough to absorb a market-wide close (~500 symbols) at once.
+const patternQueueSize = 1024
+
+// klineCloseEvent is a queued kline close waiting for pattern detection.
+type klineCloseEvent struct {
+	timeframe string
+	symbol    string
+	klines    []kline.Kline
+}
+
+// enqueueKlineClose is called from each kline store's onClose callback.
+// It queues the event for the worker pool instead of running detection inline,
+// and drops the event if the queue is full to protect CPU.
+func (m *Monitor) enqueueKlineClose(timeframe, symbol string, klines []kline.Kline) {
+	select {
+	case m.patternQueue <- klineCloseEvent{timeframe: timeframe, symbol: symbol, klines: klines}:
+	default:
+		n := atomic.AddUint64(&m.patternDropped, 1)
+		if n == 1 || n%100 == 0 {
+			log.Printf("WARN: pattern queue full, dropped kline close symbol=%s total_dropped=%d", symbol, n)
+		}
+	}
+}
+
+// startPatternWorkers starts the pattern detection workers once.
+// Workers exit when ctx is done.
+func (m *Monitor) startPatternWorkers(ctx context.Context) {
+	if m.patternQueue == nil {
+		return
+	}
+	m.workersOnce.Do(func() {
+		workers := m.PatternWorkers
+		if workers <= 0 {
+			workers = DefaultPatternWorkers
+		}
+		log.Printf("pattern: starting %d detection workers (queue=%d)", workers, cap(m.patternQueue))
+		m.workersWG.Add(workers)
+		for i := 0; i < workers; i++ {
+			go m.patternWorker(ctx)
+		}
+	})
+}
+
+// patternWorker processes queued kline close events until ctx is done.
+func (m *Monitor) patternWorker(ctx context.Context) {
+	defer m.workersWG.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-m.patternQueue:
+			m.onKlineClose(ev.timeframe, ev.symbol, ev.klines)
+		}
+	}
+}
+
+// PatternDropped returns the number of kline close events dropped because
+// the pattern queue was full.
+func (m *Monitor) PatternDropped() uint64 {
+	return atomic.LoadUint64(&m.patternDropped)
+}
+
+// SetPatternsEnabled turns pattern detection on or off at runtime. While
+// off, klines keep updating but closes run no detection. Enabled by default.
+func (m *Monitor) SetPatternsEnabled(enabled bool) {
+	var off uint32
+	if !enabled {
+		off = 1
+	}
+	atomic.StoreUint32(&m.patternsOff, off)
+}
+
+// PatternsEnabled reports whether pattern detection is switched on.
+func (m *Monitor) PatternsEnabled() bool {
+	return atomic.LoadUint32(&m.patternsOff) == 0
+}
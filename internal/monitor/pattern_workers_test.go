@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/kline"
+	"example.com/binance-pivot-monitor/internal/pattern"
+	"example.com/binance-pivot-monitor/internal/pivot"
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+	"example.com/binance-pivot-monitor/internal/sse"
+)
+
+func newPatternPoolMonitor() *Monitor {
+	return NewWithConfig(MonitorConfig{
+		PivotStore:      pivot.NewStore(),
+		Broker:          sse.NewBroker[signalpkg.Signal](),
+		KlineStore:      kline.NewStore(5*time.Minute, 12),
+		PatternDetector: pattern.NewDetector(pattern.DefaultDetectorConfig()),
+		PatternWorkers:  2,
+	})
+}
+
+func TestMonitor_EnqueueKlineClose_DropsWhenFull(t *testing.T) {
+	m := newPatternPoolMonitor()
+
+	// Workers not started: the queue fills up and the rest is dropped
+	for i := 0; i < patternQueueSize+5; i++ {
+		m.enqueueKlineClose("15m", "BTCUSDT", nil)
+	}
+
+	if got := len(m.patternQueue); got != patternQueueSize {
+		t.Errorf("queue length = %d, want %d", got, patternQueueSize)
+	}
+	if got := m.PatternDropped(); got != 5 {
+		t.Errorf("PatternDropped() = %d, want 5", got)
+	}
+}
+
+func TestMonitor_PatternWorkers_DrainQueue(t *testing.T) {
+	m := newPatternPoolMonitor()
+
+	for i := 0; i < 50; i++ {
+		m.enqueueKlineClose("15m", "BTCUSDT", nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.startPatternWorkers(ctx)
+	m.startPatternWorkers(ctx) // Idempotent
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(m.patternQueue) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue not drained, %d events left", len(m.patternQueue))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := m.PatternDropped(); got != 0 {
+		t.Errorf("PatternDropped() = %d, want 0", got)
+	}
+}
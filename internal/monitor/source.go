@@ -0,0 +1,314 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/backoff"
+	"example.com/binance-pivot-monitor/internal/binance"
+	"github.com/gorilla/websocket"
+)
+
+// PriceSource streams batches of mark price events.
+// The returned channel must be closed when ctx is done; a source may also
+// close it earlier, in which case Monitor.Run calls Stream again after a backoff.
+type PriceSource interface {
+	Stream(ctx context.Context) (<-chan []binance.MarkPriceEvent, error)
+}
+
+// BinanceSource streams the Binance futures !markPrice@arr@1s websocket.
+// It reconnects with backoff and only closes its channel when ctx is done.
+type BinanceSource struct {
+	// Dial opens the mark price websocket; nil means binance.DialMarkPriceArr1s.
+	// Set it to stream another feed such as binance.DialCoinMarkPrice1s.
+	Dial func(ctx context.Context) (*websocket.Conn, *http.Response, error)
+	// Name labels log lines; empty means "monitor ws".
+	Name string
+
+	HeartbeatEvery time.Duration
+	SymbolsSeen    func() int64   // Optional, reported in heartbeat logs
+	SkewRejected   func() int64   // Optional, reported in heartbeat logs
+	Backoff        backoff.Policy // Reconnect delays; zero uses backoff defaults
+
+	// MaxDecompressedBytes caps compressed frame expansion; zero uses
+	// DefaultMaxDecompressedBytes. Frames over the cap are dropped.
+	MaxDecompressedBytes int64
+}
+
+// Stream implements PriceSource.
+func (s *BinanceSource) Stream(ctx context.Context) (<-chan []binance.MarkPriceEvent, error) {
+	out := make(chan []binance.MarkPriceEvent, 16)
+	go s.run(ctx, out)
+	return out, nil
+}
+
+func (s *BinanceSource) dial(ctx context.Context) (*websocket.Conn, *http.Response, error) {
+	if s.Dial == nil {
+		return binance.DialMarkPriceArr1s(ctx)
+	}
+	return s.Dial(ctx)
+}
+
+func (s *BinanceSource) name() string {
+	if s.Name == "" {
+		return "monitor ws"
+	}
+	return s.Name
+}
+
+func (s *BinanceSource) symbolsSeen() int64 {
+	if s.SymbolsSeen == nil {
+		return 0
+	}
+	return s.SymbolsSeen()
+}
+
+func (s *BinanceSource) skewRejected() int64 {
+	if s.SkewRejected == nil {
+		return 0
+	}
+	return s.SkewRejected()
+}
+
+// run dials, reads and reconnects until ctx is done, then closes out.
+func (s *BinanceSource) run(ctx context.Context, out chan<- []binance.MarkPriceEvent) {
+	defer close(out)
+
+	bo := s.Backoff.New()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		conn, _, err := s.dial(ctx)
+		if err != nil {
+			log.Printf("%s dial failed: %v", s.name(), err)
+			if !bo.Wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		log.Printf("%s connected", s.name())
+		bo.Reset()
+
+		err = s.readLoop(ctx, conn, out)
+		_ = conn.Close()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("%s read loop exit: %v", s.name(), err)
+		}
+
+		if !bo.Wait(ctx) {
+			return
+		}
+	}
+}
+
+func (s *BinanceSource) readLoop(ctx context.Context, conn *websocket.Conn, out chan<- []binance.MarkPriceEvent) error {
+	_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetPongHandler(func(string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	hbEvery := s.HeartbeatEvery
+	var hbMsgs int64
+	var hbEvents int64
+	var hbUnmarshalErr int64
+	var hbTooLarge int64
+	var hbLastMsgUnixNano int64
+	atomic.StoreInt64(&hbLastMsgUnixNano, time.Now().UnixNano())
+
+	hbDone := make(chan struct{})
+	if hbEvery > 0 {
+		go func() {
+			t := time.NewTicker(hbEvery)
+			defer t.Stop()
+			for {
+				select {
+				case <-hbDone:
+					return
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					msgs := atomic.SwapInt64(&hbMsgs, 0)
+					events := atomic.SwapInt64(&hbEvents, 0)
+					bad := atomic.SwapInt64(&hbUnmarshalErr, 0)
+					tooLarge := atomic.SwapInt64(&hbTooLarge, 0)
+					last := time.Unix(0, atomic.LoadInt64(&hbLastMsgUnixNano))
+					symbols := s.symbolsSeen()
+					skewed := s.skewRejected()
+					log.Printf("%s heartbeat msgs=%d events=%d unmarshal_err=%d decompress_too_large=%d last_msg_ago=%s symbols_seen=%d skew_rejected=%d", s.name(), msgs, events, bad, tooLarge, time.Since(last).Round(time.Second), symbols, skewed)
+				}
+			}
+		}()
+	}
+	defer close(hbDone)
+
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(20 * time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				_ = conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(5*time.Second))
+			}
+		}
+	}()
+	defer close(done)
+
+	unmarshalSampleLogged := 0
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		mt, b, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		if hbEvery > 0 {
+			atomic.AddInt64(&hbMsgs, 1)
+			atomic.StoreInt64(&hbLastMsgUnixNano, time.Now().UnixNano())
+		}
+
+		events, ok, tooLarge := decodeMarkPriceEvents(b, s.MaxDecompressedBytes)
+		if !ok {
+			if hbEvery > 0 {
+				atomic.AddInt64(&hbUnmarshalErr, 1)
+				if tooLarge {
+					atomic.AddInt64(&hbTooLarge, 1)
+				}
+			}
+			if unmarshalSampleLogged < 3 {
+				unmarshalSampleLogged += 1
+				head := b
+				if len(head) > 32 {
+					head = head[:32]
+				}
+				tail := b
+				if len(tail) > 32 {
+					tail = tail[len(tail)-32:]
+				}
+				log.Printf("%s unmarshal sample mt=%d len=%d head_hex=%x tail_hex=%x", s.name(), mt, len(b), head, tail)
+				trimmed := bytes.TrimSpace(b)
+				if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+					prefix := string(trimmed)
+					if len(prefix) > 160 {
+						prefix = prefix[:160]
+					}
+					log.Printf("%s unmarshal sample prefix=%q", s.name(), prefix)
+				}
+
+				bb := cleanJSONBytes(b)
+				if len(bb) > 0 && (bb[0] == '[' || bb[0] == '{') {
+					var tmp []binance.MarkPriceEvent
+					if err0 := json.Unmarshal(bb, &tmp); err0 != nil {
+						log.Printf("%s unmarshal err_clean=%v", s.name(), err0)
+					}
+					if cand := trimAfterJSONEnd(bb); cand != nil {
+						if err1 := json.Unmarshal(cand, &tmp); err1 != nil {
+							log.Printf("%s unmarshal err_trim=%v", s.name(), err1)
+						}
+					}
+				}
+			}
+			continue
+		}
+		if hbEvery > 0 {
+			atomic.AddInt64(&hbEvents, int64(len(events)))
+		}
+
+		select {
+		case out <- events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ChanSource is a PriceSource backed by a channel, for tests and alternate feeds.
+// Each Stream call forwards batches from C until ctx is done or C is closed.
+type ChanSource struct {
+	C chan []binance.MarkPriceEvent
+}
+
+// NewChanSource creates a ChanSource with the given buffer size.
+func NewChanSource(buffer int) *ChanSource {
+	return &ChanSource{C: make(chan []binance.MarkPriceEvent, buffer)}
+}
+
+// Stream implements PriceSource.
+func (s *ChanSource) Stream(ctx context.Context) (<-chan []binance.MarkPriceEvent, error) {
+	out := make(chan []binance.MarkPriceEvent)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case batch, ok := <-s.C:
+				if !ok {
+					return
+				}
+				select {
+				case out <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// MultiSource merges several PriceSources, e.g. USDT- and COIN-margined feeds.
+// Its channel closes once every source's channel has closed.
+type MultiSource []PriceSource
+
+// Stream implements PriceSource.
+func (s MultiSource) Stream(ctx context.Context) (<-chan []binance.MarkPriceEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	chans := make([]<-chan []binance.MarkPriceEvent, 0, len(s))
+	for _, src := range s {
+		ch, err := src.Stream(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		chans = append(chans, ch)
+	}
+
+	out := make(chan []binance.MarkPriceEvent, 16)
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch <-chan []binance.MarkPriceEvent) {
+			defer wg.Done()
+			for batch := range ch {
+				select {
+				case out <- batch:
+				case <-ctx.Done():
+				}
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out, nil
+}
@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/kline"
+	"example.com/binance-pivot-monitor/internal/pivot"
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+	"example.com/binance-pivot-monitor/internal/sse"
+)
+
+// TestMonitor_Run_ChanSource drives prices through a fake source and checks
+// that a level crossing is published end to end.
+func TestMonitor_Run_ChanSource(t *testing.T) {
+	pivotStore := pivot.NewStore()
+	setPivotLevels(pivotStore, pivot.PeriodDaily, "BTCUSDT", pivot.Levels{
+		R3: 50000, R4: 51000, R5: 52000,
+		S3: 48000, S4: 47000, S5: 46000,
+	})
+
+	broker := sse.NewBroker[signalpkg.Signal]()
+	sub := broker.Subscribe(16)
+	defer broker.Unsubscribe(sub)
+
+	src := NewChanSource(4)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivotStore,
+		Broker:     broker,
+		History:    signalpkg.NewHistory(100),
+		Cooldown:   signalpkg.NewCooldown(time.Minute),
+	})
+	m.PriceSource = src
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	ts := time.Now().UnixMilli()
+	src.C <- []binance.MarkPriceEvent{{EventTime: ts, Symbol: "BTCUSDT", MarkPrice: "49900"}}
+	src.C <- []binance.MarkPriceEvent{{EventTime: ts + 1000, Symbol: "BTCUSDT", MarkPrice: "50100"}}
+
+	select {
+	case sig := <-sub:
+		if sig.Symbol != "BTCUSDT" || sig.Level != "R3" || sig.Direction != "up" {
+			t.Errorf("unexpected signal: %+v", sig)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no signal received")
+	}
+
+	if got := m.SymbolsSeen(); got != 1 {
+		t.Errorf("SymbolsSeen() = %d, want 1", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestMonitor_HandleEvents_RejectsClockSkew(t *testing.T) {
+	ks := kline.NewStore(15*time.Minute, 10)
+	m := NewWithConfig(MonitorConfig{
+		PivotStore: pivot.NewStore(),
+		History:    signalpkg.NewHistory(100),
+		Cooldown:   signalpkg.NewCooldown(time.Minute),
+		KlineStore: ks,
+	})
+
+	now := time.Now()
+	m.handleEvents([]binance.MarkPriceEvent{
+		{EventTime: now.Add(time.Hour).UnixMilli(), Symbol: "BTCUSDT", MarkPrice: "50000"},
+		{EventTime: now.UnixMilli(), Symbol: "ETHUSDT", MarkPrice: "3000"},
+	})
+
+	if got := m.SkewRejected(); got != 1 {
+		t.Errorf("SkewRejected() = %d, want 1", got)
+	}
+	if _, ok := ks.GetCurrentKline("BTCUSDT"); ok {
+		t.Error("future event should not create a kline")
+	}
+	if _, ok := ks.GetCurrentKline("ETHUSDT"); !ok {
+		t.Error("current event should create a kline")
+	}
+	if got := m.SymbolsSeen(); got != 1 {
+		t.Errorf("SymbolsSeen() = %d, want 1", got)
+	}
+
+	// Negative MaxClockSkew disables the check
+	m.MaxClockSkew = -1
+	m.handleEvents([]binance.MarkPriceEvent{
+		{EventTime: now.Add(time.Hour).UnixMilli(), Symbol: "BTCUSDT", MarkPrice: "50000"},
+	})
+	if got := m.SkewRejected(); got != 1 {
+		t.Errorf("SkewRejected() with check disabled = %d, want 1", got)
+	}
+}
+
+func TestMultiSource(t *testing.T) {
+	a, b := NewChanSource(1), NewChanSource(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, err := MultiSource{a, b}.Stream(ctx)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	a.C <- []binance.MarkPriceEvent{{Symbol: "BTCUSDT", MarkPrice: "50000"}}
+	b.C <- []binance.MarkPriceEvent{{Symbol: "BTCUSD_PERP", MarkPrice: "50010"}}
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case batch := <-out:
+			for _, ev := range batch {
+				seen[ev.Symbol] = true
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %v, want events from both sources", seen)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Error("unexpected batch after cancel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
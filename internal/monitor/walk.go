@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/pivot"
+	signalpkg "example.com/binance-pivot-monitor/internal/signal"
+)
+
+// walkMinLevels is how many consecutive levels a walk crosses, e.g. R3, R4, R5.
+const walkMinLevels = 3
+
+// walkLevelRank orders the main levels from lowest to highest price.
+// Mid-pivots sit between them and neither extend nor break a walk.
+var walkLevelRank = map[string]int{
+	"S5": 0, "S4": 1, "S3": 2, "S2": 3, "S1": 4,
+	"PP": 5,
+	"R1": 6, "R2": 7, "R3": 8, "R4": 9, "R5": 10,
+}
+
+// walkState is the run of consecutive levels a symbol/period last crossed.
+type walkState struct {
+	from      string // first level of the run
+	last      string // most recent level
+	length    int
+	direction string
+	at        time.Time // time of the most recent cross
+}
+
+// trackWalk extends the symbol/period run with a cross of levelName and
+// emits a walk signal once it spans walkMinLevels levels (and on each
+// further level). A cross extends the run when it is the next level in the
+// run's direction and comes within WalkWindow of the previous one;
+// otherwise it starts a new run.
+func (m *Monitor) trackWalk(symbol string, period pivot.Period, levelName string, price float64, direction string, ts time.Time, trend string) {
+	if m.WalkWindow <= 0 {
+		return
+	}
+	rank, ok := walkLevelRank[levelName]
+	if !ok {
+		return
+	}
+	step := 1
+	if direction == "down" {
+		step = -1
+	}
+
+	key := symbol + "|" + string(period)
+	st, ok := m.walks[key]
+	if !ok || st.direction != direction || ts.Sub(st.at) > m.WalkWindow || walkLevelRank[st.last]+step != rank {
+		if m.walks == nil {
+			m.walks = make(map[string]*walkState)
+		}
+		m.walks[key] = &walkState{from: levelName, last: levelName, length: 1, direction: direction, at: ts}
+		return
+	}
+	st.last = levelName
+	st.length++
+	st.at = ts
+	if st.length < walkMinLevels {
+		return
+	}
+
+	m.publish(signalpkg.Signal{
+		Symbol:      symbol,
+		Period:      string(period),
+		Level:       levelName,
+		Price:       price,
+		Direction:   direction,
+		TriggeredAt: ts,
+		Kind:        signalpkg.KindWalk,
+		Trend:       trend,
+		WalkFrom:    st.from,
+	})
+}
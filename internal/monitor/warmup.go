@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/kline"
+)
+
+// KlineFetcher fetches recent klines; *binance.RESTClient implements it.
+type KlineFetcher interface {
+	Klines(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error)
+}
+
+// WarmupKlines seeds store with each symbol's most recent closed klines so
+// pattern detection can fire right after startup instead of waiting for
+// limit klines to accumulate. The still-forming kline is skipped, and volume
+// is left out to match klines built from mark prices. Seeding does not
+// trigger detection. Returns the number of symbols seeded.
+func WarmupKlines(ctx context.Context, client KlineFetcher, store *kline.Store, symbols []string, limit, workers int) int {
+	if client == nil || store == nil || len(symbols) == 0 || limit <= 0 {
+		return 0
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	interval := store.Timeframe()
+	jobs := make(chan string)
+	var seeded, failed int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range jobs {
+				// One extra kline since the newest one is still forming
+				raw, err := client.Klines(ctx, symbol, interval, limit+1)
+				if err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					continue
+				}
+
+				now := time.Now()
+				klines := make([]kline.Kline, 0, len(raw))
+				for _, k := range raw {
+					if !k.CloseTime.Before(now) {
+						continue
+					}
+					klines = append(klines, kline.Kline{
+						Open:     k.Open,
+						High:     k.High,
+						Low:      k.Low,
+						Close:    k.Close,
+						OpenTime: k.OpenTime,
+					})
+				}
+				if store.Seed(symbol, klines) > 0 {
+					mu.Lock()
+					seeded++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, symbol := range symbols {
+		select {
+		case jobs <- symbol:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failed > 0 {
+		log.Printf("kline warmup %s: %d symbols failed", interval, failed)
+	}
+	return seeded
+}
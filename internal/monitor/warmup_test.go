@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/kline"
+)
+
+type fakeKlineFetcher struct {
+	klines map[string][]binance.Kline
+}
+
+func (f *fakeKlineFetcher) Klines(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error) {
+	k, ok := f.klines[symbol]
+	if !ok {
+		return nil, errors.New("unknown symbol")
+	}
+	if len(k) > limit {
+		k = k[len(k)-limit:]
+	}
+	return k, nil
+}
+
+func TestWarmupKlines(t *testing.T) {
+	interval := 5 * time.Minute
+	open := time.Now().Truncate(interval).Add(-3 * interval)
+
+	// Three closed klines plus the one still forming
+	var raw []binance.Kline
+	for i := 0; i < 4; i++ {
+		o := open.Add(time.Duration(i) * interval)
+		raw = append(raw, binance.Kline{
+			OpenTime:  o,
+			CloseTime: o.Add(interval - time.Millisecond),
+			Open:      100, High: 110, Low: 90, Close: 105,
+			Volume: 1000,
+		})
+	}
+	fetcher := &fakeKlineFetcher{klines: map[string][]binance.Kline{"BTCUSDT": raw}}
+
+	store := kline.NewStore(interval, 12)
+	closed := make(chan struct{}, 1)
+	store.SetOnClose(func(string, []kline.Kline) { closed <- struct{}{} })
+
+	n := WarmupKlines(context.Background(), fetcher, store, []string{"BTCUSDT", "ETHUSDT"}, 12, 2)
+	if n != 1 {
+		t.Errorf("WarmupKlines seeded %d symbols, want 1 (ETHUSDT fails)", n)
+	}
+
+	got, ok := store.GetKlines("BTCUSDT")
+	if !ok || len(got) != 3 {
+		t.Fatalf("GetKlines = %d klines, want 3 closed klines", len(got))
+	}
+	if !got[2].OpenTime.Equal(open.Add(2 * interval)) {
+		t.Errorf("newest seeded kline opens at %v, want %v", got[2].OpenTime, open.Add(2*interval))
+	}
+	if got[0].Volume != 0 {
+		t.Errorf("Volume = %v, want 0 (mark price klines carry no volume)", got[0].Volume)
+	}
+
+	select {
+	case <-closed:
+		t.Error("warmup must not trigger pattern detection")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
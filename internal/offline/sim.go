@@ -0,0 +1,200 @@
+// Package offline provides deterministic synthetic market data so the server
+// can run without any outbound network calls (CI, local demos).
+package offline
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/kline"
+	"example.com/binance-pivot-monitor/internal/pivot"
+)
+
+// DefaultSeed is the random seed used when Simulator.Seed is zero.
+const DefaultSeed = 42
+
+// DefaultBasePrices are the symbols and starting prices used in offline mode.
+var DefaultBasePrices = map[string]float64{
+	"BTCUSDT":  60000,
+	"ETHUSDT":  3000,
+	"BNBUSDT":  550,
+	"SOLUSDT":  150,
+	"XRPUSDT":  0.55,
+	"DOGEUSDT": 0.12,
+}
+
+// Simulator generates a deterministic random walk of mark prices.
+type Simulator struct {
+	BasePrices map[string]float64
+	Interval   time.Duration // Time between event batches, default 1s
+	Seed       int64
+
+	// OnTicker, if set, receives 24h ticker events derived from each batch.
+	OnTicker func([]binance.TickerEvent)
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	prices  map[string]float64
+	volumes map[string]float64
+}
+
+// NewSimulator creates a simulator over DefaultBasePrices.
+func NewSimulator() *Simulator {
+	return &Simulator{
+		BasePrices: DefaultBasePrices,
+		Interval:   time.Second,
+		Seed:       DefaultSeed,
+	}
+}
+
+// Symbols returns the simulated symbols (sorted).
+func (s *Simulator) Symbols() []string {
+	out := make([]string, 0, len(s.BasePrices))
+	for sym := range s.BasePrices {
+		out = append(out, sym)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *Simulator) init() {
+	if s.rng != nil {
+		return
+	}
+	seed := s.Seed
+	if seed == 0 {
+		seed = DefaultSeed
+	}
+	s.rng = rand.New(rand.NewSource(seed))
+	s.prices = make(map[string]float64, len(s.BasePrices))
+	s.volumes = make(map[string]float64, len(s.BasePrices))
+	for sym, p := range s.BasePrices {
+		s.prices[sym] = p
+	}
+}
+
+// step advances every symbol by one random-walk step (max ±0.2%).
+// Symbols are visited in sorted order so the sequence is reproducible.
+func (s *Simulator) step() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+
+	out := make(map[string]float64, len(s.prices))
+	for _, sym := range s.Symbols() {
+		p := s.prices[sym] * (1 + (s.rng.Float64()-0.5)*0.004)
+		s.prices[sym] = p
+		s.volumes[sym] += p * (1 + s.rng.Float64()*10)
+		out[sym] = p
+	}
+	return out
+}
+
+// SeedPivots stores daily and weekly Camarilla levels computed from the base
+// prices (±2% daily range, ±5% weekly range).
+func (s *Simulator) SeedPivots(store *pivot.Store, now time.Time) {
+	for _, p := range []struct {
+		period pivot.Period
+		rng    float64
+	}{{pivot.PeriodDaily, 0.02}, {pivot.PeriodWeekly, 0.05}} {
+		snap := &pivot.Snapshot{
+			Period:    p.period,
+			UpdatedAt: now,
+			Symbols:   make(map[string]pivot.Levels, len(s.BasePrices)),
+		}
+		for sym, base := range s.BasePrices {
+			lv, err := pivot.Calculate(base*(1+p.rng), base*(1-p.rng), base)
+			if err != nil {
+				continue
+			}
+			snap.Symbols[sym] = lv
+		}
+		_ = store.Swap(p.period, snap)
+	}
+}
+
+// SeedKlines feeds count intervals of back-dated prices into the kline store,
+// so charts and pattern detection have history right away.
+func (s *Simulator) SeedKlines(store *kline.Store, interval time.Duration, count int, now time.Time) {
+	const ticksPerKline = 4
+	start := now.Add(-time.Duration(count) * interval)
+	step := interval / ticksPerKline
+	for ts := start; ts.Before(now); ts = ts.Add(step) {
+		for sym, p := range s.step() {
+			store.Update(sym, p, ts)
+		}
+	}
+}
+
+// Stream emits a batch of mark price events every Interval until ctx is done.
+func (s *Simulator) Stream(ctx context.Context) (<-chan []binance.MarkPriceEvent, error) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	out := make(chan []binance.MarkPriceEvent, 1)
+	go func() {
+		defer close(out)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				batch := s.nextBatch(now)
+				select {
+				case out <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *Simulator) nextBatch(now time.Time) []binance.MarkPriceEvent {
+	prices := s.step()
+	ms := now.UnixMilli()
+
+	events := make([]binance.MarkPriceEvent, 0, len(prices))
+	for sym, p := range prices {
+		events = append(events, binance.MarkPriceEvent{
+			EventTime: ms,
+			Symbol:    sym,
+			MarkPrice: strconv.FormatFloat(p, 'f', -1, 64),
+		})
+	}
+
+	if s.OnTicker != nil {
+		s.OnTicker(s.tickers(prices, ms))
+	}
+	return events
+}
+
+func (s *Simulator) tickers(prices map[string]float64, ms int64) []binance.TickerEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]binance.TickerEvent, 0, len(prices))
+	for sym, p := range prices {
+		base := s.BasePrices[sym]
+		out = append(out, binance.TickerEvent{
+			Symbol:       sym,
+			LastPrice:    p,
+			PriceChange:  p - base,
+			PricePercent: (p - base) / base * 100,
+			QuoteVolume:  s.volumes[sym],
+			TradeCount:   int64(s.volumes[sym] / p),
+			EventTime:    ms,
+		})
+	}
+	return out
+}
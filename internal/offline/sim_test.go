@@ -0,0 +1,90 @@
+package offline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/kline"
+	"example.com/binance-pivot-monitor/internal/pivot"
+)
+
+func TestSimulator_Deterministic(t *testing.T) {
+	a := NewSimulator()
+	b := NewSimulator()
+
+	for i := 0; i < 10; i++ {
+		pa := a.step()
+		pb := b.step()
+		for sym, p := range pa {
+			if pb[sym] != p {
+				t.Fatalf("step %d %s: %v != %v", i, sym, p, pb[sym])
+			}
+		}
+	}
+}
+
+func TestSimulator_SeedPivots(t *testing.T) {
+	sim := NewSimulator()
+	store := pivot.NewStore()
+	sim.SeedPivots(store, time.Now())
+
+	for _, period := range []pivot.Period{pivot.PeriodDaily, pivot.PeriodWeekly} {
+		for sym, base := range sim.BasePrices {
+			lv, ok := store.GetLevels(period, sym)
+			if !ok {
+				t.Fatalf("%s %s: missing levels", period, sym)
+			}
+			if !(lv.S5 < base && base < lv.R5) {
+				t.Errorf("%s %s: base %v outside S5..R5 (%v..%v)", period, sym, base, lv.S5, lv.R5)
+			}
+		}
+	}
+}
+
+func TestSimulator_SeedKlines(t *testing.T) {
+	sim := NewSimulator()
+	store := kline.NewStore(5*time.Minute, 12)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	sim.SeedKlines(store, 5*time.Minute, 12, now)
+
+	for _, sym := range sim.Symbols() {
+		if n := store.KlineCount(sym); n != 11 {
+			t.Errorf("%s: KlineCount = %d, want 11", sym, n)
+		}
+	}
+}
+
+func TestSimulator_Stream(t *testing.T) {
+	sim := NewSimulator()
+	sim.Interval = 10 * time.Millisecond
+
+	var tickers []binance.TickerEvent
+	sim.OnTicker = func(ev []binance.TickerEvent) { tickers = ev }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := sim.Stream(ctx)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	select {
+	case batch := <-events:
+		if len(batch) != len(sim.BasePrices) {
+			t.Errorf("batch len = %d, want %d", len(batch), len(sim.BasePrices))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no batch received")
+	}
+	if len(tickers) != len(sim.BasePrices) {
+		t.Errorf("tickers len = %d, want %d", len(tickers), len(sim.BasePrices))
+	}
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,89 @@
+package pattern
+
+import (
+	"container/list"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	"example.com/binance-pivot-monitor/internal/kline"
+)
+
+// resultCache is an LRU of Detect results keyed by windowKey. Detect is
+// deterministic, so a window seen again (a retried or re-delivered kline
+// close) can skip the talib suite.
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front = most recently used
+	entries map[uint64]*list.Element
+}
+
+type cacheEntry struct {
+	key      uint64
+	patterns []DetectedPattern
+}
+
+func newResultCache(size int) *resultCache {
+	return &resultCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[uint64]*list.Element, size),
+	}
+}
+
+// windowKey hashes the OHLC of klines, the only input Detect depends on.
+func windowKey(klines []kline.Kline) uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	put := func(f float64) {
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+		_, _ = h.Write(b[:])
+	}
+	for _, k := range klines {
+		put(k.Open)
+		put(k.High)
+		put(k.Low)
+		put(k.Close)
+	}
+	return h.Sum64()
+}
+
+// get returns a copy of the cached patterns of key.
+func (c *resultCache) get(key uint64) ([]DetectedPattern, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return clonePatterns(el.Value.(*cacheEntry).patterns), true
+}
+
+// put stores a copy of patterns under key, evicting the least recently used
+// entry when full.
+func (c *resultCache) put(key uint64, patterns []DetectedPattern) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, patterns: clonePatterns(patterns)})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func clonePatterns(ps []DetectedPattern) []DetectedPattern {
+	if ps == nil {
+		return nil
+	}
+	out := make([]DetectedPattern, len(ps))
+	copy(out, ps)
+	return out
+}
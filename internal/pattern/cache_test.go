@@ -0,0 +1,63 @@
+package pattern
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetector_Cache(t *testing.T) {
+	klines := talibTestKlines()
+	plain := NewDetector(DefaultDetectorConfig())
+
+	cfg := DefaultDetectorConfig()
+	cfg.CacheSize = 2
+	cached := NewDetector(cfg)
+
+	want := plain.Detect(klines)
+	for i := 0; i < 3; i++ {
+		if got := cached.Detect(klines); !reflect.DeepEqual(got, want) {
+			t.Fatalf("call %d: cached Detect = %v, want %v", i, got, want)
+		}
+	}
+
+	// Callers cannot corrupt the cached result
+	if got := cached.Detect(klines); len(got) > 0 {
+		got[0].Confidence = -1
+		if again := cached.Detect(klines); again[0].Confidence == -1 {
+			t.Error("mutating a returned slice changed the cache")
+		}
+	}
+
+	// A changed window misses; the least recently used window is evicted
+	other := append(klines[:len(klines)-1:len(klines)-1], makeKline(100, 110, 90, 105))
+	third := append(klines[:len(klines)-1:len(klines)-1], makeKline(100, 101, 99, 100))
+	cached.Detect(other)
+	cached.Detect(third)
+	if _, ok := cached.cache.get(windowKey(klines)); ok {
+		t.Error("oldest window still cached after two newer ones with CacheSize=2")
+	}
+	if got, want := cached.Detect(other), plain.Detect(other); !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect(other) = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkDetect_Uncached(b *testing.B) {
+	d := NewDetector(DefaultDetectorConfig())
+	klines := talibTestKlines()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Detect(klines)
+	}
+}
+
+func BenchmarkDetect_CacheHit(b *testing.B) {
+	cfg := DefaultDetectorConfig()
+	cfg.CacheSize = 128
+	d := NewDetector(cfg)
+	klines := talibTestKlines()
+	d.Detect(klines)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Detect(klines)
+	}
+}
@@ -7,6 +7,10 @@ import (
 // detectCustomPatterns detects patterns not available in talib-cdl-go.
 func (d *Detector) detectCustomPatterns(klines []kline.Kline) []DetectedPattern {
 	var patterns []DetectedPattern
+	dojiRatio := d.config.DojiBodyRatio
+	if dojiRatio <= 0 {
+		dojiRatio = DefaultDojiBodyRatio
+	}
 
 	// Hammer
 	if found, dir, conf := detectHammer(klines); found {
@@ -39,12 +43,12 @@ func (d *Detector) detectCustomPatterns(klines []kline.Kline) []DetectedPattern
 	}
 
 	// Morning Doji Star
-	if found, dir, conf := detectMorningDojiStar(klines); found {
+	if found, dir, conf := detectMorningDojiStar(klines, dojiRatio); found {
 		patterns = append(patterns, DetectedPattern{Type: PatternMorningDojiStar, Direction: dir, Confidence: conf})
 	}
 
 	// Evening Doji Star
-	if found, dir, conf := detectEveningDojiStar(klines); found {
+	if found, dir, conf := detectEveningDojiStar(klines, dojiRatio); found {
 		patterns = append(patterns, DetectedPattern{Type: PatternEveningDojiStar, Direction: dir, Confidence: conf})
 	}
 
@@ -59,23 +63,63 @@ func (d *Detector) detectCustomPatterns(klines []kline.Kline) []DetectedPattern
 	}
 
 	// Harami Cross
-	if found, dir, conf := detectHaramiCross(klines); found {
+	if found, dir, conf := detectHaramiCross(klines, dojiRatio); found {
 		patterns = append(patterns, DetectedPattern{Type: PatternHaramiCross, Direction: dir, Confidence: conf})
 	}
 
 	// Dragonfly Doji
-	if found, dir, conf := detectDragonflyDoji(klines); found {
+	if found, dir, conf := detectDragonflyDoji(klines, dojiRatio); found {
 		patterns = append(patterns, DetectedPattern{Type: PatternDragonflyDoji, Direction: dir, Confidence: conf})
 	}
 
 	// Gravestone Doji
-	if found, dir, conf := detectGravestoneDoji(klines); found {
+	if found, dir, conf := detectGravestoneDoji(klines, dojiRatio); found {
 		patterns = append(patterns, DetectedPattern{Type: PatternGravestoneDoji, Direction: dir, Confidence: conf})
 	}
 
+	// Rising / Falling Three Methods (continuation)
+	if found, dir, conf := detectThreeMethods(klines); found {
+		pt := PatternRisingThreeMethods
+		if dir == DirectionBearish {
+			pt = PatternFallingThreeMethods
+		}
+		patterns = append(patterns, DetectedPattern{Type: pt, Direction: dir, Confidence: conf})
+	}
+
+	// Inside / Outside Bar (structure, opt-in)
+	if d.config.StructurePatterns {
+		if found, dir, conf := detectInsideBar(klines); found {
+			patterns = append(patterns, DetectedPattern{Type: PatternInsideBar, Direction: dir, Confidence: conf})
+		}
+		if found, dir, conf := detectOutsideBar(klines); found {
+			patterns = append(patterns, DetectedPattern{Type: PatternOutsideBar, Direction: dir, Confidence: conf})
+		}
+	}
+
+	// Three Inside (crypto mode fallback; talib's version wins when both fire)
+	if d.config.CryptoMode {
+		if found, dir, conf := detectThreeInside(klines); found {
+			patterns = append(patterns, DetectedPattern{Type: PatternThreeInside, Direction: dir, Confidence: conf})
+		}
+	}
+
 	return patterns
 }
 
+// Trend returns "up" or "down" when klines show a clear trend by the same
+// rules the reversal patterns use, or "" when neither (or both) apply.
+func Trend(klines []kline.Kline) string {
+	up, down := isUptrend(klines), isDowntrend(klines)
+	switch {
+	case up && !down:
+		return "up"
+	case down && !up:
+		return "down"
+	default:
+		return ""
+	}
+}
+
 // isDowntrend checks if the klines show a downtrend.
 // Condition: closing prices decreasing OR at least 2/3 bearish.
 func isDowntrend(klines []kline.Kline) bool {
@@ -133,13 +177,13 @@ func isUptrend(klines []kline.Kline) bool {
 	return bullishCount >= (len(klines)*2)/3
 }
 
-// isDoji checks if a kline is a doji (very small body).
+// isDoji checks if a kline is a doji (body/range below ratio).
 // Excludes zero-range klines to avoid false positives in low-liquidity data.
-func isDoji(k *kline.Kline) bool {
+func isDoji(k *kline.Kline, ratio float64) bool {
 	if k.Range() == 0 {
 		return false // 零波动不算 doji，避免极端数据误报
 	}
-	return k.Body()/k.Range() < 0.1
+	return k.Body()/k.Range() < ratio
 }
 
 // detectHammer detects hammer pattern.
@@ -351,7 +395,7 @@ func detectMorningStar(klines []kline.Kline) (bool, Direction, int) {
 }
 
 // detectMorningDojiStar detects morning doji star pattern.
-func detectMorningDojiStar(klines []kline.Kline) (bool, Direction, int) {
+func detectMorningDojiStar(klines []kline.Kline, dojiRatio float64) (bool, Direction, int) {
 	if len(klines) < 3 {
 		return false, "", 0
 	}
@@ -364,7 +408,7 @@ func detectMorningDojiStar(klines []kline.Kline) (bool, Direction, int) {
 		return false, "", 0
 	}
 	// Second: doji
-	if !isDoji(second) {
+	if !isDoji(second, dojiRatio) {
 		return false, "", 0
 	}
 	// Third: large bullish candle
@@ -381,7 +425,7 @@ func detectMorningDojiStar(klines []kline.Kline) (bool, Direction, int) {
 }
 
 // detectEveningDojiStar detects evening doji star pattern.
-func detectEveningDojiStar(klines []kline.Kline) (bool, Direction, int) {
+func detectEveningDojiStar(klines []kline.Kline, dojiRatio float64) (bool, Direction, int) {
 	if len(klines) < 3 {
 		return false, "", 0
 	}
@@ -394,7 +438,7 @@ func detectEveningDojiStar(klines []kline.Kline) (bool, Direction, int) {
 		return false, "", 0
 	}
 	// Second: doji
-	if !isDoji(second) {
+	if !isDoji(second, dojiRatio) {
 		return false, "", 0
 	}
 	// Third: large bearish candle
@@ -492,7 +536,7 @@ func detectHarami(klines []kline.Kline) (bool, Direction, int) {
 }
 
 // detectHaramiCross detects harami cross pattern (harami with doji).
-func detectHaramiCross(klines []kline.Kline) (bool, Direction, int) {
+func detectHaramiCross(klines []kline.Kline, dojiRatio float64) (bool, Direction, int) {
 	if len(klines) < 2 {
 		return false, "", 0
 	}
@@ -505,7 +549,7 @@ func detectHaramiCross(klines []kline.Kline) (bool, Direction, int) {
 	}
 
 	// Current must be a doji
-	if !isDoji(curr) {
+	if !isDoji(curr, dojiRatio) {
 		return false, "", 0
 	}
 
@@ -527,8 +571,140 @@ func detectHaramiCross(klines []kline.Kline) (bool, Direction, int) {
 	return true, DirectionBearish, 70
 }
 
+// detectThreeInside detects three inside up/down: a harami (as in
+// detectHarami) confirmed by a third candle closing beyond the first candle's
+// open. Unlike talib, body sizes are not compared with trailing averages and
+// the second body may touch the first body's edges, so it also fires on short
+// histories and on the flat opens common in continuous crypto markets.
+func detectThreeInside(klines []kline.Kline) (bool, Direction, int) {
+	if len(klines) < 3 {
+		return false, "", 0
+	}
+	first := &klines[len(klines)-3]
+	second := &klines[len(klines)-2]
+	third := &klines[len(klines)-1]
+
+	// First and second form a harami
+	if found, _, _ := detectHarami([]kline.Kline{*first, *second}); !found {
+		return false, "", 0
+	}
+
+	// Third confirms by closing beyond the first candle's open
+	if first.IsBearish() && third.IsBullish() && third.Close > first.Open {
+		return true, DirectionBullish, 70
+	}
+	if first.IsBullish() && third.IsBearish() && third.Close < first.Open {
+		return true, DirectionBearish, 70
+	}
+	return false, "", 0
+}
+
+// detectThreeMethods detects rising/falling three methods over the last 5
+// klines: a long candle, three small counter-trend candles inside its range,
+// then a long candle in the original direction closing beyond the first
+// candle's extreme. Rising is a bullish continuation, falling a bearish one.
+func detectThreeMethods(klines []kline.Kline) (bool, Direction, int) {
+	if len(klines) < 5 {
+		return false, "", 0
+	}
+	first := &klines[len(klines)-5]
+	last := &klines[len(klines)-1]
+	middle := klines[len(klines)-4 : len(klines)-1]
+
+	// First and last: long candles of the same color
+	if first.Range() == 0 || first.Body() < first.Range()*0.6 {
+		return false, "", 0
+	}
+	if last.Range() == 0 || last.Body() < last.Range()*0.6 {
+		return false, "", 0
+	}
+	bullish := first.IsBullish()
+	if bullish != last.IsBullish() {
+		return false, "", 0
+	}
+
+	// Middle: three small opposite-color candles inside the first's range
+	for i := range middle {
+		k := &middle[i]
+		if bullish && !k.IsBearish() || !bullish && !k.IsBullish() {
+			return false, "", 0
+		}
+		if k.Body() > first.Body()*0.5 || k.High > first.High || k.Low < first.Low {
+			return false, "", 0
+		}
+	}
+
+	// Last: closes beyond the first candle's extreme
+	confidence := 75
+	if bullish {
+		if last.Close <= first.High {
+			return false, "", 0
+		}
+		if last.Body() >= first.Body() {
+			confidence = 85
+		}
+		return true, DirectionBullish, confidence
+	}
+	if last.Close >= first.Low {
+		return false, "", 0
+	}
+	if last.Body() >= first.Body() {
+		confidence = 85
+	}
+	return true, DirectionBearish, confidence
+}
+
+// detectInsideBar detects an inside bar: the current range lies within the
+// previous range. It hints at consolidation before a continuation, so it is
+// reported as neutral.
+func detectInsideBar(klines []kline.Kline) (bool, Direction, int) {
+	if len(klines) < 2 {
+		return false, "", 0
+	}
+	prev := &klines[len(klines)-2]
+	curr := &klines[len(klines)-1]
+
+	if prev.Range() == 0 || curr.Range() >= prev.Range() {
+		return false, "", 0
+	}
+	if curr.High > prev.High || curr.Low < prev.Low {
+		return false, "", 0
+	}
+	return true, DirectionNeutral, 60
+}
+
+// detectOutsideBar detects an outside bar: the current range engulfs the
+// previous range on both sides. The direction follows the close: the upper
+// half of the range is bullish, the lower half bearish. A close beyond the
+// previous range raises the confidence.
+func detectOutsideBar(klines []kline.Kline) (bool, Direction, int) {
+	if len(klines) < 2 {
+		return false, "", 0
+	}
+	prev := &klines[len(klines)-2]
+	curr := &klines[len(klines)-1]
+
+	if curr.High <= prev.High || curr.Low >= prev.Low {
+		return false, "", 0
+	}
+
+	mid := (curr.High + curr.Low) / 2
+	switch {
+	case curr.Close > prev.High:
+		return true, DirectionBullish, 70
+	case curr.Close < prev.Low:
+		return true, DirectionBearish, 70
+	case curr.Close > mid:
+		return true, DirectionBullish, 60
+	case curr.Close < mid:
+		return true, DirectionBearish, 60
+	default:
+		return true, DirectionNeutral, 60
+	}
+}
+
 // detectDragonflyDoji detects dragonfly doji pattern.
-func detectDragonflyDoji(klines []kline.Kline) (bool, Direction, int) {
+func detectDragonflyDoji(klines []kline.Kline, dojiRatio float64) (bool, Direction, int) {
 	if len(klines) < 1 {
 		return false, "", 0
 	}
@@ -539,7 +715,7 @@ func detectDragonflyDoji(klines []kline.Kline) (bool, Direction, int) {
 	}
 
 	// Must be a doji
-	if !isDoji(k) {
+	if !isDoji(k, dojiRatio) {
 		return false, "", 0
 	}
 
@@ -558,7 +734,7 @@ func detectDragonflyDoji(klines []kline.Kline) (bool, Direction, int) {
 }
 
 // detectGravestoneDoji detects gravestone doji pattern.
-func detectGravestoneDoji(klines []kline.Kline) (bool, Direction, int) {
+func detectGravestoneDoji(klines []kline.Kline, dojiRatio float64) (bool, Direction, int) {
 	if len(klines) < 1 {
 		return false, "", 0
 	}
@@ -569,7 +745,7 @@ func detectGravestoneDoji(klines []kline.Kline) (bool, Direction, int) {
 	}
 
 	// Must be a doji
-	if !isDoji(k) {
+	if !isDoji(k, dojiRatio) {
 		return false, "", 0
 	}
 
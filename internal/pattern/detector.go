@@ -1,6 +1,8 @@
 package pattern
 
 import (
+	"math"
+
 	talibcdl "github.com/iwat/talib-cdl-go"
 
 	"example.com/binance-pivot-monitor/internal/kline"
@@ -12,8 +14,53 @@ type DetectorConfig struct {
 	HighEfficiencyOnly bool // Only detect high efficiency patterns (A/B rank)
 	CryptoMode         bool // Crypto market mode (relaxed gap conditions)
 	GapThreshold       float64 // Gap threshold for crypto mode (default 0.001 = 0.1%)
+
+	// MinConfidencePerPattern overrides MinConfidence for listed patterns.
+	// Unlisted patterns use the global MinConfidence.
+	MinConfidencePerPattern map[PatternType]int
+
+	// EnabledTalibPatterns restricts talib-cdl-go detection to the listed
+	// patterns to save CPU. Nil means all; custom patterns are unaffected.
+	EnabledTalibPatterns []PatternType
+
+	// MinKlines is the number of klines required before Detect runs, so a
+	// nearly-empty store does not produce premature patterns. Values below 2
+	// keep the built-in minimum of 2.
+	MinKlines int
+
+	// DojiBodyRatio is the largest body/range ratio that still counts as a
+	// doji (doji stars, harami cross, dragonfly and gravestone). Zero or
+	// negative uses DefaultDojiBodyRatio.
+	DojiBodyRatio float64
+
+	// CacheSize keeps the results of this many recent kline windows (LRU,
+	// keyed by a hash of their OHLC) so detecting an already seen window
+	// again skips the talib suite (about 15x faster, see
+	// BenchmarkDetect_CacheHit). Zero disables the cache.
+	CacheSize int
+
+	// RawScores keeps talib's signed -100..100 output in
+	// DetectedPattern.RawScore (and so Signal.RawScore). Off by default,
+	// leaving RawScore zero and the signal JSON unchanged.
+	RawScores bool
+
+	// TalibConfidenceScale multiplies talib-derived confidences (mostly 100
+	// or 80) before the min-confidence filter, to de-weight talib relative
+	// to the custom detectors. Results are capped at 100. Zero or negative
+	// uses DefaultTalibConfidenceScale.
+	TalibConfidenceScale float64
+
+	// StructurePatterns enables the inside and outside bar detectors. They
+	// fire on most klines, so they are off by default.
+	StructurePatterns bool
 }
 
+// DefaultDojiBodyRatio is the default doji body/range threshold.
+const DefaultDojiBodyRatio = 0.1
+
+// DefaultTalibConfidenceScale leaves talib confidences unchanged.
+const DefaultTalibConfidenceScale = 1.0
+
 // DefaultDetectorConfig returns the default detector configuration.
 func DefaultDetectorConfig() DetectorConfig {
 	return DetectorConfig{
@@ -21,17 +68,32 @@ func DefaultDetectorConfig() DetectorConfig {
 		HighEfficiencyOnly: false,
 		CryptoMode:         true,
 		GapThreshold:       0.001,
+		DojiBodyRatio:      DefaultDojiBodyRatio,
+
+		TalibConfidenceScale: DefaultTalibConfidenceScale,
 	}
 }
 
 // Detector detects candlestick patterns in kline data.
 type Detector struct {
-	config DetectorConfig
+	config       DetectorConfig
+	enabledTalib map[PatternType]struct{} // nil = all talib patterns
+	cache        *resultCache             // nil = no caching
 }
 
 // NewDetector creates a new pattern detector.
 func NewDetector(config DetectorConfig) *Detector {
-	return &Detector{config: config}
+	d := &Detector{config: config}
+	if config.EnabledTalibPatterns != nil {
+		d.enabledTalib = make(map[PatternType]struct{}, len(config.EnabledTalibPatterns))
+		for _, pt := range config.EnabledTalibPatterns {
+			d.enabledTalib[pt] = struct{}{}
+		}
+	}
+	if config.CacheSize > 0 {
+		d.cache = newResultCache(config.CacheSize)
+	}
+	return d
 }
 
 // toSeries converts klines to talib-cdl-go SimpleSeries format.
@@ -57,10 +119,23 @@ func toSeries(klines []kline.Kline) talibcdl.SimpleSeries {
 // klines must be in time order (oldest first, newest last).
 // Returns all detected patterns.
 func (d *Detector) Detect(klines []kline.Kline) []DetectedPattern {
-	if len(klines) < 2 {
+	if len(klines) < 2 || len(klines) < d.config.MinKlines {
 		return nil
 	}
+	if d.cache == nil {
+		return d.detect(klines)
+	}
+	key := windowKey(klines)
+	if patterns, ok := d.cache.get(key); ok {
+		return patterns
+	}
+	patterns := d.detect(klines)
+	d.cache.put(key, patterns)
+	return patterns
+}
 
+// detect runs the talib and custom detectors and filters their results.
+func (d *Detector) detect(klines []kline.Kline) []DetectedPattern {
 	// Detect talib-cdl-go patterns first (higher priority)
 	talibPatterns := d.detectTalibPatterns(klines)
 
@@ -71,20 +146,14 @@ func (d *Detector) Detect(klines []kline.Kline) []DetectedPattern {
 	// This ensures low-confidence talib patterns don't suppress high-confidence custom patterns
 	var filteredTalib []DetectedPattern
 	for _, p := range talibPatterns {
-		if p.Confidence >= d.config.MinConfidence {
-			if d.config.HighEfficiencyOnly && !IsHighEfficiency(p.Type) {
-				continue
-			}
+		if d.keep(p) {
 			filteredTalib = append(filteredTalib, p)
 		}
 	}
 
 	var filteredCustom []DetectedPattern
 	for _, p := range customPatterns {
-		if p.Confidence >= d.config.MinConfidence {
-			if d.config.HighEfficiencyOnly && !IsHighEfficiency(p.Type) {
-				continue
-			}
+		if d.keep(p) {
 			filteredCustom = append(filteredCustom, p)
 		}
 	}
@@ -93,6 +162,23 @@ func (d *Detector) Detect(klines []kline.Kline) []DetectedPattern {
 	return deduplicatePatterns(filteredTalib, filteredCustom)
 }
 
+// keep reports whether p passes its confidence floor and, with
+// HighEfficiencyOnly, the efficiency filter.
+func (d *Detector) keep(p DetectedPattern) bool {
+	if p.Confidence < d.minConfidenceFor(p.Type) {
+		return false
+	}
+	return !d.config.HighEfficiencyOnly || IsHighEfficiency(p.Type)
+}
+
+// minConfidenceFor returns the confidence floor for a pattern type.
+func (d *Detector) minConfidenceFor(pt PatternType) int {
+	if v, ok := d.config.MinConfidencePerPattern[pt]; ok {
+		return v
+	}
+	return d.config.MinConfidence
+}
+
 // patternConflicts defines which custom patterns should be suppressed when talib patterns are detected.
 // Key: talib pattern type, Value: list of custom pattern types to suppress
 // Note: Only patterns that pass the confidence threshold participate in deduplication.
@@ -108,8 +194,9 @@ var patternConflicts = map[PatternType][]PatternType{
 	PatternDojiStar: {},
 
 	// ThreeInside (talib 3-bar) includes Harami (custom 2-bar) as first two bars
-	// Suppress Harami/HaramiCross when ThreeInside is detected to avoid redundant signals
-	PatternThreeInside: {PatternHarami, PatternHaramiCross},
+	// Suppress Harami/HaramiCross when ThreeInside is detected to avoid redundant signals.
+	// talib's ThreeInside is authoritative over the custom crypto-mode fallback.
+	PatternThreeInside: {PatternHarami, PatternHaramiCross, PatternThreeInside},
 
 	// ThreeOutside (talib 3-bar) includes Engulfing (custom 2-bar) as first two bars
 	// Suppress Engulfing when ThreeOutside is detected
@@ -149,7 +236,48 @@ func deduplicatePatterns(talibPatterns, customPatterns []DetectedPattern) []Dete
 	return result
 }
 
+// talibPattern describes one talib-cdl-go detector.
+type talibPattern struct {
+	typ       PatternType
+	detect    func(talibcdl.Series) []int
+	direction Direction // Fixed direction; empty means derived from the result sign
+	gapBased  bool      // Skipped in crypto mode due to gap dependency
+}
+
+// talibPatterns lists all talib-cdl-go patterns in detection order.
+var talibPatterns = []talibPattern{
+	{typ: PatternDoji, detect: talibcdl.Doji, direction: DirectionNeutral},
+	{typ: PatternDojiStar, detect: talibcdl.DojiStar},
+	{typ: PatternEveningStar, detect: func(s talibcdl.Series) []int { return talibcdl.EveningStar(s, 0.3) }, direction: DirectionBearish},
+	{typ: PatternPiercing, detect: talibcdl.Piercing, direction: DirectionBullish},
+	{typ: PatternAbandonedBaby, detect: func(s talibcdl.Series) []int { return talibcdl.AbandonedBaby(s, 0.3) }, gapBased: true},
+	{typ: PatternThreeWhite, detect: talibcdl.ThreeWhiteSoldiers, direction: DirectionBullish},
+	{typ: PatternThreeBlack, detect: talibcdl.ThreeBlackCrows, direction: DirectionBearish},
+	{typ: PatternThreeInside, detect: talibcdl.ThreeInside},
+	{typ: PatternThreeOutside, detect: talibcdl.ThreeOutside},
+	{typ: PatternThreeLineStrike, detect: talibcdl.ThreeLineStrike},
+	{typ: PatternThreeStarsInSouth, detect: talibcdl.ThreeStarsInSouth, direction: DirectionBullish},
+	{typ: PatternAdvanceBlock, detect: talibcdl.AdvanceBlock, direction: DirectionBearish},
+	{typ: PatternBeltHold, detect: talibcdl.BeltHold},
+	{typ: PatternBreakAway, detect: talibcdl.BreakAway},
+	{typ: PatternClosingMarubozu, detect: talibcdl.ClosingMarubozu},
+	{typ: PatternTwoCrows, detect: talibcdl.TwoCrows, direction: DirectionBearish},
+	{typ: PatternMatchingLow, detect: talibcdl.MatchingLow, direction: DirectionBullish},
+	{typ: PatternStickSandwich, detect: talibcdl.StickSandwich, direction: DirectionBullish},
+	{typ: PatternConcealBabySwall, detect: talibcdl.ConcealBabySwall, direction: DirectionBearish},
+}
+
+// talibEnabled reports whether a talib pattern should run (nil EnabledTalibPatterns = all).
+func (d *Detector) talibEnabled(pt PatternType) bool {
+	if d.enabledTalib == nil {
+		return true
+	}
+	_, ok := d.enabledTalib[pt]
+	return ok
+}
+
 // detectTalibPatterns detects patterns using talib-cdl-go library.
+// Disabled patterns (see DetectorConfig.EnabledTalibPatterns) are skipped entirely.
 func (d *Detector) detectTalibPatterns(klines []kline.Kline) []DetectedPattern {
 	if len(klines) < 3 {
 		return nil
@@ -158,213 +286,53 @@ func (d *Detector) detectTalibPatterns(klines []kline.Kline) []DetectedPattern {
 	series := toSeries(klines)
 	var patterns []DetectedPattern
 	lastIdx := len(klines) - 1
-
-	// Doji
-	if results := talibcdl.Doji(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternDoji,
-			Direction:  DirectionNeutral,
-			Confidence: absInt(results[lastIdx]),
-		})
+	scale := d.config.TalibConfidenceScale
+	if scale <= 0 {
+		scale = DefaultTalibConfidenceScale
 	}
 
-	// DojiStar
-	if results := talibcdl.DojiStar(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBearish
-		if results[lastIdx] > 0 {
-			dir = DirectionBullish
+	for _, tp := range talibPatterns {
+		if tp.gapBased && d.config.CryptoMode {
+			continue
 		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternDojiStar,
-			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// EveningStar
-	if results := talibcdl.EveningStar(series, 0.3); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternEveningStar,
-			Direction:  DirectionBearish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// Piercing
-	if results := talibcdl.Piercing(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternPiercing,
-			Direction:  DirectionBullish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// AbandonedBaby (skip in crypto mode due to gap dependency)
-	if !d.config.CryptoMode {
-		if results := talibcdl.AbandonedBaby(series, 0.3); len(results) > lastIdx && results[lastIdx] != 0 {
-			dir := DirectionBullish
+		if !d.talibEnabled(tp.typ) {
+			continue
+		}
+		results := tp.detect(series)
+		if len(results) <= lastIdx || results[lastIdx] == 0 {
+			continue
+		}
+		dir := tp.direction
+		if dir == "" {
+			dir = DirectionBullish
 			if results[lastIdx] < 0 {
 				dir = DirectionBearish
 			}
-			patterns = append(patterns, DetectedPattern{
-				Type:       PatternAbandonedBaby,
-				Direction:  dir,
-				Confidence: absInt(results[lastIdx]),
-			})
-		}
-	}
-
-	// ThreeWhiteSoldiers
-	if results := talibcdl.ThreeWhiteSoldiers(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternThreeWhite,
-			Direction:  DirectionBullish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// ThreeBlackCrows
-	if results := talibcdl.ThreeBlackCrows(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternThreeBlack,
-			Direction:  DirectionBearish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// ThreeInside
-	if results := talibcdl.ThreeInside(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBullish
-		if results[lastIdx] < 0 {
-			dir = DirectionBearish
-		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternThreeInside,
-			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// ThreeOutside
-	if results := talibcdl.ThreeOutside(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBullish
-		if results[lastIdx] < 0 {
-			dir = DirectionBearish
-		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternThreeOutside,
-			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// ThreeLineStrike
-	if results := talibcdl.ThreeLineStrike(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBullish
-		if results[lastIdx] < 0 {
-			dir = DirectionBearish
-		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternThreeLineStrike,
-			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// ThreeStarsInSouth
-	if results := talibcdl.ThreeStarsInSouth(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternThreeStarsInSouth,
-			Direction:  DirectionBullish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// AdvanceBlock
-	if results := talibcdl.AdvanceBlock(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternAdvanceBlock,
-			Direction:  DirectionBearish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// BeltHold
-	if results := talibcdl.BeltHold(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBullish
-		if results[lastIdx] < 0 {
-			dir = DirectionBearish
 		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternBeltHold,
+		p := DetectedPattern{
+			Type:       tp.typ,
 			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// BreakAway
-	if results := talibcdl.BreakAway(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBullish
-		if results[lastIdx] < 0 {
-			dir = DirectionBearish
+			Confidence: scaleConfidence(absInt(results[lastIdx]), scale),
 		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternBreakAway,
-			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// ClosingMarubozu
-	if results := talibcdl.ClosingMarubozu(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		dir := DirectionBullish
-		if results[lastIdx] < 0 {
-			dir = DirectionBearish
+		if d.config.RawScores {
+			p.RawScore = results[lastIdx]
 		}
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternClosingMarubozu,
-			Direction:  dir,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
-
-	// TwoCrows
-	if results := talibcdl.TwoCrows(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternTwoCrows,
-			Direction:  DirectionBearish,
-			Confidence: absInt(results[lastIdx]),
-		})
+		patterns = append(patterns, p)
 	}
 
-	// MatchingLow
-	if results := talibcdl.MatchingLow(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternMatchingLow,
-			Direction:  DirectionBullish,
-			Confidence: absInt(results[lastIdx]),
-		})
-	}
+	return patterns
+}
 
-	// StickSandwich
-	if results := talibcdl.StickSandwich(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternStickSandwich,
-			Direction:  DirectionBullish,
-			Confidence: absInt(results[lastIdx]),
-		})
+// scaleConfidence multiplies a confidence by scale, rounded and capped at 100.
+func scaleConfidence(c int, scale float64) int {
+	if scale == 1 {
+		return c
 	}
-
-	// ConcealBabySwall
-	if results := talibcdl.ConcealBabySwall(series); len(results) > lastIdx && results[lastIdx] != 0 {
-		patterns = append(patterns, DetectedPattern{
-			Type:       PatternConcealBabySwall,
-			Direction:  DirectionBearish,
-			Confidence: absInt(results[lastIdx]),
-		})
+	scaled := int(math.Round(float64(c) * scale))
+	if scaled > 100 {
+		return 100
 	}
-
-	return patterns
+	return scaled
 }
 
 // absInt returns the absolute value of an integer.
@@ -230,6 +230,49 @@ func TestDetector_Detect_GravestoneDoji(t *testing.T) {
 	}
 }
 
+func TestDetector_DojiBodyRatio(t *testing.T) {
+	// Body 1.2 of range 10 (12%): a doji at 0.15 but not at the default 0.1
+	klines := []kline.Kline{
+		makeKline(95, 100, 90, 98),
+		makeKline(98.8, 100, 90, 100),
+	}
+	hasDragonfly := func(ratio float64) bool {
+		detector := NewDetector(DetectorConfig{MinConfidence: 0, DojiBodyRatio: ratio})
+		for _, p := range detector.Detect(klines) {
+			if p.Type == PatternDragonflyDoji {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasDragonfly(0) {
+		t.Error("12% body detected as dragonfly doji with default ratio 0.1")
+	}
+	if !hasDragonfly(0.15) {
+		t.Error("Expected dragonfly doji with DojiBodyRatio 0.15")
+	}
+}
+
+func TestDetector_MinKlines(t *testing.T) {
+	klines := []kline.Kline{
+		makeKline(110, 110, 95, 96),
+		makeKline(96, 98, 94, 97),
+		makeKline(97, 115, 96, 112),
+	}
+
+	detector := NewDetector(DetectorConfig{MinConfidence: 0, MinKlines: 8})
+	if patterns := detector.Detect(klines); len(patterns) != 0 {
+		t.Errorf("expected no patterns below MinKlines, got %v", patterns)
+	}
+
+	// The same window is detected once MinKlines is met
+	detector = NewDetector(DetectorConfig{MinConfidence: 0, MinKlines: 3})
+	if patterns := detector.Detect(klines); len(patterns) == 0 {
+		t.Error("expected patterns with MinKlines=3")
+	}
+}
+
 func TestDetector_MinConfidenceFilter(t *testing.T) {
 	// Create detector with high min confidence
 	detector := NewDetector(DetectorConfig{MinConfidence: 95})
@@ -248,6 +291,49 @@ func TestDetector_MinConfidenceFilter(t *testing.T) {
 	}
 }
 
+func TestDetector_MinConfidencePerPattern(t *testing.T) {
+	detector := NewDetector(DetectorConfig{
+		MinConfidence:           60,
+		MinConfidencePerPattern: map[PatternType]int{PatternHarami: 80},
+	})
+
+	// Bullish harami (confidence 65): filtered by the per-pattern floor of 80
+	harami := []kline.Kline{
+		makeKline(110, 110, 90, 92),
+		makeKline(95, 100, 94, 98),
+	}
+	if containsType(detector.Detect(harami), PatternHarami) {
+		t.Error("Harami with confidence 65 should be filtered by per-pattern floor 80")
+	}
+
+	// An engulfing scoring 65 is unlisted, so the global floor of 60 keeps
+	// it. The custom detector only scores engulfings 75 or 90, so the 65 one
+	// is built directly; a detected one must pass as well.
+	if !detector.keep(DetectedPattern{Type: PatternEngulfing, Direction: DirectionBullish, Confidence: 65}) {
+		t.Error("Engulfing with confidence 65 should pass the global floor 60")
+	}
+	if detector.keep(DetectedPattern{Type: PatternHarami, Direction: DirectionBullish, Confidence: 65}) {
+		t.Error("keep should apply the harami floor of 80")
+	}
+	engulfing := []kline.Kline{
+		makeKline(100, 100, 95, 96),
+		makeKline(95.5, 101.5, 95, 101),
+	}
+	if !containsType(detector.Detect(engulfing), PatternEngulfing) {
+		t.Error("Unlisted engulfing should pass the global floor 60")
+	}
+
+	// Overriding another pattern leaves the harami, now unlisted, on the
+	// global floor of 60
+	other := NewDetector(DetectorConfig{
+		MinConfidence:           60,
+		MinConfidencePerPattern: map[PatternType]int{PatternEngulfing: 80},
+	})
+	if !containsType(other.Detect(harami), PatternHarami) {
+		t.Error("Unlisted harami with confidence 65 should pass the global floor 60")
+	}
+}
+
 func TestDetector_HighEfficiencyOnlyFilter(t *testing.T) {
 	detector := NewDetector(DetectorConfig{MinConfidence: 0, HighEfficiencyOnly: true})
 
@@ -688,3 +774,327 @@ func TestDetect_FullFlowWithConfidenceFilter(t *testing.T) {
 		}
 	}
 }
+
+// talibTestKlines returns 19 klines with regular bodies followed by a doji.
+func talibTestKlines() []kline.Kline {
+	var klines []kline.Kline
+	price := 100.0
+	for i := 0; i < 19; i++ {
+		if i%2 == 0 {
+			klines = append(klines, makeKline(price, price+3, price-1, price+2))
+			price += 2
+		} else {
+			klines = append(klines, makeKline(price, price+1, price-3, price-2))
+			price -= 2
+		}
+	}
+	return append(klines, makeKline(price, price+2, price-2, price+0.01))
+}
+
+func TestDetector_EnabledTalibPatterns(t *testing.T) {
+	klines := talibTestKlines()
+
+	all := NewDetector(DetectorConfig{MinConfidence: 0}).detectTalibPatterns(klines)
+	foundDoji := false
+	for _, p := range all {
+		if p.Type == PatternDoji {
+			foundDoji = true
+		}
+	}
+	if !foundDoji {
+		t.Fatalf("expected talib Doji with all patterns enabled, got %v", all)
+	}
+
+	only := NewDetector(DetectorConfig{MinConfidence: 0, EnabledTalibPatterns: []PatternType{PatternDoji}}).detectTalibPatterns(klines)
+	if len(only) != 1 || only[0].Type != PatternDoji {
+		t.Errorf("expected only Doji, got %v", only)
+	}
+
+	none := NewDetector(DetectorConfig{MinConfidence: 0, EnabledTalibPatterns: []PatternType{}}).detectTalibPatterns(klines)
+	if len(none) != 0 {
+		t.Errorf("expected no talib patterns with empty selection, got %v", none)
+	}
+}
+
+func BenchmarkDetectTalib_All(b *testing.B) {
+	d := NewDetector(DefaultDetectorConfig())
+	klines := talibTestKlines()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.detectTalibPatterns(klines)
+	}
+}
+
+func BenchmarkDetectTalib_Reduced(b *testing.B) {
+	cfg := DefaultDetectorConfig()
+	cfg.EnabledTalibPatterns = []PatternType{PatternDoji, PatternEveningStar, PatternThreeBlack}
+	d := NewDetector(cfg)
+	klines := talibTestKlines()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.detectTalibPatterns(klines)
+	}
+}
+
+// threeInsideUp returns 10 small filler candles followed by a three inside up
+// whose second candle opens at secondOpen.
+func threeInsideUp(secondOpen float64) []kline.Kline {
+	var klines []kline.Kline
+	for i := 0; i < 10; i++ {
+		klines = append(klines, makeKline(100, 101.5, 99.5, 101))
+	}
+	return append(klines,
+		makeKline(110, 111, 91, 92),                       // Long bearish
+		makeKline(secondOpen, 93.5, 91.5, secondOpen+0.5), // Short body inside the first
+		makeKline(97, 113, 96, 112),                       // Bullish close above first open
+	)
+}
+
+func TestDetector_Detect_ThreeInsideCustomFallback(t *testing.T) {
+	// Second body touches the first close: talib's strict containment misses it
+	klines := threeInsideUp(92)
+
+	detector := NewDetector(DetectorConfig{MinConfidence: 0, CryptoMode: true})
+	if talib := detector.detectTalibPatterns(klines); containsType(talib, PatternThreeInside) {
+		t.Fatal("test setup: talib should not detect ThreeInside")
+	}
+
+	var got []DetectedPattern
+	for _, p := range detector.Detect(klines) {
+		if p.Type == PatternThreeInside {
+			got = append(got, p)
+		}
+	}
+	if len(got) != 1 || got[0].Direction != DirectionBullish || got[0].Confidence != 70 {
+		t.Errorf("expected custom bullish ThreeInside (70), got %+v", got)
+	}
+
+	// The fallback only runs in crypto mode
+	strict := NewDetector(DetectorConfig{MinConfidence: 0})
+	if containsType(strict.Detect(klines), PatternThreeInside) {
+		t.Error("custom ThreeInside should not run outside crypto mode")
+	}
+}
+
+func TestDetector_Detect_ThreeInsideTalibAuthoritative(t *testing.T) {
+	klines := threeInsideUp(92.5)
+
+	detector := NewDetector(DetectorConfig{MinConfidence: 0, CryptoMode: true})
+	if !containsType(detector.detectTalibPatterns(klines), PatternThreeInside) {
+		t.Fatal("test setup: talib should detect ThreeInside")
+	}
+	if !containsType(detector.detectCustomPatterns(klines), PatternThreeInside) {
+		t.Fatal("test setup: custom should detect ThreeInside")
+	}
+
+	var got []DetectedPattern
+	for _, p := range detector.Detect(klines) {
+		if p.Type == PatternThreeInside {
+			got = append(got, p)
+		}
+	}
+	if len(got) != 1 || got[0].Confidence != 100 {
+		t.Errorf("expected only talib ThreeInside (100), got %+v", got)
+	}
+}
+
+func containsType(patterns []DetectedPattern, pt PatternType) bool {
+	for _, p := range patterns {
+		if p.Type == pt {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetectThreeMethods(t *testing.T) {
+	rising := []kline.Kline{
+		makeKline(100, 111, 99, 110),  // Long bullish
+		makeKline(109, 110, 106, 107), // Small bearish inside
+		makeKline(107, 108, 104, 105),
+		makeKline(105, 106, 102, 103),
+		makeKline(103, 116, 102, 115), // Long bullish, new high
+	}
+	found, dir, _ := detectThreeMethods(rising)
+	if !found || dir != DirectionBullish {
+		t.Errorf("rising three methods: found=%v dir=%s", found, dir)
+	}
+	detector := NewDetector(DetectorConfig{MinConfidence: 0})
+	hasType := func(patterns []DetectedPattern, pt PatternType) bool {
+		for _, p := range patterns {
+			if p.Type == pt {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasType(detector.Detect(rising), PatternRisingThreeMethods) {
+		t.Error("Detect should report rising three methods")
+	}
+
+	falling := []kline.Kline{
+		makeKline(110, 111, 99, 100),  // Long bearish
+		makeKline(101, 104, 100, 103), // Small bullish inside
+		makeKline(103, 106, 102, 105),
+		makeKline(105, 108, 104, 107),
+		makeKline(107, 108, 94, 95), // Long bearish, new low
+	}
+	found, dir, _ = detectThreeMethods(falling)
+	if !found || dir != DirectionBearish {
+		t.Errorf("falling three methods: found=%v dir=%s", found, dir)
+	}
+	if !hasType(detector.Detect(falling), PatternFallingThreeMethods) {
+		t.Error("Detect should report falling three methods")
+	}
+
+	invalid := map[string][]kline.Kline{
+		"middle breaks first high": {
+			rising[0], rising[1], makeKline(107, 113, 104, 105), rising[3], rising[4],
+		},
+		"middle candle bullish": {
+			rising[0], rising[1], makeKline(105, 108, 104, 107), rising[3], rising[4],
+		},
+		"last closes inside first range": {
+			rising[0], rising[1], rising[2], rising[3], makeKline(103, 110.5, 102, 110),
+		},
+		"too few klines": rising[1:],
+	}
+	for name, klines := range invalid {
+		if found, _, _ := detectThreeMethods(klines); found {
+			t.Errorf("%s: unexpected three methods", name)
+		}
+	}
+}
+
+func TestDetectInsideBar(t *testing.T) {
+	klines := []kline.Kline{
+		makeKline(100, 110, 90, 105),
+		makeKline(104, 107, 95, 98), // Range inside the previous range
+	}
+	found, dir, _ := detectInsideBar(klines)
+	if !found || dir != DirectionNeutral {
+		t.Errorf("inside bar: found=%v dir=%s", found, dir)
+	}
+	if containsType(NewDetector(DetectorConfig{MinConfidence: 0}).Detect(klines), PatternInsideBar) {
+		t.Error("Detect should not report inside bar unless StructurePatterns is set")
+	}
+	detector := NewDetector(DetectorConfig{MinConfidence: 0, StructurePatterns: true})
+	if !containsType(detector.Detect(klines), PatternInsideBar) {
+		t.Error("Detect should report inside bar")
+	}
+
+	invalid := map[string][]kline.Kline{
+		"breaks previous high": {klines[0], makeKline(104, 111, 95, 98)},
+		"breaks previous low":  {klines[0], makeKline(104, 107, 89, 98)},
+		"outside bar":          {klines[0], makeKline(95, 115, 85, 112)},
+		"too few klines":       klines[1:],
+	}
+	for name, klines := range invalid {
+		if found, _, _ := detectInsideBar(klines); found {
+			t.Errorf("%s: unexpected inside bar", name)
+		}
+	}
+}
+
+func TestDetectOutsideBar(t *testing.T) {
+	prev := makeKline(100, 105, 95, 102)
+	tests := []struct {
+		name string
+		curr kline.Kline
+		dir  Direction
+		conf int
+	}{
+		{"close above previous high", makeKline(96, 110, 94, 108), DirectionBullish, 70},
+		{"close below previous low", makeKline(104, 106, 90, 92), DirectionBearish, 70},
+		{"close in upper half", makeKline(97, 110, 90, 103), DirectionBullish, 60},
+		{"close in lower half", makeKline(103, 110, 90, 97), DirectionBearish, 60},
+		{"close at midpoint", makeKline(96, 110, 90, 100), DirectionNeutral, 60},
+	}
+	for _, tt := range tests {
+		found, dir, conf := detectOutsideBar([]kline.Kline{prev, tt.curr})
+		if !found || dir != tt.dir || conf != tt.conf {
+			t.Errorf("%s: found=%v dir=%s conf=%d, want dir=%s conf=%d", tt.name, found, dir, conf, tt.dir, tt.conf)
+		}
+	}
+
+	detector := NewDetector(DetectorConfig{MinConfidence: 0, StructurePatterns: true})
+	if !containsType(detector.Detect([]kline.Kline{prev, tests[0].curr}), PatternOutsideBar) {
+		t.Error("Detect should report outside bar")
+	}
+
+	invalid := map[string][]kline.Kline{
+		"equal high":     {prev, makeKline(96, 105, 90, 104)},
+		"inside bar":     {prev, makeKline(101, 104, 97, 99)},
+		"too few klines": {prev},
+	}
+	for name, klines := range invalid {
+		if found, _, _ := detectOutsideBar(klines); found {
+			t.Errorf("%s: unexpected outside bar", name)
+		}
+	}
+}
+
+func TestDetector_RawScores(t *testing.T) {
+	bullish := threeInsideUp(101)
+	// Mirror the prices around 200 to turn it into a three inside down
+	var bearish []kline.Kline
+	for _, k := range bullish {
+		bearish = append(bearish, makeKline(200-k.Open, 200-k.Low, 200-k.High, 200-k.Close))
+	}
+
+	raw := NewDetector(DetectorConfig{MinConfidence: 0, CryptoMode: true, RawScores: true})
+	bulls, bears := 0, 0
+	for _, klines := range [][]kline.Kline{bullish, bearish} {
+		for _, p := range raw.detectTalibPatterns(klines) {
+			if p.RawScore == 0 || absInt(p.RawScore) != p.Confidence {
+				t.Errorf("%s: RawScore %d does not match confidence %d", p.Type, p.RawScore, p.Confidence)
+			}
+			switch p.Direction {
+			case DirectionBullish:
+				bulls++
+				if p.RawScore < 0 {
+					t.Errorf("bullish %s has negative RawScore %d", p.Type, p.RawScore)
+				}
+			case DirectionBearish:
+				bears++
+				if p.RawScore > 0 {
+					t.Errorf("bearish %s has positive RawScore %d", p.Type, p.RawScore)
+				}
+			}
+		}
+	}
+	if bulls == 0 || bears == 0 {
+		t.Fatalf("test setup: found %d bullish and %d bearish talib patterns, want both", bulls, bears)
+	}
+
+	// Off by default: no raw scores, so the signal JSON is unchanged
+	for _, p := range NewDetector(DetectorConfig{MinConfidence: 0, CryptoMode: true}).detectTalibPatterns(bearish) {
+		if p.RawScore != 0 {
+			t.Errorf("%s: RawScore %d without RawScores", p.Type, p.RawScore)
+		}
+	}
+}
+
+func TestDetector_TalibConfidenceScale(t *testing.T) {
+	klines := threeInsideUp(101)
+	full := NewDetector(DetectorConfig{MinConfidence: 60})
+	if talib := full.detectTalibPatterns(klines); len(talib) != 1 || talib[0].Type != PatternThreeInside || talib[0].Confidence != 100 {
+		t.Fatalf("test setup: talib patterns = %+v, want three inside at 100", talib)
+	}
+	if !containsType(full.Detect(klines), PatternThreeInside) {
+		t.Fatal("unscaled three inside should pass the 60 floor")
+	}
+
+	half := NewDetector(DetectorConfig{MinConfidence: 60, TalibConfidenceScale: 0.5})
+	talib := half.detectTalibPatterns(klines)
+	if len(talib) != 1 || talib[0].Confidence != 50 {
+		t.Fatalf("scaled talib patterns = %+v, want confidence 50", talib)
+	}
+	if containsType(half.Detect(klines), PatternThreeInside) {
+		t.Error("three inside scaled to 50 should be dropped below the 60 floor")
+	}
+
+	if got := scaleConfidence(80, 1.5); got != 100 {
+		t.Errorf("scaleConfidence(80, 1.5) = %d, want 100 (capped)", got)
+	}
+}
@@ -0,0 +1,78 @@
+package pattern
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/tail"
+)
+
+// Follow tails a history file written by another process and adds its
+// signals to h, for a read-only replica; h should be memory-only
+// (NewHistory with an empty path). Signals already in the file are loaded
+// silently; onNew (optional) is called for each signal appended afterwards.
+// After a compaction the file is re-read, skipping signals already held or
+// detected before the newest one seen.
+// It polls every interval until ctx is done.
+func (h *History) Follow(ctx context.Context, filePath string, interval time.Duration, onNew func(Signal)) {
+	f := &tail.File{Path: filePath}
+	var latest time.Time
+
+	poll := func(onNew func(Signal)) {
+		lines, reset, err := f.ReadNew()
+		if err != nil {
+			log.Printf("pattern history follow %s failed: %v", filePath, err)
+			return
+		}
+		for _, line := range lines {
+			var sig Signal
+			if err := json.Unmarshal(line, &sig); err != nil {
+				continue
+			}
+			if reset && (sig.DetectedAt.Before(latest) || h.has(sig.ID)) {
+				continue
+			}
+			if sig.DetectedAt.After(latest) {
+				latest = sig.DetectedAt
+			}
+			if err := h.Add(sig); err != nil {
+				continue // e.g. a duplicate, already added and published
+			}
+			if onNew != nil {
+				onNew(sig)
+			}
+		}
+	}
+
+	poll(nil)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			poll(onNew)
+		}
+	}
+}
+
+// has reports whether a signal with the given ID is in memory.
+func (h *History) has(id string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.hasLocked(id)
+}
+
+// hasLocked is has for callers holding h.mu.
+func (h *History) hasLocked(id string) bool {
+	for i := len(h.signals) - 1; i >= 0; i-- {
+		if h.signals[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
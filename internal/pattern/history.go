@@ -3,9 +3,11 @@ package pattern
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,6 +15,11 @@ import (
 // History stores pattern signal history.
 // Storage strategy: memory-first, optional persistence via file.
 type History struct {
+	// MaxAge drops signals detected longer ago than this, in addition to
+	// the maxSize count cap. Zero keeps count-only retention.
+	// Set before the history is shared between goroutines.
+	MaxAge time.Duration
+
 	mu          sync.RWMutex
 	signals     []Signal
 	maxSize     int
@@ -20,11 +27,28 @@ type History struct {
 	persistMode bool
 	file        *os.File
 	fileLines   int // 跟踪文件行数，用于截断判断
+
+	// 写入熔断：连续失败达到阈值后降级为仅内存模式，定期重试恢复
+	writeFailures int
+	degraded      bool
+	lastRetry     time.Time
 }
 
 // DefaultPatternHistoryMax is the default maximum number of pattern signals to keep.
 const DefaultPatternHistoryMax = 1000
 
+const (
+	// writeFailureThreshold is the number of consecutive write failures
+	// after which persistence is suspended (memory-only mode).
+	writeFailureThreshold = 5
+	// writeRetryInterval is how often a degraded history retries persisting.
+	writeRetryInterval = time.Minute
+)
+
+// ErrDuplicate is returned by Add when a signal with the same ID is
+// already in memory; the signal is dropped.
+var ErrDuplicate = errors.New("pattern: duplicate signal")
+
 // NewHistory creates a new history store.
 // filePath: empty string for memory-only mode, non-empty to enable persistence.
 func NewHistory(filePath string, maxSize int) (*History, error) {
@@ -97,17 +121,31 @@ func (h *History) load() error {
 
 // Add adds a signal to history.
 // If persistence is enabled, writes to file synchronously.
+// A signal whose ID is already in memory is dropped with ErrDuplicate.
 func (h *History) Add(sig Signal) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	if sig.ID != "" && h.hasLocked(sig.ID) {
+		return ErrDuplicate
+	}
+
 	// Add to memory
 	h.signals = append(h.signals, sig)
 
-	// Maintain max size
+	// Maintain max size and age
 	if len(h.signals) > h.maxSize {
 		h.signals = h.signals[len(h.signals)-h.maxSize:]
 	}
+	h.pruneExpired(time.Now())
+
+	// Degraded: memory-only until a periodic retry succeeds
+	if h.degraded {
+		if time.Since(h.lastRetry) >= writeRetryInterval {
+			h.retryPersistence()
+		}
+		return nil
+	}
 
 	// Persist if enabled
 	if h.persistMode && h.file != nil {
@@ -116,8 +154,10 @@ func (h *History) Add(sig Signal) error {
 			return err
 		}
 		if _, err := h.file.Write(append(data, '\n')); err != nil {
+			h.recordWriteFailure(err)
 			return err
 		}
+		h.writeFailures = 0
 		h.fileLines++
 
 		// 每 100 条检查一次，文件行数超过 maxSize*2 时触发截断
@@ -135,6 +175,76 @@ func (h *History) Add(sig Signal) error {
 	return nil
 }
 
+// Prune drops signals older than MaxAge and returns how many were removed.
+// If persistence is enabled and anything was removed, the file is compacted.
+// It is a no-op when MaxAge is zero.
+func (h *History) Prune() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	removed := h.pruneExpired(time.Now())
+	if removed > 0 && h.persistMode && h.file != nil && !h.degraded {
+		if err := h.compact(); err != nil {
+			log.Printf("WARN: pattern history compact failed: %v", err)
+		}
+	}
+	return removed
+}
+
+// pruneExpired removes signals detected before now-MaxAge, keeping order.
+// Must be called with h.mu held.
+func (h *History) pruneExpired(now time.Time) int {
+	if h.MaxAge <= 0 {
+		return 0
+	}
+	cutoff := now.Add(-h.MaxAge)
+	kept := h.signals[:0]
+	for _, sig := range h.signals {
+		if !sig.DetectedAt.Before(cutoff) {
+			kept = append(kept, sig)
+		}
+	}
+	removed := len(h.signals) - len(kept)
+	// Clear the tail so dropped signals can be collected
+	for i := len(kept); i < len(h.signals); i++ {
+		h.signals[i] = Signal{}
+	}
+	h.signals = kept
+	return removed
+}
+
+// recordWriteFailure counts a failed write and trips the circuit breaker
+// after writeFailureThreshold consecutive failures.
+// Must be called with h.mu held.
+func (h *History) recordWriteFailure(err error) {
+	h.writeFailures++
+	if h.writeFailures >= writeFailureThreshold && !h.degraded {
+		h.degraded = true
+		h.lastRetry = time.Now()
+		log.Printf("WARN: pattern history: %d consecutive write failures (last: %v), switching to memory-only mode", h.writeFailures, err)
+	}
+}
+
+// retryPersistence rewrites the in-memory window to the file. On success the
+// breaker is reset, so signals recorded while degraded are persisted too.
+// Must be called with h.mu held.
+func (h *History) retryPersistence() {
+	h.lastRetry = time.Now()
+	if err := h.compact(); err != nil {
+		return
+	}
+	h.degraded = false
+	h.writeFailures = 0
+	log.Printf("pattern history: persistence recovered, %d signals written", h.fileLines)
+}
+
+// Degraded reports whether persistence is suspended after repeated write failures.
+func (h *History) Degraded() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.degraded
+}
+
 // Recent returns the most recent signals.
 func (h *History) Recent(limit int) []Signal {
 	h.mu.RLock()
@@ -163,15 +273,34 @@ type QueryOptions struct {
 	Pattern   PatternType
 	Direction Direction
 	Limit     int
+	Offset    int // Skips this many matches (after filtering, before limit); negative is treated as 0
 	Since     time.Time
+
+	// MinRank keeps patterns whose EfficiencyRank is at or above this
+	// rank (e.g. "B+", see ParseEfficiencyRank). Empty keeps all.
+	MinRank string
 }
 
 // Query queries signals with filtering options.
+// Results are ordered newest first, so offsets are stable while no new signals arrive.
 func (h *History) Query(opts QueryOptions) []Signal {
+	result, _ := h.QueryWithTotal(opts)
+	return result
+}
+
+// QueryWithTotal is like Query but also returns the total number of matches
+// before offset and limit are applied.
+func (h *History) QueryWithTotal(opts QueryOptions) ([]Signal, int) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
 	var result []Signal
+	total := 0
 
 	// Iterate from newest to oldest
 	for i := len(h.signals) - 1; i >= 0; i-- {
@@ -190,15 +319,21 @@ func (h *History) Query(opts QueryOptions) []Signal {
 		if !opts.Since.IsZero() && sig.DetectedAt.Before(opts.Since) {
 			continue
 		}
+		if opts.MinRank != "" && !rankAtLeast(sig.EfficiencyRank, opts.MinRank) {
+			continue
+		}
 
-		result = append(result, sig)
-
+		total++
+		if total <= offset {
+			continue
+		}
 		if opts.Limit > 0 && len(result) >= opts.Limit {
-			break
+			continue
 		}
+		result = append(result, sig)
 	}
 
-	return result
+	return result, total
 }
 
 // IsPersistent returns whether persistence is enabled.
@@ -213,23 +348,50 @@ func (h *History) Count() int {
 	return len(h.signals)
 }
 
+// Clear removes all signals from memory and truncates the history file.
+// It returns how many signals were removed.
+func (h *History) Clear() (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := len(h.signals)
+	h.signals = make([]Signal, 0, h.maxSize)
+	if !h.persistMode {
+		return n, nil
+	}
+	h.fileLines = 0
+	if h.file != nil {
+		return n, h.file.Truncate(0)
+	}
+	if err := os.Truncate(h.filePath, 0); err != nil && !os.IsNotExist(err) {
+		return n, err
+	}
+	return n, nil
+}
+
 // Close closes the history file if open.
 func (h *History) Close() error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	// Last chance to persist signals recorded while degraded
+	if h.degraded {
+		h.retryPersistence()
+	}
+
 	if h.file != nil {
 		return h.file.Close()
 	}
 	return nil
 }
 
-// compact 截断历史文件，只保留最新的 maxSize 条记录
+// compact 截断历史文件，只保留最新的 maxSize 条记录（设置 MaxAge 时同时丢弃过期记录）
 // 参考 internal/signal/history.go 的实现
 func (h *History) compact() error {
 	if !h.persistMode || h.filePath == "" {
 		return nil
 	}
+	h.pruneExpired(time.Now())
 
 	// 保存旧文件句柄，以便失败时恢复
 	oldFile := h.file
@@ -338,3 +500,54 @@ func (h *History) QueryBySymbolAndTime(symbol string, refTime time.Time, window
 
 	return result
 }
+
+// PatternSummary aggregates detections of one pattern type.
+type PatternSummary struct {
+	Pattern     PatternType `json:"pattern"`
+	PatternCN   string      `json:"pattern_cn"`
+	Count       int         `json:"count"`             // Detections in the window
+	SymbolCount int         `json:"symbol_count"`      // Distinct symbols
+	Symbols     []string    `json:"symbols"`           // Sorted alphabetically
+	Aliases     []string    `json:"aliases,omitempty"` // Display names of Symbols, set by httpapi when aliases are configured
+}
+
+// Summary aggregates signals detected within the last window by pattern
+// type, sorted by count descending (ties by pattern name).
+func (h *History) Summary(window time.Duration) []PatternSummary {
+	since := time.Now().Add(-window)
+
+	h.mu.RLock()
+	byType := make(map[PatternType]*PatternSummary)
+	seen := make(map[PatternType]map[string]struct{})
+	for _, sig := range h.signals {
+		if sig.DetectedAt.Before(since) {
+			continue
+		}
+		ps, ok := byType[sig.Pattern]
+		if !ok {
+			ps = &PatternSummary{Pattern: sig.Pattern, PatternCN: PatternNames[sig.Pattern]}
+			byType[sig.Pattern] = ps
+			seen[sig.Pattern] = make(map[string]struct{})
+		}
+		ps.Count++
+		if _, dup := seen[sig.Pattern][sig.Symbol]; !dup {
+			seen[sig.Pattern][sig.Symbol] = struct{}{}
+			ps.Symbols = append(ps.Symbols, sig.Symbol)
+		}
+	}
+	h.mu.RUnlock()
+
+	result := make([]PatternSummary, 0, len(byType))
+	for _, ps := range byType {
+		sort.Strings(ps.Symbols)
+		ps.SymbolCount = len(ps.Symbols)
+		result = append(result, *ps)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Pattern < result[j].Pattern
+	})
+	return result
+}
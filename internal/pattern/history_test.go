@@ -1,6 +1,9 @@
 package pattern
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -62,6 +65,53 @@ func TestHistory_MaxSize(t *testing.T) {
 	}
 }
 
+func TestHistory_Duplicate(t *testing.T) {
+	h, _ := NewHistory("", 100)
+
+	sig := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, time.Now())
+	if err := h.Add(sig); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := h.Add(sig); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("second Add error = %v, want ErrDuplicate", err)
+	}
+
+	if h.Count() != 1 {
+		t.Errorf("Count = %d, want 1", h.Count())
+	}
+}
+
+func TestHistory_Clear(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := NewHistory(filePath, 100)
+	if err != nil {
+		t.Fatalf("NewHistory failed: %v", err)
+	}
+	defer h.Close()
+
+	klineTime := time.Now()
+	h.Add(NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime))
+	h.Add(NewSignal("ETHUSDT", PatternHammer, DirectionBullish, 75, klineTime))
+
+	if n, err := h.Clear(); err != nil || n != 2 {
+		t.Fatalf("Clear = %d, %v; want 2, nil", n, err)
+	}
+	if h.Count() != 0 {
+		t.Errorf("Count = %d, want 0", h.Count())
+	}
+
+	// Appends continue at the start of the truncated file
+	h.Add(NewSignal("SOLUSDT", PatternHammer, DirectionBullish, 75, klineTime))
+	h2, err := NewHistory(filePath, 100)
+	if err != nil {
+		t.Fatalf("NewHistory (reload) failed: %v", err)
+	}
+	defer h2.Close()
+	if h2.Count() != 1 {
+		t.Errorf("Count after reload = %d, want 1", h2.Count())
+	}
+}
+
 func TestHistory_Query(t *testing.T) {
 	h, _ := NewHistory("", 100)
 
@@ -95,6 +145,41 @@ func TestHistory_Query(t *testing.T) {
 	}
 }
 
+func TestHistory_QueryOffset(t *testing.T) {
+	h, _ := NewHistory("", 100)
+
+	klineTime := time.Now()
+	for i := 0; i < 5; i++ {
+		sig := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime.Add(time.Duration(i)*time.Minute))
+		h.Add(sig)
+	}
+	h.Add(NewSignal("ETHUSDT", PatternHammer, DirectionBullish, 75, klineTime))
+
+	// Offset applies after filtering, results newest first
+	results, total := h.QueryWithTotal(QueryOptions{Symbol: "BTCUSDT", Offset: 1, Limit: 2})
+	if total != 5 {
+		t.Errorf("total = %d, want 5", total)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if !results[0].KlineTime.Equal(klineTime.Add(3 * time.Minute)) {
+		t.Errorf("first result kline time = %v, want %v", results[0].KlineTime, klineTime.Add(3*time.Minute))
+	}
+
+	// Negative offset is treated as 0
+	results = h.Query(QueryOptions{Symbol: "BTCUSDT", Offset: -1, Limit: 1})
+	if len(results) != 1 || !results[0].KlineTime.Equal(klineTime.Add(4*time.Minute)) {
+		t.Errorf("negative offset should return the newest signal, got %v", results)
+	}
+
+	// Offset past the end
+	results, total = h.QueryWithTotal(QueryOptions{Offset: 10})
+	if len(results) != 0 || total != 6 {
+		t.Errorf("got %d results and total %d, want 0 and 6", len(results), total)
+	}
+}
+
 func TestHistory_Persistence(t *testing.T) {
 	// Create temp directory
 	tmpDir, err := os.MkdirTemp("", "pattern_history_test")
@@ -478,7 +563,7 @@ func TestHistory_FileLineTracking(t *testing.T) {
 	// 写入 50 条记录
 	klineTime := time.Now()
 	for i := 0; i < 50; i++ {
-		sig := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime)
+		sig := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime.Add(time.Duration(i)*time.Minute))
 		h.Add(sig)
 	}
 
@@ -500,3 +585,239 @@ func TestHistory_FileLineTracking(t *testing.T) {
 		t.Errorf("Reloaded fileLines = %d, want 50", h2.fileLines)
 	}
 }
+
+func TestHistory_WriteCircuitBreaker(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	h, err := NewHistory(filePath, 100)
+	if err != nil {
+		t.Fatalf("NewHistory failed: %v", err)
+	}
+
+	// 关闭文件句柄，模拟写入失败
+	h.file.Close()
+
+	klineTime := time.Now()
+	for i := 0; i < writeFailureThreshold; i++ {
+		if err := h.Add(NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime.Add(time.Duration(i)*time.Minute))); err == nil {
+			t.Fatalf("Add #%d: expected write error", i+1)
+		}
+	}
+	if !h.Degraded() {
+		t.Fatal("Expected degraded after repeated write failures")
+	}
+
+	// 降级后仅写内存，不再返回错误
+	if err := h.Add(NewSignal("ETHUSDT", PatternHammer, DirectionBullish, 75, klineTime)); err != nil {
+		t.Errorf("Add while degraded: unexpected error %v", err)
+	}
+	if h.Count() != writeFailureThreshold+1 {
+		t.Errorf("Count = %d, want %d", h.Count(), writeFailureThreshold+1)
+	}
+
+	// 重试间隔已过，下一次写入触发恢复
+	h.lastRetry = time.Now().Add(-writeRetryInterval)
+	if err := h.Add(NewSignal("SOLUSDT", PatternHammer, DirectionBullish, 75, klineTime)); err != nil {
+		t.Errorf("Add after retry: unexpected error %v", err)
+	}
+	if h.Degraded() {
+		t.Fatal("Expected recovery after retry")
+	}
+	h.Close()
+
+	h2, err := NewHistory(filePath, 100)
+	if err != nil {
+		t.Fatalf("NewHistory (reload) failed: %v", err)
+	}
+	defer h2.Close()
+
+	if h2.Count() != writeFailureThreshold+2 {
+		t.Errorf("Reloaded count = %d, want %d", h2.Count(), writeFailureThreshold+2)
+	}
+}
+
+func TestHistory_MaxAge(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "history.jsonl")
+
+	h, err := NewHistory(filePath, 100)
+	if err != nil {
+		t.Fatalf("NewHistory failed: %v", err)
+	}
+	h.MaxAge = 7 * 24 * time.Hour
+
+	now := time.Now()
+	old := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, now.Add(-8*24*time.Hour))
+	old.DetectedAt = now.Add(-8 * 24 * time.Hour)
+	recent := NewSignal("ETHUSDT", PatternEngulfing, DirectionBearish, 80, now.Add(-time.Hour))
+	recent.DetectedAt = now.Add(-time.Hour)
+
+	if err := h.Add(old); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if h.Count() != 0 {
+		t.Errorf("Count after adding expired signal = %d, want 0", h.Count())
+	}
+	if err := h.Add(recent); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if got := h.Recent(10); len(got) != 1 || got[0].Symbol != "ETHUSDT" {
+		t.Fatalf("Recent = %+v, want only ETHUSDT", got)
+	}
+
+	// Signals that expire while held are removed by Prune, and the file follows
+	h.MaxAge = 30 * time.Minute
+	if removed := h.Prune(); removed != 1 {
+		t.Errorf("Prune removed %d, want 1", removed)
+	}
+	h.Close()
+
+	h2, err := NewHistory(filePath, 100)
+	if err != nil {
+		t.Fatalf("NewHistory (reload) failed: %v", err)
+	}
+	defer h2.Close()
+	if h2.Count() != 0 {
+		t.Errorf("Reloaded count = %d, want 0 after compaction", h2.Count())
+	}
+}
+
+func TestHistory_MaxAgeZeroKeepsAll(t *testing.T) {
+	h, _ := NewHistory("", 10)
+
+	sig := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, time.Now())
+	sig.DetectedAt = time.Now().Add(-365 * 24 * time.Hour)
+	h.Add(sig)
+
+	if removed := h.Prune(); removed != 0 {
+		t.Errorf("Prune removed %d, want 0", removed)
+	}
+	if h.Count() != 1 {
+		t.Errorf("Count = %d, want 1", h.Count())
+	}
+}
+
+func TestHistory_Summary(t *testing.T) {
+	h, _ := NewHistory("", 100)
+	now := time.Now()
+
+	add := func(symbol string, p PatternType, age time.Duration) {
+		sig := NewSignal(symbol, p, DirectionBullish, 70, now.Add(-age))
+		sig.DetectedAt = now.Add(-age)
+		if err := h.Add(sig); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	add("BTCUSDT", PatternHammer, 2*time.Hour) // Outside the window
+	add("ETHUSDT", PatternHammer, 50*time.Minute)
+	add("BTCUSDT", PatternDoji, 40*time.Minute)
+	add("SOLUSDT", PatternDoji, 30*time.Minute)
+	add("BTCUSDT", PatternDoji, 20*time.Minute)
+
+	got := h.Summary(time.Hour)
+	if len(got) != 2 {
+		t.Fatalf("Summary = %+v, want 2 pattern types", got)
+	}
+
+	doji := got[0]
+	if doji.Pattern != PatternDoji || doji.Count != 3 || doji.SymbolCount != 2 {
+		t.Errorf("first entry = %+v, want doji count=3 symbol_count=2", doji)
+	}
+	if len(doji.Symbols) != 2 || doji.Symbols[0] != "BTCUSDT" || doji.Symbols[1] != "SOLUSDT" {
+		t.Errorf("doji symbols = %v, want [BTCUSDT SOLUSDT]", doji.Symbols)
+	}
+
+	hammer := got[1]
+	if hammer.Pattern != PatternHammer || hammer.Count != 1 || hammer.SymbolCount != 1 || hammer.Symbols[0] != "ETHUSDT" {
+		t.Errorf("second entry = %+v, want hammer count=1 on ETHUSDT", hammer)
+	}
+
+	if got := h.Summary(10 * time.Minute); len(got) != 0 {
+		t.Errorf("Summary(10m) = %+v, want empty", got)
+	}
+}
+
+func TestHistory_QueryMinRank(t *testing.T) {
+	h, _ := NewHistory("", 100)
+	now := time.Now()
+	for i, pt := range []PatternType{PatternKicking, PatternEngulfing, PatternHammer, PatternHangingMan, PatternHarami, PatternDoji} {
+		_ = h.Add(NewSignal("BTCUSDT", pt, DirectionBullish, 70, now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	got, total := h.QueryWithTotal(QueryOptions{MinRank: "B+"})
+	if total != 3 {
+		t.Fatalf("total = %d, want 3 (A+, A, B+)", total)
+	}
+	for _, sig := range got {
+		switch sig.Pattern {
+		case PatternKicking, PatternEngulfing, PatternHammer:
+		default:
+			t.Errorf("%s (rank %s) passed min rank B+", sig.Pattern, sig.EfficiencyRank)
+		}
+	}
+
+	if _, total := h.QueryWithTotal(QueryOptions{MinRank: "J-"}); total != 6 {
+		t.Errorf("min rank J-: total = %d, want all 6", total)
+	}
+	if _, total := h.QueryWithTotal(QueryOptions{MinRank: "A+"}); total != 1 {
+		t.Errorf("min rank A+: total = %d, want 1", total)
+	}
+}
+
+func TestParseEfficiencyRank(t *testing.T) {
+	for in, want := range map[string]string{"b+": "B+", " A ": "A", "j-": "J-"} {
+		if got, err := ParseEfficiencyRank(in); err != nil || got != want {
+			t.Errorf("ParseEfficiencyRank(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "K", "B*", "A++", "+"} {
+		if _, err := ParseEfficiencyRank(in); err == nil {
+			t.Errorf("ParseEfficiencyRank(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestHistory_FollowSkipsDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now()
+	first := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 70, now)
+	second := NewSignal("ETHUSDT", PatternEngulfing, DirectionBearish, 75, now.Add(time.Minute))
+	appendLines := func(sigs ...Signal) {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer f.Close()
+		for _, sig := range sigs {
+			data, _ := json.Marshal(sig)
+			_, _ = f.Write(append(data, '\n'))
+		}
+	}
+	appendLines(first)
+
+	h, _ := NewHistory("", 100)
+	published := make(chan Signal, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Follow(ctx, path, 10*time.Millisecond, func(sig Signal) { published <- sig })
+
+	time.Sleep(50 * time.Millisecond)
+	appendLines(first, second) // first is appended again, e.g. by a retried write
+
+	select {
+	case sig := <-published:
+		if sig.ID != second.ID {
+			t.Errorf("published %s, want only the new %s", sig.Symbol, second.Symbol)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("new signal not published")
+	}
+	select {
+	case sig := <-published:
+		t.Errorf("unexpected publish of %s", sig.Symbol)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if h.Count() != 2 {
+		t.Errorf("Count = %d, want 2", h.Count())
+	}
+}
@@ -0,0 +1,47 @@
+package pattern
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Detection sensitivity presets for PresetConfig.
+const (
+	// PresetConservative reports only high-efficiency (A/B rank) patterns
+	// at confidence 75+, with strict gaps and near-perfect dojis.
+	PresetConservative = "conservative"
+	// PresetBalanced is DefaultDetectorConfig.
+	PresetBalanced = "balanced"
+	// PresetAggressive reports every pattern at confidence 40+ and accepts
+	// dojis with bodies up to 15% of the range.
+	PresetAggressive = "aggressive"
+)
+
+// ParsePreset parses a preset name; empty means PresetBalanced.
+func ParsePreset(s string) (string, error) {
+	switch name := strings.ToLower(strings.TrimSpace(s)); name {
+	case "":
+		return PresetBalanced, nil
+	case PresetConservative, PresetBalanced, PresetAggressive:
+		return name, nil
+	default:
+		return "", fmt.Errorf("unknown detector preset %q", s)
+	}
+}
+
+// PresetConfig returns the detector configuration of a preset. Unknown
+// names get PresetBalanced; validate user input with ParsePreset first.
+func PresetConfig(name string) DetectorConfig {
+	cfg := DefaultDetectorConfig()
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case PresetConservative:
+		cfg.MinConfidence = 75
+		cfg.HighEfficiencyOnly = true
+		cfg.CryptoMode = false
+		cfg.DojiBodyRatio = 0.05
+	case PresetAggressive:
+		cfg.MinConfidence = 40
+		cfg.DojiBodyRatio = 0.15
+	}
+	return cfg
+}
@@ -0,0 +1,54 @@
+package pattern
+
+import (
+	"testing"
+
+	"example.com/binance-pivot-monitor/internal/kline"
+)
+
+func TestPresetConfig_DistinctFiltering(t *testing.T) {
+	// Downtrend, bullish engulfing bar, then a 12%-body dragonfly
+	klines := []kline.Kline{
+		makeKline(110, 112, 100, 102),
+		makeKline(105, 106, 95, 96),
+		makeKline(100, 105, 95, 96),
+		makeKline(95, 110, 94, 108),
+		makeKline(107.8, 109, 99, 109),
+	}
+	detected := func(preset string) map[PatternType]bool {
+		got := make(map[PatternType]bool)
+		for _, p := range NewDetector(PresetConfig(preset)).Detect(klines) {
+			got[p.Type] = true
+		}
+		return got
+	}
+
+	conservative := detected(PresetConservative)
+	balanced := detected(PresetBalanced)
+	aggressive := detected(PresetAggressive)
+	if len(conservative) == 0 || len(conservative) >= len(balanced) || len(balanced) >= len(aggressive) {
+		t.Fatalf("want conservative < balanced < aggressive, got %v / %v / %v", conservative, balanced, aggressive)
+	}
+	for pt := range conservative {
+		if !IsHighEfficiency(pt) {
+			t.Errorf("conservative reported low-efficiency pattern %s", pt)
+		}
+	}
+	if balanced[PatternDragonflyDoji] || !aggressive[PatternDragonflyDoji] {
+		t.Error("12% body should be a dragonfly doji only in the aggressive preset")
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	for in, want := range map[string]string{"": PresetBalanced, " Aggressive ": PresetAggressive, "conservative": PresetConservative} {
+		if got, err := ParsePreset(in); err != nil || got != want {
+			t.Errorf("ParsePreset(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePreset("yolo"); err == nil {
+		t.Error("ParsePreset(yolo) should fail")
+	}
+	if PresetConfig(PresetBalanced).MinConfidence != DefaultDetectorConfig().MinConfidence {
+		t.Error("balanced preset should match DefaultDetectorConfig")
+	}
+}
@@ -21,6 +21,22 @@ type Signal struct {
 	IsEstimated    bool        `json:"is_estimated"`    // Whether stats are estimated
 	KlineTime      time.Time   `json:"kline_time"`      // Kline close time
 	DetectedAt     time.Time   `json:"detected_at"`
+
+	// Timeframe is the kline interval the pattern was detected on, e.g. "5m".
+	Timeframe string `json:"timeframe,omitempty"`
+
+	// AtPivot names the pivot level the pattern formed at, e.g. "S3", and
+	// AtPivotPeriod its period ("1d" or "1w"). Empty when not near a level.
+	AtPivot       string `json:"at_pivot,omitempty"`
+	AtPivotPeriod string `json:"at_pivot_period,omitempty"`
+
+	// Alias is the symbol's display name, set by httpapi when aliases are configured.
+	Alias string `json:"alias,omitempty"`
+
+	// RawScore is talib's signed output (-100..100, negative = bearish)
+	// behind Confidence, set when DetectorConfig.RawScores is on. Zero for
+	// custom patterns.
+	RawScore int `json:"raw_score,omitempty"`
 }
 
 // NewSignal creates a new pattern signal with statistics populated.
@@ -50,11 +66,23 @@ func generateID(symbol string, pattern PatternType, klineTime time.Time) string
 	return fmt.Sprintf("%d-%s-%s", klineTime.UnixNano(), symbol, pattern)
 }
 
+// SetTimeframe tags the signal with the kline interval it was detected on.
+// The timeframe is appended to the ID so the same pattern closing on several
+// timeframes at once yields distinct signals.
+func (s *Signal) SetTimeframe(timeframe string) {
+	s.Timeframe = timeframe
+	s.ID = generateID(s.Symbol, s.Pattern, s.KlineTime)
+	if timeframe != "" {
+		s.ID += "-" + timeframe
+	}
+}
+
 // DetectedPattern represents a pattern detected by the detector.
 type DetectedPattern struct {
 	Type       PatternType
 	Direction  Direction
 	Confidence int // 0-100, based on talib-cdl-go return value
+	RawScore   int // Signed talib-cdl-go return value with DetectorConfig.RawScores; 0 otherwise
 }
 
 // IsValid returns true if the signal has all required fields.
@@ -74,6 +74,30 @@ func TestGenerateID(t *testing.T) {
 	}
 }
 
+func TestSignal_SetTimeframe(t *testing.T) {
+	klineTime := time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)
+
+	sig5m := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime)
+	sig5m.SetTimeframe("5m")
+	sig15m := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime)
+	sig15m.SetTimeframe("15m")
+
+	if sig5m.Timeframe != "5m" {
+		t.Errorf("Timeframe = %q, want 5m", sig5m.Timeframe)
+	}
+	if sig5m.ID == sig15m.ID {
+		t.Error("Different timeframes should produce different IDs")
+	}
+
+	// Empty timeframe keeps the plain ID
+	plain := NewSignal("BTCUSDT", PatternHammer, DirectionBullish, 75, klineTime)
+	id := plain.ID
+	plain.SetTimeframe("")
+	if plain.ID != id {
+		t.Errorf("ID = %q, want %q", plain.ID, id)
+	}
+}
+
 func TestSignal_IsValid(t *testing.T) {
 	klineTime := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)
 
@@ -160,6 +184,49 @@ func TestSignal_IsValid(t *testing.T) {
 	}
 }
 
+func TestAllPatternStats(t *testing.T) {
+	all := AllPatternStats()
+	if len(all) != len(PatternStatsMap) {
+		t.Fatalf("len = %d, want %d", len(all), len(PatternStatsMap))
+	}
+
+	for i := 1; i < len(all); i++ {
+		prev, cur := all[i-1], all[i]
+		pr, cr := rankOrder(prev.EfficiencyRank), rankOrder(cur.EfficiencyRank)
+		if pr > cr || (pr == cr && prev.Type > cur.Type) {
+			t.Errorf("not sorted at %d: %s(%s) before %s(%s)", i, prev.Type, prev.EfficiencyRank, cur.Type, cur.EfficiencyRank)
+		}
+	}
+	if all[0].EfficiencyRank != "A+" {
+		t.Errorf("first rank = %s, want A+", all[0].EfficiencyRank)
+	}
+
+	for _, info := range all {
+		if info.NameCN == "" {
+			t.Errorf("%s: missing Chinese name", info.Type)
+		}
+		if info.HighEfficiency != IsHighEfficiency(info.Type) {
+			t.Errorf("%s: HighEfficiency mismatch", info.Type)
+		}
+	}
+
+	// Modifying the result must not touch the live map
+	orig := PatternStatsMap[all[0].Type].UpPercent
+	all[0].UpPercent = -1
+	if PatternStatsMap[all[0].Type].UpPercent != orig {
+		t.Error("AllPatternStats returned live data")
+	}
+}
+
+func TestRankOrder(t *testing.T) {
+	ranks := []string{"A+", "A", "A-", "B+", "B", "J-", ""}
+	for i := 1; i < len(ranks); i++ {
+		if rankOrder(ranks[i-1]) >= rankOrder(ranks[i]) {
+			t.Errorf("rankOrder(%q) should be < rankOrder(%q)", ranks[i-1], ranks[i])
+		}
+	}
+}
+
 // Property test: Signal completeness
 func TestProperty_SignalCompleteness(t *testing.T) {
 	properties := gopter.NewProperties(nil)
@@ -1,5 +1,11 @@
 package pattern
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // PatternStats holds statistical data for a pattern.
 type PatternStats struct {
 	UpPercent      int    // Historical up probability
@@ -50,6 +56,14 @@ var PatternStatsMap = map[PatternType]PatternStats{
 	PatternKicking:         {69, 31, "A+", "J", "custom", "feedroll.com", false},
 	PatternDragonflyDoji:   {57, 43, "C+", "E", "custom", "fivehundred.co", false},
 	PatternGravestoneDoji:  {43, 57, "C+", "E", "custom", "fivehundred.co", false},
+
+	// Continuation patterns (custom)
+	PatternRisingThreeMethods:  {74, 26, "B", "J", "custom", "estimated", true},
+	PatternFallingThreeMethods: {29, 71, "B", "J", "custom", "estimated", true},
+
+	// Structure patterns (custom)
+	PatternInsideBar:  {50, 50, "E", "A", "custom", "estimated", true},
+	PatternOutsideBar: {52, 48, "D", "C", "custom", "estimated", true},
 }
 
 // IsHighEfficiency returns true if the pattern has efficiency rank A or B.
@@ -64,6 +78,26 @@ func IsHighEfficiency(pt PatternType) bool {
 	return stats.EfficiencyRank[0] == 'A' || stats.EfficiencyRank[0] == 'B'
 }
 
+// rankAtLeast reports whether efficiency rank a is at or above rank b
+// (see rankOrder). Unknown ranks sort last.
+func rankAtLeast(a, b string) bool {
+	return rankOrder(a) <= rankOrder(b)
+}
+
+// ParseEfficiencyRank normalizes an efficiency rank such as "b+" to "B+".
+// Ranks run from A+ to J-.
+func ParseEfficiencyRank(s string) (string, error) {
+	rank := strings.ToUpper(strings.TrimSpace(s))
+	valid := len(rank) >= 1 && len(rank) <= 2 && rank[0] >= 'A' && rank[0] <= 'J'
+	if valid && len(rank) == 2 {
+		valid = rank[1] == '+' || rank[1] == '-'
+	}
+	if !valid {
+		return "", fmt.Errorf("unknown efficiency rank %q (A+ to J-)", s)
+	}
+	return rank, nil
+}
+
 // GetHighEfficiencyPatterns returns all patterns with efficiency rank A or B.
 func GetHighEfficiencyPatterns() []PatternType {
 	var result []PatternType
@@ -80,3 +114,62 @@ func GetStats(pt PatternType) (PatternStats, bool) {
 	stats, ok := PatternStatsMap[pt]
 	return stats, ok
 }
+
+// PatternTypeInfo describes a pattern type and its statistics, for API listings.
+type PatternTypeInfo struct {
+	Type           PatternType `json:"type"`
+	NameCN         string      `json:"name_cn"`
+	UpPercent      int         `json:"up_percent"`
+	DownPercent    int         `json:"down_percent"`
+	EfficiencyRank string      `json:"efficiency_rank"`
+	CommonRank     string      `json:"common_rank"`
+	Source         string      `json:"source"`
+	StatsSource    string      `json:"stats_source"`
+	IsEstimated    bool        `json:"is_estimated"`
+	HighEfficiency bool        `json:"high_efficiency"`
+}
+
+// AllPatternStats returns a copy of PatternStatsMap as a slice, sorted by
+// efficiency rank (A+ first) and then by pattern type.
+func AllPatternStats() []PatternTypeInfo {
+	out := make([]PatternTypeInfo, 0, len(PatternStatsMap))
+	for pt, st := range PatternStatsMap {
+		out = append(out, PatternTypeInfo{
+			Type:           pt,
+			NameCN:         PatternNames[pt],
+			UpPercent:      st.UpPercent,
+			DownPercent:    st.DownPercent,
+			EfficiencyRank: st.EfficiencyRank,
+			CommonRank:     st.CommonRank,
+			Source:         st.Source,
+			StatsSource:    st.StatsSource,
+			IsEstimated:    st.IsEstimated,
+			HighEfficiency: IsHighEfficiency(pt),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ri, rj := rankOrder(out[i].EfficiencyRank), rankOrder(out[j].EfficiencyRank)
+		if ri != rj {
+			return ri < rj
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+// rankOrder maps a rank like "A+", "B" or "J-" to a sortable value (lower is better).
+// Unknown ranks sort last.
+func rankOrder(rank string) int {
+	if len(rank) == 0 || rank[0] < 'A' || rank[0] > 'Z' {
+		return 1 << 30
+	}
+	v := int(rank[0]-'A') * 3
+	switch rank[1:] {
+	case "+":
+	case "-":
+		v += 2
+	default:
+		v++
+	}
+	return v
+}
@@ -44,6 +44,14 @@ const (
 	PatternKicking         PatternType = "kicking"           // 反冲形态
 	PatternDragonflyDoji   PatternType = "dragonfly_doji"    // 蜻蜓十字
 	PatternGravestoneDoji  PatternType = "gravestone_doji"   // 墓碑十字
+
+	// Continuation patterns (custom, 5-kline window)
+	PatternRisingThreeMethods  PatternType = "rising_three_methods"  // 上升三法
+	PatternFallingThreeMethods PatternType = "falling_three_methods" // 下降三法
+
+	// Structure patterns (custom, price action, 2-kline window)
+	PatternInsideBar  PatternType = "inside_bar"  // 内包线
+	PatternOutsideBar PatternType = "outside_bar" // 外包线
 )
 
 // Direction represents the pattern direction.
@@ -93,4 +101,12 @@ var PatternNames = map[PatternType]string{
 	PatternKicking:         "反冲形态",
 	PatternDragonflyDoji:   "蜻蜓十字",
 	PatternGravestoneDoji:  "墓碑十字",
+
+	// Continuation patterns
+	PatternRisingThreeMethods:  "上升三法",
+	PatternFallingThreeMethods: "下降三法",
+
+	// Structure patterns
+	PatternInsideBar:  "内包线",
+	PatternOutsideBar: "外包线",
 }
@@ -1,6 +1,10 @@
 package pivot
 
-import "errors"
+import (
+	"errors"
+	"math"
+	"sort"
+)
 
 type Levels struct {
 	High  float64 `json:"high"`
@@ -17,6 +21,74 @@ type Levels struct {
 	S3    float64 `json:"s3"`
 	S4    float64 `json:"s4"`
 	S5    float64 `json:"s5"`
+
+	// Meta is set when MergeNearLevels collapsed any levels.
+	Meta *LevelsMeta `json:"meta,omitempty"`
+}
+
+// LevelsMeta records post-processing applied to a symbol's levels.
+type LevelsMeta struct {
+	// Collapsed lists levels that duplicate an adjacent level nearer to PP;
+	// the monitor does not emit signals for them.
+	Collapsed []string `json:"collapsed,omitempty"`
+}
+
+// IsCollapsed reports whether the named level was collapsed into a neighbour.
+func (l Levels) IsCollapsed(name string) bool {
+	if l.Meta == nil {
+		return false
+	}
+	for _, n := range l.Meta.Collapsed {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeNearLevels flags adjacent levels whose prices differ by at most eps
+// (a fraction, e.g. 0.0005 = 0.05%) of the retained level. Of each such pair
+// the level farther from PP is collapsed. eps <= 0 returns l unchanged.
+func MergeNearLevels(l Levels, eps float64) Levels {
+	l.Meta = nil
+	if eps <= 0 {
+		return l
+	}
+
+	levels := l.Named()
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Price < levels[j].Price
+	})
+
+	var collapsed []string
+	kept := -1
+	for i, ld := range levels {
+		if ld.Price <= 0 {
+			continue
+		}
+		if kept < 0 {
+			kept = i
+			continue
+		}
+		prev := levels[kept]
+		if ld.Price-prev.Price > eps*prev.Price {
+			kept = i
+			continue
+		}
+		// Keep whichever of the pair is nearer to PP
+		if math.Abs(ld.Price-l.PP) < math.Abs(prev.Price-l.PP) {
+			collapsed = append(collapsed, prev.Name)
+			kept = i
+		} else {
+			collapsed = append(collapsed, ld.Name)
+		}
+	}
+
+	if len(collapsed) > 0 {
+		sort.Strings(collapsed)
+		l.Meta = &LevelsMeta{Collapsed: collapsed}
+	}
+	return l
 }
 
 func Calculate(high, low, close float64) (Levels, error) {
@@ -60,3 +132,86 @@ func Calculate(high, low, close float64) (Levels, error) {
 		S5:    s5,
 	}, nil
 }
+
+// LevelDistance is a named pivot level and its distance from a reference price.
+type LevelDistance struct {
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Distance float64 `json:"distance"`     // Price - reference price (positive = above)
+	Percent  float64 `json:"distance_pct"` // Distance as a percentage of the reference price
+}
+
+// Named returns the 11 pivot levels (PP, R1-R5, S1-S5) with their names.
+func (l Levels) Named() []LevelDistance {
+	return []LevelDistance{
+		{Name: "PP", Price: l.PP},
+		{Name: "R1", Price: l.R1},
+		{Name: "R2", Price: l.R2},
+		{Name: "R3", Price: l.R3},
+		{Name: "R4", Price: l.R4},
+		{Name: "R5", Price: l.R5},
+		{Name: "S1", Price: l.S1},
+		{Name: "S2", Price: l.S2},
+		{Name: "S3", Price: l.S3},
+		{Name: "S4", Price: l.S4},
+		{Name: "S5", Price: l.S5},
+	}
+}
+
+// MidPivots are the midpoints between the inner levels:
+// M1 = (S2+S1)/2, M2 = (S1+PP)/2, M3 = (PP+R1)/2, M4 = (R1+R2)/2.
+type MidPivots struct {
+	M1 float64 `json:"m1"`
+	M2 float64 `json:"m2"`
+	M3 float64 `json:"m3"`
+	M4 float64 `json:"m4"`
+}
+
+// MidPivots returns the mid-pivots of l. A mid-pivot is zero when either
+// of its levels is missing.
+func (l Levels) MidPivots() MidPivots {
+	mid := func(a, b float64) float64 {
+		if a <= 0 || b <= 0 {
+			return 0
+		}
+		return (a + b) / 2
+	}
+	return MidPivots{
+		M1: mid(l.S2, l.S1),
+		M2: mid(l.S1, l.PP),
+		M3: mid(l.PP, l.R1),
+		M4: mid(l.R1, l.R2),
+	}
+}
+
+// Named returns the 4 mid-pivots (M1-M4) with their names.
+func (m MidPivots) Named() []LevelDistance {
+	return []LevelDistance{
+		{Name: "M1", Price: m.M1},
+		{Name: "M2", Price: m.M2},
+		{Name: "M3", Price: m.M3},
+		{Name: "M4", Price: m.M4},
+	}
+}
+
+// Nearest returns the levels with their distance from price, sorted by
+// absolute distance (nearest first). Non-positive levels are skipped; a
+// non-positive price yields nil.
+func (l Levels) Nearest(price float64) []LevelDistance {
+	if price <= 0 {
+		return nil
+	}
+	out := make([]LevelDistance, 0, 11)
+	for _, ld := range l.Named() {
+		if ld.Price <= 0 {
+			continue
+		}
+		ld.Distance = ld.Price - price
+		ld.Percent = ld.Distance / price * 100
+		out = append(out, ld)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return math.Abs(out[i].Distance) < math.Abs(out[j].Distance)
+	})
+	return out
+}
@@ -0,0 +1,85 @@
+package pivot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLevels_Nearest(t *testing.T) {
+	lv := Levels{
+		PP: 100,
+		R1: 101, R2: 102, R3: 103, R4: 106, R5: 110,
+		S1: 99, S2: 98, S3: 97, S4: 94, S5: 0, // S5 missing
+	}
+
+	got := lv.Nearest(101.8)
+	if len(got) != 10 {
+		t.Fatalf("len = %d, want 10 (zero level skipped)", len(got))
+	}
+	if got[0].Name != "R2" || got[1].Name != "R1" {
+		t.Errorf("nearest = %s, %s; want R2, R1", got[0].Name, got[1].Name)
+	}
+	for i := 1; i < len(got); i++ {
+		if math.Abs(got[i].Distance) < math.Abs(got[i-1].Distance) {
+			t.Errorf("not sorted by distance at %d: %v", i, got)
+		}
+	}
+
+	r2 := got[0]
+	if math.Abs(r2.Distance-0.2) > 1e-9 {
+		t.Errorf("R2 distance = %v, want 0.2", r2.Distance)
+	}
+	if math.Abs(r2.Percent-0.2/101.8*100) > 1e-9 {
+		t.Errorf("R2 percent = %v", r2.Percent)
+	}
+	if got[1].Distance >= 0 {
+		t.Errorf("R1 below price should have negative distance, got %v", got[1].Distance)
+	}
+
+	if lv.Nearest(0) != nil {
+		t.Error("expected nil for non-positive price")
+	}
+}
+
+func TestLevels_MidPivots(t *testing.T) {
+	lv := Levels{PP: 100, R1: 101, R2: 103, S1: 99, S2: 0} // S2 missing
+
+	got := lv.MidPivots()
+	want := MidPivots{M1: 0, M2: 99.5, M3: 100.5, M4: 102}
+	if got != want {
+		t.Errorf("MidPivots() = %+v, want %+v", got, want)
+	}
+
+	named := got.Named()
+	if len(named) != 4 || named[0].Name != "M1" || named[3].Name != "M4" || named[3].Price != 102 {
+		t.Errorf("Named() = %+v", named)
+	}
+}
+
+func TestMergeNearLevels(t *testing.T) {
+	lv := Levels{
+		PP: 100,
+		R1: 101, R2: 102, R3: 103, R4: 103.02, R5: 110,
+		S1: 99, S2: 98, S3: 97, S4: 94, S5: 90,
+	}
+
+	if got := MergeNearLevels(lv, 0); got.Meta != nil {
+		t.Errorf("eps=0 should not merge, got %+v", got.Meta)
+	}
+
+	got := MergeNearLevels(lv, 0.001) // 0.1%
+	if got.Meta == nil || len(got.Meta.Collapsed) != 1 || got.Meta.Collapsed[0] != "R4" {
+		t.Fatalf("Collapsed = %+v, want [R4]", got.Meta)
+	}
+	if !got.IsCollapsed("R4") || got.IsCollapsed("R3") {
+		t.Errorf("IsCollapsed(R4)=%v IsCollapsed(R3)=%v, want true/false", got.IsCollapsed("R4"), got.IsCollapsed("R3"))
+	}
+	if got.R4 != lv.R4 {
+		t.Errorf("level prices must be preserved, R4 = %v", got.R4)
+	}
+
+	// Levels further apart than eps are untouched
+	if got := MergeNearLevels(lv, 0.0001); got.Meta != nil {
+		t.Errorf("eps=0.01%% should not merge, got %+v", got.Meta)
+	}
+}
@@ -0,0 +1,60 @@
+package pivot
+
+import (
+	"errors"
+	"strings"
+)
+
+// Pivot calculation methods accepted by CalculateWithMethod.
+const (
+	MethodCamarilla = "camarilla" // Calculate; what the monitor uses
+	MethodClassic   = "classic"   // CalculateClassic
+)
+
+// ErrUnknownMethod is returned by CalculateWithMethod for other methods.
+var ErrUnknownMethod = errors.New("unknown pivot method")
+
+// CalculateWithMethod computes levels with the named method (case-insensitive).
+func CalculateWithMethod(method string, high, low, close float64) (Levels, error) {
+	switch strings.ToLower(method) {
+	case MethodCamarilla:
+		return Calculate(high, low, close)
+	case MethodClassic:
+		return CalculateClassic(high, low, close)
+	default:
+		return Levels{}, ErrUnknownMethod
+	}
+}
+
+// CalculateClassic computes classic (floor trader) pivots. R4/R5 and S4/S5,
+// which the classic formula lacks, extend R3 and S3 by one range each.
+func CalculateClassic(high, low, close float64) (Levels, error) {
+	if high <= 0 || low <= 0 {
+		return Levels{}, errors.New("invalid high/low")
+	}
+	if high < low {
+		return Levels{}, errors.New("high < low")
+	}
+
+	rng := high - low
+	pp := (high + low + close) / 3.0
+	r3 := high + 2*(pp-low)
+	s3 := low - 2*(high-pp)
+
+	return Levels{
+		High:  high,
+		Low:   low,
+		Close: close,
+		PP:    pp,
+		R1:    2*pp - low,
+		R2:    pp + rng,
+		R3:    r3,
+		R4:    r3 + rng,
+		R5:    r3 + 2*rng,
+		S1:    2*pp - high,
+		S2:    pp - rng,
+		S3:    s3,
+		S4:    s3 - rng,
+		S5:    s3 - 2*rng,
+	}, nil
+}
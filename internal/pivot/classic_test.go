@@ -0,0 +1,28 @@
+package pivot
+
+import "testing"
+
+func TestCalculateWithMethod(t *testing.T) {
+	lv, err := CalculateWithMethod("Classic", 110, 90, 100)
+	if err != nil {
+		t.Fatalf("classic: %v", err)
+	}
+	want := Levels{High: 110, Low: 90, Close: 100, PP: 100,
+		R1: 110, R2: 120, R3: 130, R4: 150, R5: 170,
+		S1: 90, S2: 80, S3: 70, S4: 50, S5: 30}
+	if lv != want {
+		t.Errorf("classic = %+v, want %+v", lv, want)
+	}
+
+	cam, err := CalculateWithMethod(MethodCamarilla, 110, 90, 100)
+	if ref, _ := Calculate(110, 90, 100); err != nil || cam != ref {
+		t.Errorf("camarilla = %+v, %v; want Calculate's %+v", cam, err, ref)
+	}
+
+	if _, err := CalculateWithMethod("fibonacci", 110, 90, 100); err != ErrUnknownMethod {
+		t.Errorf("unknown method err = %v, want ErrUnknownMethod", err)
+	}
+	if _, err := CalculateWithMethod(MethodClassic, 90, 110, 100); err == nil {
+		t.Error("classic with high < low: want error")
+	}
+}
@@ -0,0 +1,130 @@
+package pivot
+
+import (
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+)
+
+// DefaultHistorySize is the default number of past snapshots kept per period.
+const DefaultHistorySize = 7
+
+// HistoricalLevels is a symbol's levels from one snapshot.
+type HistoricalLevels struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Levels    Levels    `json:"levels"`
+}
+
+// SetHistorySize sets how many past snapshots are kept per period.
+// Existing history is trimmed to the new size; n <= 0 disables history.
+func (s *Store) SetHistorySize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.histMu.Lock()
+	defer s.histMu.Unlock()
+	s.histMax = n
+	for p, snaps := range s.history {
+		s.history[p] = trimSnapshots(snaps, n)
+	}
+}
+
+// PushHistory records snap as the newest past snapshot of period, dropping
+// the oldest once the history is full. A snapshot computed from the same
+// klines as the newest entry (a repeated refresh) replaces it instead, and
+// one with the timestamp of a snapshot already held is ignored.
+func (s *Store) PushHistory(period Period, snap *Snapshot) {
+	if snap == nil {
+		return
+	}
+	s.histMu.Lock()
+	defer s.histMu.Unlock()
+	if s.histMax <= 0 {
+		return
+	}
+
+	snaps := s.history[period]
+	for _, held := range snaps {
+		if held.UpdatedAt.Equal(snap.UpdatedAt) {
+			return
+		}
+	}
+	if n := len(snaps); n > 0 && sameSource(snaps[n-1], snap) {
+		snaps[n-1] = snap
+		return
+	}
+	s.history[period] = trimSnapshots(append(snaps, snap), s.histMax)
+}
+
+// History returns the past snapshots of period, oldest first.
+func (s *Store) History(period Period) []*Snapshot {
+	s.histMu.Lock()
+	defer s.histMu.Unlock()
+	out := make([]*Snapshot, len(s.history[period]))
+	copy(out, s.history[period])
+	return out
+}
+
+// SetHistory replaces the past snapshots of period (oldest first), e.g. when
+// loading from disk. It is trimmed to the configured history size.
+func (s *Store) SetHistory(period Period, snaps []*Snapshot) {
+	s.histMu.Lock()
+	defer s.histMu.Unlock()
+	kept := make([]*Snapshot, 0, len(snaps))
+	for _, snap := range snaps {
+		if snap != nil {
+			kept = append(kept, snap)
+		}
+	}
+	s.history[period] = trimSnapshots(kept, s.histMax)
+}
+
+// LevelsHistory returns up to n levels for symbol, newest first: the current
+// snapshot followed by past ones. Snapshots without the symbol are skipped.
+// n <= 0 means all.
+func (s *Store) LevelsHistory(period Period, symbol string, n int) []HistoricalLevels {
+	symbol = binance.NormalizeSymbol(symbol)
+
+	snaps := s.History(period)
+	if cur, _ := s.Snapshot(period); cur != nil {
+		snaps = append(snaps, cur)
+	}
+
+	out := []HistoricalLevels{}
+	for i := len(snaps) - 1; i >= 0; i-- {
+		if n > 0 && len(out) >= n {
+			break
+		}
+		if lv, ok := snaps[i].Symbols[symbol]; ok {
+			out = append(out, HistoricalLevels{UpdatedAt: snaps[i].UpdatedAt, Levels: lv})
+		}
+	}
+	return out
+}
+
+// trimSnapshots keeps the newest max snapshots.
+func trimSnapshots(snaps []*Snapshot, max int) []*Snapshot {
+	if len(snaps) <= max {
+		return snaps
+	}
+	out := make([]*Snapshot, max)
+	copy(out, snaps[len(snaps)-max:])
+	return out
+}
+
+// sameSource reports whether two snapshots were computed from the same
+// klines, i.e. every symbol they share has identical high/low/close.
+func sameSource(a, b *Snapshot) bool {
+	common := 0
+	for sym, la := range a.Symbols {
+		lb, ok := b.Symbols[sym]
+		if !ok {
+			continue
+		}
+		if la.High != lb.High || la.Low != lb.Low || la.Close != lb.Close {
+			return false
+		}
+		common++
+	}
+	return common > 0
+}
@@ -0,0 +1,153 @@
+package pivot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/clock"
+)
+
+// testSnapshot returns a snapshot whose BTCUSDT levels were computed from close c.
+func testSnapshot(day int, c float64) *Snapshot {
+	return &Snapshot{
+		Period:    PeriodDaily,
+		UpdatedAt: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+		Symbols:   map[string]Levels{"BTCUSDT": {High: c + 10, Low: c - 10, Close: c, PP: c}},
+	}
+}
+
+func TestStore_HistoryRingBuffer(t *testing.T) {
+	s := NewStore()
+	s.SetHistorySize(3)
+
+	for day := 1; day <= 5; day++ {
+		s.PushHistory(PeriodDaily, testSnapshot(day, float64(100+day)))
+	}
+
+	hist := s.History(PeriodDaily)
+	if len(hist) != 3 {
+		t.Fatalf("len(History) = %d, want 3", len(hist))
+	}
+	for i, day := range []int{3, 4, 5} {
+		if hist[i].UpdatedAt.Day() != day {
+			t.Errorf("History[%d] day = %d, want %d (oldest first)", i, hist[i].UpdatedAt.Day(), day)
+		}
+	}
+	if got := s.History(PeriodWeekly); len(got) != 0 {
+		t.Errorf("weekly history should be independent, got %d", len(got))
+	}
+
+	// Shrinking keeps the newest
+	s.SetHistorySize(2)
+	if hist := s.History(PeriodDaily); len(hist) != 2 || hist[0].UpdatedAt.Day() != 4 {
+		t.Errorf("after shrink: %d snapshots, first day %d; want 2 starting at day 4", len(hist), hist[0].UpdatedAt.Day())
+	}
+
+	// Disabled history keeps nothing new
+	s.SetHistorySize(0)
+	s.PushHistory(PeriodDaily, testSnapshot(6, 106))
+	if got := s.History(PeriodDaily); len(got) != 0 {
+		t.Errorf("history size 0: got %d snapshots", len(got))
+	}
+}
+
+func TestStore_PushHistoryReplacesRepeatedRefresh(t *testing.T) {
+	s := NewStore()
+	s.PushHistory(PeriodDaily, testSnapshot(1, 100))
+	s.PushHistory(PeriodDaily, testSnapshot(2, 100)) // Same klines, refreshed again
+
+	hist := s.History(PeriodDaily)
+	if len(hist) != 1 || hist[0].UpdatedAt.Day() != 2 {
+		t.Errorf("expected the repeated snapshot to replace the first, got %d entries", len(hist))
+	}
+}
+
+func TestStore_PushHistoryIgnoresSameTimestamp(t *testing.T) {
+	s := NewStore()
+	s.PushHistory(PeriodDaily, testSnapshot(1, 100))
+	s.PushHistory(PeriodDaily, testSnapshot(2, 101))
+	s.PushHistory(PeriodDaily, testSnapshot(1, 102)) // Day 1 pushed again
+
+	hist := s.History(PeriodDaily)
+	if len(hist) != 2 || hist[0].Symbols["BTCUSDT"].Close != 100 || hist[1].Symbols["BTCUSDT"].Close != 101 {
+		t.Errorf("expected the second day-1 snapshot to be ignored, got %d entries", len(hist))
+	}
+}
+
+func TestRefresher_RepeatedRefreshKeepsNoDuplicate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/exchangeInfo":
+			_, _ = w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","status":"TRADING","contractType":"PERPETUAL","quoteAsset":"USDT"}]}`))
+		default:
+			_, _ = w.Write([]byte(`[
+				[1704067200000,"100.0","110.0","90.0","105.0","1",1704153599999,"0",1],
+				[1704153600000,"105.0","106.0","104.0","105.5","1",1704239999999,"0",1]
+			]`))
+		}
+	}))
+	defer ts.Close()
+
+	fake := clock.NewFake(time.Date(2024, 1, 3, 1, 0, 0, 0, time.UTC))
+	r := NewRefresher(t.TempDir(), NewStore(), binance.NewRESTClient(ts.URL))
+	r.Clock = fake
+	for i := 0; i < 3; i++ {
+		if err := r.Refresh(context.Background(), PeriodDaily); err != nil {
+			t.Fatalf("Refresh %d: %v", i+1, err)
+		}
+		fake.Advance(time.Minute)
+	}
+
+	if hist := r.Store.History(PeriodDaily); len(hist) != 0 {
+		t.Errorf("history = %d snapshots, want none for refreshes of the same klines", len(hist))
+	}
+	if got := r.Store.LevelsHistory(PeriodDaily, "BTCUSDT", 0); len(got) != 1 {
+		t.Errorf("LevelsHistory = %d entries, want only the current levels", len(got))
+	}
+}
+
+func TestStore_LevelsHistory(t *testing.T) {
+	s := NewStore()
+	for day := 1; day <= 3; day++ {
+		s.PushHistory(PeriodDaily, testSnapshot(day, float64(100+day)))
+	}
+	_ = s.Swap(PeriodDaily, testSnapshot(4, 104))
+
+	got := s.LevelsHistory(PeriodDaily, " btcusdt ", 0)
+	if len(got) != 4 {
+		t.Fatalf("len = %d, want 4 (current + 3 past)", len(got))
+	}
+	for i, want := range []float64{104, 103, 102, 101} {
+		if got[i].Levels.Close != want {
+			t.Errorf("[%d] close = %v, want %v (newest first)", i, got[i].Levels.Close, want)
+		}
+	}
+
+	if got := s.LevelsHistory(PeriodDaily, "BTCUSDT", 2); len(got) != 2 || got[1].Levels.Close != 103 {
+		t.Errorf("n=2: %+v", got)
+	}
+	if got := s.LevelsHistory(PeriodDaily, "ETHUSDT", 0); len(got) != 0 {
+		t.Errorf("unknown symbol: %+v", got)
+	}
+}
+
+func TestRefresher_HistoryPersistence(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRefresher(dir, NewStore(), nil)
+	r.Store.PushHistory(PeriodDaily, testSnapshot(1, 101))
+	r.Store.PushHistory(PeriodDaily, testSnapshot(2, 102))
+	if err := r.persistHistory(PeriodDaily); err != nil {
+		t.Fatalf("persistHistory failed: %v", err)
+	}
+
+	r2 := NewRefresher(dir, NewStore(), nil)
+	r2.LoadFromDisk()
+	hist := r2.Store.History(PeriodDaily)
+	if len(hist) != 2 || hist[1].Symbols["BTCUSDT"].Close != 102 {
+		t.Errorf("reloaded history = %d snapshots, want 2 ending with close 102", len(hist))
+	}
+}
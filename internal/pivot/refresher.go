@@ -8,19 +8,68 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/clock"
 )
 
+// DefaultVolumeWait is used for a zero Refresher.VolumeWait.
+const DefaultVolumeWait = time.Minute
+
+// volumePollInterval spaces the SymbolVolume checks of waitVolumes.
+const volumePollInterval = 200 * time.Millisecond
+
 type Refresher struct {
 	DataDir string
 	Store   *Store
 	Client  *binance.RESTClient
 	Workers int
 
+	// LevelMergeEpsilon collapses adjacent levels closer than this fraction
+	// of their price (see MergeNearLevels). Zero disables merging.
+	LevelMergeEpsilon float64
+
+	// CoinMargined also refreshes COIN-margined perpetuals (e.g. BTCUSD_PERP)
+	// from Client's dapi endpoints. Their failure does not fail the refresh.
+	CoinMargined bool
+
+	// MaxSymbols caps how many symbols a refresh computes, for hosts that
+	// cannot hold levels for the whole market. Zero means no limit.
+	MaxSymbols int
+
+	// SymbolVolume ranks symbols when MaxSymbols applies: the highest
+	// volumes are kept, ties broken alphabetically. Nil keeps the first
+	// MaxSymbols symbols in alphabetical order.
+	SymbolVolume func(symbol string) float64
+
+	// VolumeWait bounds how long a limited refresh waits for SymbolVolume
+	// to report any volume (e.g. until the ticker stream has connected at
+	// startup) before ranking alphabetically. Zero uses DefaultVolumeWait.
+	VolumeWait time.Duration
+
+	// Clock supplies the current time for staleness checks and scheduling.
+	// Nil means the wall clock.
+	Clock clock.Clock
+
+	// RetryMin and RetryMax bound the delay before retrying a failed
+	// refresh: RetryMin after the first failure, doubling with each further
+	// one up to RetryMax. Until then the period is not reported stale.
+	// Zero uses DefaultRetryMin and DefaultRetryMax.
+	RetryMin time.Duration
+	RetryMax time.Duration
+
 	mu sync.Mutex
+
+	attemptMu sync.Mutex
+	attempts  map[Period]refreshAttempt // Periods whose last refresh failed
+
+	// fileMu guards the committed pivot files so RawFile never observes a
+	// rename in progress. It is held only briefly, unlike mu.
+	fileMu sync.RWMutex
 }
 
 func NewRefresher(dataDir string, store *Store, client *binance.RESTClient) *Refresher {
@@ -44,8 +93,71 @@ func (r *Refresher) pivotFilePath(period Period) (string, error) {
 	}
 }
 
+// RawFile returns the committed pivot file of period as written by the last
+// refresh (never the in-progress .tmp). A missing file yields an error
+// satisfying errors.Is(err, fs.ErrNotExist).
+func (r *Refresher) RawFile(period Period) ([]byte, error) {
+	path, err := r.pivotFilePath(period)
+	if err != nil {
+		return nil, err
+	}
+	r.fileMu.RLock()
+	defer r.fileMu.RUnlock()
+	return os.ReadFile(path)
+}
+
+// historyFilePath returns the file holding past snapshots of period,
+// next to the current one (e.g. pivots/daily_history.json).
+func (r *Refresher) historyFilePath(period Period) (string, error) {
+	path, err := r.pivotFilePath(period)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".json") + "_history.json", nil
+}
+
+// persistHistory writes the store's past snapshots of period to disk.
+func (r *Refresher) persistHistory(period Period) error {
+	path, err := r.historyFilePath(period)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(r.Store.History(period))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadHistory restores past snapshots of period from disk, if present.
+func (r *Refresher) loadHistory(period Period) {
+	path, err := r.historyFilePath(period)
+	if err != nil {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var snaps []*Snapshot
+	if err := json.Unmarshal(b, &snaps); err != nil {
+		log.Printf("pivot history load %s failed: %v", path, err)
+		return
+	}
+	r.Store.SetHistory(period, snaps)
+}
+
 func (r *Refresher) LoadFromDisk() {
 	for _, p := range []Period{PeriodDaily, PeriodWeekly} {
+		r.loadHistory(p)
+
 		path, err := r.pivotFilePath(p)
 		if err != nil {
 			continue
@@ -72,10 +184,18 @@ func (r *Refresher) LoadFromDisk() {
 	}
 }
 
+// Refresh recomputes the levels of period from Binance and swaps them in.
+// Failures are counted towards the retry backoff (see RetryMin).
 func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	err := r.refresh(ctx, period)
+	r.recordAttempt(period, clock.Or(r.Clock).Now(), err)
+	return err
+}
+
+func (r *Refresher) refresh(ctx context.Context, period Period) error {
 	interval := ""
 	switch period {
 	case PeriodDaily:
@@ -93,6 +213,15 @@ func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 	if err != nil {
 		return err
 	}
+	if r.CoinMargined {
+		coin, err := r.Client.ExchangeInfoCoinPERP(ctxSymbols)
+		if err != nil {
+			log.Printf("pivot %s coin-margined symbols failed: %v", period, err)
+		} else {
+			symbols = append(symbols, coin...)
+		}
+	}
+	symbols = r.limitSymbols(ctx, symbols)
 
 	type result struct {
 		symbol string
@@ -125,6 +254,9 @@ func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 					continue
 				}
 				lv, err := Calculate(h, l, c)
+				if err == nil {
+					lv = MergeNearLevels(lv, r.LevelMergeEpsilon)
+				}
 				results <- result{symbol: sym, lv: lv, err: err}
 			}
 		}()
@@ -162,7 +294,13 @@ func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 		minCount = 1
 	}
 	if oldSnap, _ := r.Store.Snapshot(period); oldSnap != nil {
-		oldMin := len(oldSnap.Symbols) * 8 / 10
+		// A snapshot taken before MaxSymbols was lowered must not demand
+		// more symbols than this refresh asks for
+		prev := len(oldSnap.Symbols)
+		if prev > expected {
+			prev = expected
+		}
+		oldMin := prev * 8 / 10
 		if oldMin > minCount {
 			minCount = oldMin
 		}
@@ -173,7 +311,7 @@ func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 
 	snap := &Snapshot{
 		Period:    period,
-		UpdatedAt: time.Now().UTC(),
+		UpdatedAt: clock.Or(r.Clock).Now().UTC(),
 		Symbols:   levelsBySymbol,
 	}
 
@@ -191,12 +329,25 @@ func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 	}
 
 	tmp := path + ".tmp"
+	r.fileMu.Lock()
 	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		r.fileMu.Unlock()
 		return err
 	}
 	if err := os.Rename(tmp, path); err != nil {
+		r.fileMu.Unlock()
 		return err
 	}
+	r.fileMu.Unlock()
+
+	// Keep the replaced snapshot so past periods' levels stay queryable,
+	// unless it holds the same levels as the new one (a repeated refresh)
+	if old, _ := r.Store.Snapshot(period); old != nil && !sameSource(old, snap) {
+		r.Store.PushHistory(period, old)
+		if err := r.persistHistory(period); err != nil {
+			log.Printf("pivot history persist %s failed: %v", period, err)
+		}
+	}
 
 	if err := r.Store.Swap(period, snap); err != nil {
 		return err
@@ -206,6 +357,57 @@ func (r *Refresher) Refresh(ctx context.Context, period Period) error {
 	return nil
 }
 
+// limitSymbols returns the MaxSymbols symbols to refresh, ranked by
+// SymbolVolume when set and alphabetically otherwise.
+func (r *Refresher) limitSymbols(ctx context.Context, symbols []string) []string {
+	if r.MaxSymbols <= 0 || len(symbols) <= r.MaxSymbols {
+		return symbols
+	}
+	ranked := append([]string(nil), symbols...)
+	vols := r.waitVolumes(ctx, ranked)
+	sort.Slice(ranked, func(i, j int) bool {
+		if vols[ranked[i]] != vols[ranked[j]] {
+			return vols[ranked[i]] > vols[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	return ranked[:r.MaxSymbols]
+}
+
+// waitVolumes returns SymbolVolume for each symbol, polling for up to
+// VolumeWait by Clock while every volume is still zero.
+func (r *Refresher) waitVolumes(ctx context.Context, symbols []string) map[string]float64 {
+	vols := make(map[string]float64, len(symbols))
+	if r.SymbolVolume == nil {
+		return vols
+	}
+	wait := r.VolumeWait
+	if wait <= 0 {
+		wait = DefaultVolumeWait
+	}
+	clk := clock.Or(r.Clock)
+	deadline := clk.Now().Add(wait)
+	for {
+		known := false
+		for _, sym := range symbols {
+			vols[sym] = r.SymbolVolume(sym)
+			known = known || vols[sym] > 0
+		}
+		if known {
+			return vols
+		}
+		if !clk.Now().Before(deadline) {
+			log.Printf("pivot symbol volumes unavailable after %v, ranking alphabetically", wait)
+			return vols
+		}
+		select {
+		case <-ctx.Done():
+			return vols
+		case <-time.After(volumePollInterval):
+		}
+	}
+}
+
 func (r *Refresher) StartScheduler(ctx context.Context) {
 	loc, err := time.LoadLocation("Asia/Shanghai")
 	if err != nil {
@@ -216,13 +418,57 @@ func (r *Refresher) StartScheduler(ctx context.Context) {
 	go r.loop(ctx, PeriodWeekly, loc)
 }
 
+// Follow reloads pivots from disk whenever another process rewrites the
+// pivot files, for a read-only replica that never calls Refresh.
+// It polls every interval until ctx is done.
+func (r *Refresher) Follow(ctx context.Context, interval time.Duration) {
+	mod := r.pivotModTimes()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if m := r.pivotModTimes(); m != mod {
+				mod = m
+				r.LoadFromDisk()
+			}
+		}
+	}
+}
+
+// pivotModTimes returns the daily and weekly pivot file modification times
+// in Unix nanoseconds, zero for a missing file.
+func (r *Refresher) pivotModTimes() [2]int64 {
+	var mod [2]int64
+	for i, p := range []Period{PeriodDaily, PeriodWeekly} {
+		path, err := r.pivotFilePath(p)
+		if err != nil {
+			continue
+		}
+		if fi, err := os.Stat(path); err == nil {
+			mod[i] = fi.ModTime().UnixNano()
+		}
+	}
+	return mod
+}
+
+// needsRefresh reports whether the loop should refresh period now: its
+// pivots are stale and no failure backoff is pending.
 func (r *Refresher) needsRefresh(period Period, loc *time.Location) bool {
+	return !r.backingOff(period) && r.isStale(period, loc)
+}
+
+// isStale reports whether period has no pivots yet or they predate the
+// latest scheduled refresh, regardless of any retry backoff.
+func (r *Refresher) isStale(period Period, loc *time.Location) bool {
 	snap, _ := r.Store.Snapshot(period)
 	if snap == nil {
 		return true
 	}
 
-	now := time.Now().In(loc)
+	now := clock.Or(r.Clock).Now().In(loc)
 
 	// 延迟2分钟刷新，确保币安K线数据已完全收盘
 	// 币安日线在 UTC 00:00 (UTC+8 08:00) 收盘，延迟到 08:02 确保数据稳定
@@ -278,9 +524,9 @@ func (r *Refresher) loop(ctx context.Context, period Period, loc *time.Location)
 			}
 		}
 
-		now := time.Now().In(loc)
-		next := nextRun(now, period, loc)
-		d := time.Until(next)
+		now := clock.Or(r.Clock).Now().In(loc)
+		next := r.nextRefresh(now, period, loc)
+		d := next.Sub(now)
 		if d < time.Minute {
 			d = time.Minute // 避免过于频繁的循环
 		}
@@ -324,6 +570,10 @@ type PivotPeriodStatus struct {
 	SecondsUntil  int64      `json:"seconds_until"`
 	IsStale       bool       `json:"is_stale"`
 	SymbolCount   int        `json:"symbol_count"`
+
+	// Failures counts consecutive failed refreshes; NextRefreshAt is then
+	// the backed-off retry when that comes before the scheduled run.
+	Failures int `json:"failures,omitempty"`
 }
 
 type PivotStatusResponse struct {
@@ -337,15 +587,18 @@ func (r *Refresher) PivotStatus() PivotStatusResponse {
 		loc = time.FixedZone("UTC+8", 8*60*60)
 	}
 
-	now := time.Now().In(loc)
+	now := clock.Or(r.Clock).Now().In(loc)
 
 	buildStatus := func(period Period) PivotPeriodStatus {
 		snap, _ := r.Store.Snapshot(period)
-		next := nextRun(now, period, loc)
+		next := r.nextRefresh(now, period, loc)
 		status := PivotPeriodStatus{
 			NextRefreshAt: next.UTC(),
-			SecondsUntil:  int64(time.Until(next).Seconds()),
-			IsStale:       r.needsRefresh(period, loc),
+			SecondsUntil:  int64(next.Sub(now).Seconds()),
+			IsStale:       r.isStale(period, loc),
+		}
+		if _, failures, ok := r.retryAt(period); ok {
+			status.Failures = failures
 		}
 		if snap != nil {
 			t := snap.UpdatedAt
@@ -1,8 +1,17 @@
 package pivot
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+	"example.com/binance-pivot-monitor/internal/clock"
 )
 
 func TestGetThisWeekMonday(t *testing.T) {
@@ -100,7 +109,8 @@ func TestNeedsRefresh_WeeklyOnSunday(t *testing.T) {
 	loc, _ := time.LoadLocation("Asia/Shanghai")
 
 	store := NewStore()
-	_ = &Refresher{Store: store} // 用于未来扩展测试
+	fake := clock.NewFake(time.Time{})
+	r := &Refresher{Store: store, Clock: fake}
 
 	// 模拟上周一更新的数据
 	lastMonday := time.Date(2024, 12, 23, 8, 5, 0, 0, loc) // 上周一 08:05
@@ -114,9 +124,13 @@ func TestNeedsRefresh_WeeklyOnSunday(t *testing.T) {
 	// 测试周日：应该判定为 stale，因为本周一已经过了
 	sunday := time.Date(2024, 12, 29, 10, 0, 0, 0, loc) // 周日 10:00
 
-	// 我们需要一个可以注入时间的测试方法
-	// 由于 needsRefresh 使用 time.Now()，我们需要重构或使用其他方式测试
-	// 这里我们直接测试 getThisWeekMonday 的正确性
+	fake.Set(sunday)
+
+	// snap.UpdatedAt (上周一 08:05) 在本周一 08:02 之后，不应判定为 stale
+	if r.needsRefresh(PeriodWeekly, loc) {
+		t.Errorf("On Sunday %s, snapshot from %s should not be stale",
+			sunday.Format("2006-01-02"), lastMonday.Format("2006-01-02 15:04"))
+	}
 
 	thisMonday := getThisWeekMonday(sunday, loc)
 	expectedMonday := time.Date(2024, 12, 23, 8, 2, 0, 0, loc)
@@ -139,10 +153,39 @@ func TestNeedsRefresh_WeeklyOnSunday(t *testing.T) {
 	store.Swap(PeriodWeekly, oldSnap)
 
 	// 上上周一 08:05 < 本周一 08:02 (2024-12-23)，应该判定为 stale
-	if !oldSnap.UpdatedAt.Before(thisMonday) {
-		t.Errorf("oldSnap.UpdatedAt %s should be before thisMonday %s",
-			oldSnap.UpdatedAt.Format("2006-01-02 15:04"),
-			thisMonday.Format("2006-01-02 15:04"))
+	if !r.needsRefresh(PeriodWeekly, loc) {
+		t.Errorf("On Sunday %s, snapshot from %s should be stale",
+			sunday.Format("2006-01-02"), oldSnap.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+func TestNeedsRefresh_Daily(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+
+	store := NewStore()
+	fake := clock.NewFake(time.Time{})
+	r := &Refresher{Store: store, Clock: fake}
+
+	// 昨天 08:03 更新的数据
+	store.Swap(PeriodDaily, &Snapshot{
+		Period:    PeriodDaily,
+		UpdatedAt: time.Date(2025, 1, 5, 8, 3, 0, 0, loc),
+		Symbols:   map[string]Levels{"BTCUSDT": {}},
+	})
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{time.Date(2025, 1, 6, 8, 1, 0, 0, loc), false}, // 08:02 之前仍使用昨天的数据
+		{time.Date(2025, 1, 6, 8, 2, 0, 0, loc), true},
+		{time.Date(2025, 1, 6, 20, 0, 0, 0, loc), true},
+	}
+	for _, tt := range tests {
+		fake.Set(tt.now)
+		if got := r.needsRefresh(PeriodDaily, loc); got != tt.want {
+			t.Errorf("needsRefresh at %s = %v, want %v", tt.now.Format("2006-01-02 15:04"), got, tt.want)
+		}
 	}
 }
 
@@ -247,3 +290,192 @@ func TestProperty_StaleDetectionPersistence(t *testing.T) {
 		}
 	}
 }
+
+func TestRefresh_MaxSymbols(t *testing.T) {
+	var infos []string
+	for i := 0; i < 30; i++ {
+		infos = append(infos, fmt.Sprintf(`{"symbol":"S%02dUSDT","status":"TRADING","contractType":"PERPETUAL","quoteAsset":"USDT"}`, i))
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/exchangeInfo":
+			_, _ = w.Write([]byte(`{"symbols":[` + strings.Join(infos, ",") + `]}`))
+		case "/fapi/v1/klines":
+			_, _ = w.Write([]byte(`[
+				[1704067200000,"100.0","110.0","90.0","105.0","1",1704153599999,"0",1],
+				[1704153600000,"105.0","106.0","104.0","105.5","1",1704239999999,"0",1]
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	store := NewStore()
+	r := NewRefresher(t.TempDir(), store, binance.NewRESTClient(ts.URL))
+	r.MaxSymbols = 10
+	if err := r.Refresh(context.Background(), PeriodDaily); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	snap, _ := store.Snapshot(PeriodDaily)
+	if snap == nil || len(snap.Symbols) != 10 {
+		t.Fatalf("snapshot = %+v, want 10 symbols", snap)
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := snap.Symbols[fmt.Sprintf("S%02dUSDT", i)]; !ok {
+			t.Errorf("S%02dUSDT missing; without volumes the first symbols alphabetically are kept", i)
+		}
+	}
+
+	// Volume ranking wins, and the previous 10-symbol snapshot does not
+	// raise the minimum past a smaller limit
+	r.MaxSymbols = 5
+	r.SymbolVolume = func(symbol string) float64 {
+		var n int
+		_, _ = fmt.Sscanf(symbol, "S%dUSDT", &n)
+		return float64(n)
+	}
+	if err := r.Refresh(context.Background(), PeriodDaily); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	snap, _ = store.Snapshot(PeriodDaily)
+	if len(snap.Symbols) != 5 {
+		t.Fatalf("got %d symbols, want 5", len(snap.Symbols))
+	}
+	for i := 25; i < 30; i++ {
+		if _, ok := snap.Symbols[fmt.Sprintf("S%02dUSDT", i)]; !ok {
+			t.Errorf("S%02dUSDT missing; want the highest volumes", i)
+		}
+	}
+}
+
+func TestRefresh_MaxSymbolsWaitsForVolumes(t *testing.T) {
+	var infos []string
+	for i := 0; i < 30; i++ {
+		infos = append(infos, fmt.Sprintf(`{"symbol":"S%02dUSDT","status":"TRADING","contractType":"PERPETUAL","quoteAsset":"USDT"}`, i))
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/exchangeInfo":
+			_, _ = w.Write([]byte(`{"symbols":[` + strings.Join(infos, ",") + `]}`))
+		case "/fapi/v1/klines":
+			_, _ = w.Write([]byte(`[
+				[1704067200000,"100.0","110.0","90.0","105.0","1",1704153599999,"0",1],
+				[1704153600000,"105.0","106.0","104.0","105.5","1",1704239999999,"0",1]
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	// Like the ticker store at startup: empty until the stream connects,
+	// here after the first poll
+	var calls atomic.Int64
+	store := NewStore()
+	r := NewRefresher(t.TempDir(), store, binance.NewRESTClient(ts.URL))
+	r.Clock = clock.NewFake(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+	r.MaxSymbols = 5
+	r.VolumeWait = 5 * time.Second
+	r.SymbolVolume = func(symbol string) float64 {
+		if calls.Add(1) <= int64(len(infos)) {
+			return 0
+		}
+		var n int
+		_, _ = fmt.Sscanf(symbol, "S%dUSDT", &n)
+		return float64(n)
+	}
+	if err := r.Refresh(context.Background(), PeriodDaily); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	snap, _ := store.Snapshot(PeriodDaily)
+	for i := 25; i < 30; i++ {
+		if _, ok := snap.Symbols[fmt.Sprintf("S%02dUSDT", i)]; !ok {
+			t.Errorf("S%02dUSDT missing; want the highest volumes once they arrive", i)
+		}
+	}
+
+	// Volumes that never arrive fall back to alphabetical order once
+	// VolumeWait has passed on the refresher's clock
+	fake := clock.NewFake(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC))
+	r.Clock = fake
+	r.SymbolVolume = func(string) float64 {
+		fake.Advance(time.Second)
+		return 0
+	}
+	r.VolumeWait = time.Minute
+	if err := r.Refresh(context.Background(), PeriodWeekly); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	snap, _ = store.Snapshot(PeriodWeekly)
+	for i := 0; i < 5; i++ {
+		if _, ok := snap.Symbols[fmt.Sprintf("S%02dUSDT", i)]; !ok {
+			t.Errorf("S%02dUSDT missing; without volumes the first symbols alphabetically are kept", i)
+		}
+	}
+}
+
+func TestRefresh_FailureBackoff(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	failing := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/fapi/v1/exchangeInfo":
+			_, _ = w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","status":"TRADING","contractType":"PERPETUAL","quoteAsset":"USDT"}]}`))
+		default:
+			_, _ = w.Write([]byte(`[
+				[1704067200000,"100.0","110.0","90.0","105.0","1",1704153599999,"0",1],
+				[1704153600000,"105.0","106.0","104.0","105.5","1",1704239999999,"0",1]
+			]`))
+		}
+	}))
+	defer ts.Close()
+
+	// Monday 09:00: this week's weekly pivots are due
+	now := time.Date(2025, 1, 6, 9, 0, 0, 0, loc)
+	fake := clock.NewFake(now)
+	r := NewRefresher(t.TempDir(), NewStore(), binance.NewRESTClient(ts.URL))
+	r.Clock = fake
+	r.RetryMin = time.Minute
+	r.RetryMax = 5 * time.Minute
+
+	if !r.needsRefresh(PeriodWeekly, loc) {
+		t.Fatal("weekly pivots should be stale before the first refresh")
+	}
+
+	// Each failure doubles the delay before the period is retried, up to RetryMax
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		if err := r.Refresh(context.Background(), PeriodWeekly); err == nil {
+			t.Fatalf("attempt %d: expected an error", i+1)
+		}
+		status := r.PivotStatus().Weekly
+		if got := status.NextRefreshAt.Sub(now); got != want {
+			t.Errorf("attempt %d: next_refresh_at in %v, want %v", i+1, got, want)
+		}
+		if status.Failures != i+1 || !status.IsStale {
+			t.Errorf("attempt %d: status = %+v, want %d failures and still stale without pivots", i+1, status, i+1)
+		}
+		fake.Set(now.Add(want - time.Second))
+		if r.needsRefresh(PeriodWeekly, loc) {
+			t.Errorf("attempt %d: stale before the %v backoff elapsed", i+1, want)
+		}
+		now = now.Add(want)
+		fake.Set(now)
+		if !r.needsRefresh(PeriodWeekly, loc) {
+			t.Errorf("attempt %d: not stale after the %v backoff", i+1, want)
+		}
+	}
+
+	// A success clears the backoff
+	failing = false
+	if err := r.Refresh(context.Background(), PeriodWeekly); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if status := r.PivotStatus().Weekly; status.Failures != 0 || status.IsStale || !status.NextRefreshAt.Equal(nextRun(now, PeriodWeekly, loc)) {
+		t.Errorf("after success: status = %+v, want the scheduled run", status)
+	}
+}
@@ -0,0 +1,89 @@
+package pivot
+
+import (
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/clock"
+)
+
+// Defaults for zero Refresher.RetryMin and RetryMax.
+const (
+	DefaultRetryMin = time.Minute
+	DefaultRetryMax = time.Hour
+)
+
+// refreshAttempt tracks the failed refreshes of one period since its last
+// success.
+type refreshAttempt struct {
+	last     time.Time // Time of the last failed attempt
+	failures int       // Consecutive failures
+}
+
+// recordAttempt notes the outcome of a refresh of period at now.
+func (r *Refresher) recordAttempt(period Period, now time.Time, err error) {
+	r.attemptMu.Lock()
+	defer r.attemptMu.Unlock()
+	if err == nil {
+		delete(r.attempts, period)
+		return
+	}
+	if r.attempts == nil {
+		r.attempts = make(map[Period]refreshAttempt)
+	}
+	a := r.attempts[period]
+	a.last = now
+	a.failures++
+	r.attempts[period] = a
+}
+
+// retryAt returns when period may be retried after its consecutive
+// failures: RetryMin after the first, doubling up to RetryMax. ok is false
+// when the last refresh succeeded (or none failed yet).
+func (r *Refresher) retryAt(period Period) (at time.Time, failures int, ok bool) {
+	r.attemptMu.Lock()
+	a, ok := r.attempts[period]
+	r.attemptMu.Unlock()
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	return a.last.Add(r.retryDelay(a.failures)), a.failures, true
+}
+
+// retryDelay is the backoff after n consecutive failures.
+func (r *Refresher) retryDelay(n int) time.Duration {
+	lo, hi := r.RetryMin, r.RetryMax
+	if lo <= 0 {
+		lo = DefaultRetryMin
+	}
+	if hi <= 0 {
+		hi = DefaultRetryMax
+	}
+	d := lo
+	for i := 1; i < n && d < hi; i++ {
+		d *= 2
+	}
+	if d > hi {
+		d = hi
+	}
+	return d
+}
+
+// backingOff reports whether period failed recently enough that it must
+// not be retried (nor reported stale) yet.
+func (r *Refresher) backingOff(period Period) bool {
+	at, _, ok := r.retryAt(period)
+	return ok && clock.Or(r.Clock).Now().Before(at)
+}
+
+// nextRefresh returns when period is refreshed next after now: the
+// scheduled 08:02 run, or the retry after a failure if that is sooner.
+func (r *Refresher) nextRefresh(now time.Time, period Period, loc *time.Location) time.Time {
+	next := nextRun(now, period, loc)
+	if at, _, ok := r.retryAt(period); ok && at.Before(next) {
+		next = at.In(loc)
+		if next.Before(now) {
+			next = now
+		}
+	}
+	return next
+}
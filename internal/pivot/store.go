@@ -2,8 +2,11 @@ package pivot
 
 import (
 	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
 )
 
 type Period string
@@ -22,10 +25,18 @@ type Snapshot struct {
 type Store struct {
 	daily  atomic.Value
 	weekly atomic.Value
+
+	// Past snapshots per period, oldest first (see history.go)
+	histMu  sync.Mutex
+	histMax int
+	history map[Period][]*Snapshot
 }
 
 func NewStore() *Store {
-	s := &Store{}
+	s := &Store{
+		histMax: DefaultHistorySize,
+		history: make(map[Period][]*Snapshot),
+	}
 	s.daily.Store((*Snapshot)(nil))
 	s.weekly.Store((*Snapshot)(nil))
 	return s
@@ -65,6 +76,6 @@ func (s *Store) GetLevels(period Period, symbol string) (Levels, bool) {
 	if err != nil || snap == nil {
 		return Levels{}, false
 	}
-	lv, ok := snap.Symbols[symbol]
+	lv, ok := snap.Symbols[binance.NormalizeSymbol(symbol)]
 	return lv, ok
 }
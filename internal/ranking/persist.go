@@ -1,7 +1,9 @@
 package ranking
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -11,8 +13,15 @@ import (
 const (
 	rankingSubDir  = "ranking"
 	snapshotsFile  = "snapshots.json"
+	compressedExt  = ".gz"
 )
 
+// snapshotPaths returns the plain and gzip-compressed snapshot file paths.
+func (s *Store) snapshotPaths() (plain, compressed string) {
+	plain = filepath.Join(s.dataDir, rankingSubDir, snapshotsFile)
+	return plain, plain + compressedExt
+}
+
 // persistedData is the structure for persisted ranking data.
 type persistedData struct {
 	Snapshots []*Snapshot `json:"snapshots"`
@@ -21,7 +30,7 @@ type persistedData struct {
 
 // Persist saves the current snapshots to disk.
 func (s *Store) Persist() error {
-	if s.dataDir == "" {
+	if s.dataDir == "" || s.ReadOnly {
 		return nil // No persistence configured
 	}
 
@@ -40,30 +49,115 @@ func (s *Store) Persist() error {
 	}
 
 	// Write to temp file first, then rename for atomicity
-	filePath := filepath.Join(dir, snapshotsFile)
+	filePath, stalePath := s.snapshotPaths()
+	if s.Compress {
+		filePath, stalePath = stalePath, filePath
+	}
 	tempPath := filePath + ".tmp"
 
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+	var jsonData []byte
+	var err error
+	if s.Compress {
+		jsonData, err = json.Marshal(data)
+	} else {
+		jsonData, err = json.MarshalIndent(data, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.Compress {
+		err = writeGzipFile(tempPath, jsonData)
+	} else {
+		err = os.WriteFile(tempPath, jsonData, 0644)
+	}
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(tempPath, jsonData, 0644); err != nil {
+	if err := os.Rename(tempPath, filePath); err != nil {
 		return err
 	}
 
-	return os.Rename(tempPath, filePath)
+	// Drop the file in the other format so Load never sees stale data.
+	if err := os.Remove(stalePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
-// Load loads snapshots from disk.
+// writeGzipFile writes data gzip-compressed to path.
+func writeGzipFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readSnapshotFile reads path, decompressing it when it has a .gz extension.
+func readSnapshotFile(path string) ([]byte, error) {
+	if filepath.Ext(path) != compressedExt {
+		return os.ReadFile(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// Clear removes all snapshots and deletes the persisted file, if any and
+// not ReadOnly. It returns how many snapshots were removed.
+func (s *Store) Clear() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.snapshots)
+	s.snapshots = make([]*Snapshot, 0)
+	s.invalidateCacheLocked()
+
+	if s.dataDir == "" || s.ReadOnly {
+		return n, nil
+	}
+	plain, compressed := s.snapshotPaths()
+	for _, path := range []string{plain, compressed} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Load loads snapshots from disk. A gzip-compressed file (.gz) is preferred
+// over the plain one, regardless of the Compress setting.
 func (s *Store) Load() error {
 	if s.dataDir == "" {
 		return nil // No persistence configured
 	}
 
-	filePath := filepath.Join(s.dataDir, rankingSubDir, snapshotsFile)
+	plain, compressed := s.snapshotPaths()
+	filePath := compressed
+	if _, err := os.Stat(compressed); os.IsNotExist(err) {
+		filePath = plain
+	}
 
-	jsonData, err := os.ReadFile(filePath)
+	jsonData, err := readSnapshotFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // No data file yet
@@ -82,6 +176,7 @@ func (s *Store) Load() error {
 	// Load snapshots and cleanup old ones
 	s.snapshots = data.Snapshots
 	s.cleanupLocked()
+	s.invalidateCacheLocked()
 
 	log.Printf("ranking store: loaded %d snapshots from disk", len(s.snapshots))
 	return nil
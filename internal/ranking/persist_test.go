@@ -77,6 +77,64 @@ func TestPersistAndLoad(t *testing.T) {
 	}
 }
 
+func TestPersistCompressed(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewStore(tmpDir, 24*time.Hour)
+	store.Compress = true
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		store.Add(&Snapshot{
+			Timestamp: now.Add(time.Duration(i-3) * 5 * time.Minute),
+			Items: map[string]*SnapshotItem{
+				"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, TradesRank: 2, Price: 100 + float64(i), Volume: 1000, TradeCount: 500},
+				"ETHUSDT": {Symbol: "ETHUSDT", VolumeRank: 2, TradesRank: 1, Price: 50, Volume: 800, TradeCount: 600 + int64(i)},
+			},
+		})
+	}
+
+	// A stale plain file must be replaced by the compressed one
+	plainPath := filepath.Join(tmpDir, "ranking", "snapshots.json")
+	if err := NewStore(tmpDir, 24*time.Hour).Persist(); err != nil {
+		t.Fatalf("plain Persist failed: %v", err)
+	}
+	if err := store.Persist(); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	if _, err := os.Stat(plainPath + ".gz"); err != nil {
+		t.Fatalf("compressed file not created: %v", err)
+	}
+	if _, err := os.Stat(plainPath); !os.IsNotExist(err) {
+		t.Errorf("plain file still present after compressed Persist: %v", err)
+	}
+
+	// Loading does not depend on the Compress setting
+	store2 := NewStore(tmpDir, 24*time.Hour)
+	if err := store2.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if store2.Count() != 3 {
+		t.Fatalf("Expected 3 snapshots after load, got %d", store2.Count())
+	}
+	latest := store2.Latest()
+	if len(latest.Items) != 2 {
+		t.Fatalf("Expected 2 items in latest snapshot, got %d", len(latest.Items))
+	}
+	if btc := latest.Items["BTCUSDT"]; btc == nil || btc.Price != 102 || btc.TradesRank != 2 {
+		t.Errorf("BTCUSDT = %+v, want Price 102, TradesRank 2", btc)
+	}
+	if eth := latest.Items["ETHUSDT"]; eth == nil || eth.TradeCount != 602 || eth.Volume != 800 {
+		t.Errorf("ETHUSDT = %+v, want TradeCount 602, Volume 800", eth)
+	}
+
+	if _, err := store2.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := os.Stat(plainPath + ".gz"); !os.IsNotExist(err) {
+		t.Errorf("compressed file still present after Clear: %v", err)
+	}
+}
+
 func TestLoadNonExistent(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "ranking-test-*")
 	if err != nil {
@@ -114,6 +172,64 @@ func TestPersistNoDataDir(t *testing.T) {
 // TestPersistenceRoundTripProperty tests the persistence round trip property.
 // Property 9: Persistence Round Trip
 // Validates: Requirements 10.1, 10.2
+func TestClear(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewStore(tmpDir, 24*time.Hour)
+	store.Add(&Snapshot{Timestamp: time.Now(), Items: map[string]*SnapshotItem{
+		"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, TradesRank: 1},
+	}})
+	if err := store.Persist(); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+
+	if n, err := store.Clear(); err != nil || n != 1 {
+		t.Fatalf("Clear = %d, %v; want 1, nil", n, err)
+	}
+	if store.Count() != 0 || store.Latest() != nil {
+		t.Errorf("store not empty after Clear: Count = %d", store.Count())
+	}
+
+	reloaded := NewStore(tmpDir, 24*time.Hour)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if reloaded.Count() != 0 {
+		t.Errorf("Count after reload = %d, want 0", reloaded.Count())
+	}
+}
+
+func TestClearReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	primary := NewStore(tmpDir, 24*time.Hour)
+	primary.Add(&Snapshot{Timestamp: time.Now(), Items: map[string]*SnapshotItem{
+		"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, TradesRank: 1},
+	}})
+	if err := primary.Persist(); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+
+	// A replica loads the primary's file; clearing it must not delete it
+	replica := NewStore(tmpDir, 24*time.Hour)
+	replica.ReadOnly = true
+	if err := replica.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if n, err := replica.Clear(); err != nil || n != 1 || replica.Count() != 0 {
+		t.Fatalf("Clear = %d, %v (Count %d); want 1, nil (0)", n, err, replica.Count())
+	}
+	if err := replica.Persist(); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+
+	reloaded := NewStore(tmpDir, 24*time.Hour)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if reloaded.Count() != 1 {
+		t.Errorf("primary's file has %d snapshots after replica Clear, want 1", reloaded.Count())
+	}
+}
+
 func TestPersistenceRoundTripProperty(t *testing.T) {
 	property := func(count uint8) bool {
 		if count == 0 {
@@ -1,8 +1,11 @@
 package ranking
 
 import (
+	"sort"
 	"sync"
 	"time"
+
+	"example.com/binance-pivot-monitor/internal/clock"
 )
 
 const (
@@ -12,10 +15,28 @@ const (
 
 // Store stores and manages ranking snapshots.
 type Store struct {
+	// Clock supplies the current time for default timestamps and retention.
+	// Nil means the wall clock.
+	Clock clock.Clock
+
+	// Compress makes Persist write gzip-compressed snapshots
+	// (snapshots.json.gz). Load detects the format by extension either way.
+	Compress bool
+
+	// ReadOnly keeps Persist and Clear off the data directory, for replicas
+	// that only read the primary's snapshot files: Clear empties memory only.
+	ReadOnly bool
+
 	mu        sync.RWMutex
 	snapshots []*Snapshot // Ordered by timestamp, newest at the end
 	maxAge    time.Duration
 	dataDir   string
+
+	// cache holds ranking items (sorted by rank) of the latest snapshot
+	// compared with the previous one, per ranking type. Built on first read
+	// and invalidated whenever snapshots change.
+	cacheMu sync.Mutex
+	cache   map[string][]RankingItem
 }
 
 // NewStore creates a new ranking store.
@@ -43,11 +64,47 @@ func (s *Store) Add(snapshot *Snapshot) {
 
 	// Set timestamp if not set
 	if snapshot.Timestamp.IsZero() {
-		snapshot.Timestamp = time.Now()
+		snapshot.Timestamp = clock.Or(s.Clock).Now()
 	}
 
 	s.snapshots = append(s.snapshots, snapshot)
 	s.cleanupLocked()
+	s.invalidateCacheLocked()
+}
+
+// invalidateCacheLocked drops cached ranking items.
+// Must be called with s.mu write lock held.
+func (s *Store) invalidateCacheLocked() {
+	s.cacheMu.Lock()
+	s.cache = nil
+	s.cacheMu.Unlock()
+}
+
+// latestItemsLocked returns the latest snapshot's items compared with the
+// previous snapshot, sorted by rank, building and caching them on first use.
+// The returned slice is shared and must not be modified.
+// Must be called with s.mu read lock held and at least one snapshot.
+func (s *Store) latestItemsLocked(rankType string) []RankingItem {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if items, ok := s.cache[rankType]; ok {
+		return items
+	}
+
+	current := s.snapshots[len(s.snapshots)-1]
+	var compare *Snapshot
+	if len(s.snapshots) >= 2 {
+		compare = s.snapshots[len(s.snapshots)-2]
+	}
+	items := s.buildRankingItems(current, compare, rankType)
+	sortRankingItemsByRank(items)
+
+	if s.cache == nil {
+		s.cache = make(map[string][]RankingItem)
+	}
+	s.cache[rankType] = items
+	return items
 }
 
 // cleanup removes snapshots older than maxAge.
@@ -57,7 +114,7 @@ func (s *Store) cleanupLocked() {
 		return
 	}
 
-	cutoff := time.Now().Add(-s.maxAge)
+	cutoff := clock.Or(s.Clock).Now().Add(-s.maxAge)
 	firstValid := 0
 
 	for i, snap := range s.snapshots {
@@ -78,6 +135,7 @@ func (s *Store) Cleanup() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.cleanupLocked()
+	s.invalidateCacheLocked()
 }
 
 // Latest returns the most recent snapshot, or nil if none.
@@ -165,28 +223,49 @@ func (s *Store) GetCurrent(opts CurrentOptions) *CurrentResponse {
 	// Get the latest snapshot
 	current := s.snapshots[len(s.snapshots)-1]
 
-	// Find comparison snapshot
+	// Find comparison snapshot and build items sorted by rank
 	var compare *Snapshot
+	var items []RankingItem
 	if opts.Compare > 0 {
 		// Find snapshot at targetTime = now - compare duration
 		targetTime := current.Timestamp.Add(-opts.Compare)
 		compare = s.findSnapshotByTimeLocked(targetTime)
+		items = s.buildRankingItems(current, compare, opts.Type)
+		sortRankingItemsByRank(items)
 	} else {
-		// Use previous snapshot
+		// Use previous snapshot (cached)
 		if len(s.snapshots) >= 2 {
 			compare = s.snapshots[len(s.snapshots)-2]
 		}
+		items = s.latestItemsLocked(opts.Type)
 	}
 
-	// Build response items
-	items := s.buildRankingItems(current, compare, opts.Type)
+	// Drop illiquid symbols after ranking, so ranks stay global (filters into
+	// a new slice, leaving the cached one intact)
+	if opts.MinVolume > 0 {
+		filtered := make([]RankingItem, 0, len(items))
+		for _, it := range items {
+			if it.Volume >= opts.MinVolume {
+				filtered = append(filtered, it)
+			}
+		}
+		items = filtered
+	}
 
-	// Sort by rank
-	sortRankingItemsByRank(items)
+	// Re-sort a copy if a non-default order was requested (items are sorted by rank)
+	if less := currentComparator(opts.Sort, opts.Order); less != nil {
+		items = append(make([]RankingItem, 0, len(items)), items...)
+		sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+	}
 
-	// Apply limit
-	if opts.Limit > 0 && len(items) > opts.Limit {
-		items = items[:opts.Limit]
+	// Apply limit (copy so callers never share the cached slice)
+	n := len(items)
+	if opts.Limit > 0 && n > opts.Limit {
+		n = opts.Limit
+	}
+	items = append(make([]RankingItem, 0, n), items[:n]...)
+	if !opts.IncludePrev {
+		clearPrevValues(items)
 	}
 
 	resp := &CurrentResponse{
@@ -200,6 +279,59 @@ func (s *Store) GetCurrent(opts CurrentOptions) *CurrentResponse {
 	return resp
 }
 
+// clearPrevValues removes comparison snapshot values so they are omitted
+// from responses that did not ask for them.
+func clearPrevValues(items []RankingItem) {
+	for i := range items {
+		items[i].PrevRank = nil
+		items[i].PrevPrice = nil
+		items[i].PrevVolume = nil
+	}
+}
+
+// currentComparator returns the less function for a sort field and order, or
+// nil for the default order (rank ascending). Items with a nil change value
+// always sort last; ties keep rank order.
+func currentComparator(field, order string) func(a, b RankingItem) bool {
+	if field == "" {
+		field = SortRank
+	}
+	desc := order == OrderDesc
+	if order == "" && field != SortRank {
+		desc = true // Largest changes first by default
+	}
+
+	if field == SortRank {
+		if !desc {
+			return nil
+		}
+		return func(a, b RankingItem) bool { return a.Rank > b.Rank }
+	}
+
+	var value func(RankingItem) *float64
+	switch field {
+	case SortPriceChange:
+		value = func(it RankingItem) *float64 { return it.PriceChange }
+	case SortVolumeChange:
+		value = func(it RankingItem) *float64 { return it.VolumeChange }
+	case SortTradeChange:
+		value = func(it RankingItem) *float64 { return it.TradeChange }
+	default:
+		return nil
+	}
+
+	return func(a, b RankingItem) bool {
+		va, vb := value(a), value(b)
+		if va == nil || vb == nil {
+			return va != nil && vb == nil
+		}
+		if desc {
+			return *va > *vb
+		}
+		return *va < *vb
+	}
+}
+
 // findSnapshotByTimeLocked finds snapshot by time (must hold read lock).
 func (s *Store) findSnapshotByTimeLocked(targetTime time.Time) *Snapshot {
 	if len(s.snapshots) == 0 {
@@ -256,6 +388,12 @@ func (s *Store) buildRankingItems(current, compare *Snapshot, rankType string) [
 				rankChange := prevRank - ri.Rank
 				ri.RankChange = &rankChange
 
+				// Raw values from the comparison snapshot
+				prevPrice, prevVolume := prevItem.Price, prevItem.Volume
+				ri.PrevRank = &prevRank
+				ri.PrevPrice = &prevPrice
+				ri.PrevVolume = &prevVolume
+
 				// Calculate price change percentage
 				if prevItem.Price > 0 {
 					priceChange := ((item.Price - prevItem.Price) / prevItem.Price) * 100
@@ -324,6 +462,26 @@ func (s *Store) GetHistory(symbol string) *HistoryResponse {
 	return resp
 }
 
+// GetHistoryDownsampled is like GetHistory but keeps only the last snapshot
+// in each interval-sized time bucket (aligned to the Unix epoch), still in
+// chronological order. A non-positive interval returns every snapshot.
+func (s *Store) GetHistoryDownsampled(symbol string, interval time.Duration) *HistoryResponse {
+	resp := s.GetHistory(symbol)
+	if interval <= 0 || len(resp.Snapshots) == 0 {
+		return resp
+	}
+
+	out := resp.Snapshots[:0]
+	for i, snap := range resp.Snapshots {
+		last := i == len(resp.Snapshots)-1
+		if last || !resp.Snapshots[i+1].Timestamp.Truncate(interval).Equal(snap.Timestamp.Truncate(interval)) {
+			out = append(out, snap)
+		}
+	}
+	resp.Snapshots = out
+	return resp
+}
+
 // GetMovers returns symbols with the largest rank changes.
 func (s *Store) GetMovers(opts MoversOptions) *MoversResponse {
 	s.mu.RLock()
@@ -359,7 +517,21 @@ func (s *Store) GetMovers(opts MoversOptions) *MoversResponse {
 	resp.CompareTo = compare.Timestamp
 
 	// Build ranking items with changes
-	items := s.buildRankingItems(current, compare, opts.Type)
+	var items []RankingItem
+	if opts.Compare > 0 {
+		items = s.buildRankingItems(current, compare, opts.Type)
+	} else {
+		items = s.latestItemsLocked(opts.Type)
+	}
+
+	// Restrict to the requested symbols; ranks stay global
+	var only map[string]struct{}
+	if len(opts.Symbols) > 0 {
+		only = make(map[string]struct{}, len(opts.Symbols))
+		for _, sym := range opts.Symbols {
+			only[sym] = struct{}{}
+		}
+	}
 
 	// Filter by direction and collect movers
 	var movers []RankingItem
@@ -367,7 +539,15 @@ func (s *Store) GetMovers(opts MoversOptions) *MoversResponse {
 		if item.RankChange == nil {
 			continue // Skip new symbols
 		}
+		if only != nil {
+			if _, ok := only[item.Symbol]; !ok {
+				continue
+			}
+		}
 		change := *item.RankChange
+		if opts.MinChange > 0 && change < opts.MinChange && change > -opts.MinChange {
+			continue
+		}
 		if opts.Direction == DirectionUp && change > 0 {
 			movers = append(movers, item)
 		} else if opts.Direction == DirectionDown && change < 0 {
@@ -382,6 +562,9 @@ func (s *Store) GetMovers(opts MoversOptions) *MoversResponse {
 	if opts.Limit > 0 && len(movers) > opts.Limit {
 		movers = movers[:opts.Limit]
 	}
+	if !opts.IncludePrev {
+		clearPrevValues(movers)
+	}
 
 	resp.Items = movers
 	return resp
@@ -1,9 +1,12 @@
 package ranking
 
 import (
+	"fmt"
 	"testing"
 	"testing/quick"
 	"time"
+
+	"example.com/binance-pivot-monitor/internal/clock"
 )
 
 func TestStoreAddAndLatest(t *testing.T) {
@@ -134,6 +137,7 @@ func TestRetentionWindowProperty(t *testing.T) {
 		maxAge := 1 * time.Hour // Use 1 hour for faster testing
 		store := NewStore("", maxAge)
 		baseTime := time.Now()
+		store.Clock = clock.NewFake(baseTime)
 
 		// Add snapshots in chronological order (oldest first, like real sampling)
 		// This simulates snapshots being added every 5 minutes over time
@@ -148,11 +152,9 @@ func TestRetentionWindowProperty(t *testing.T) {
 		}
 
 		// Verify all remaining snapshots are within maxAge from now
-		now := time.Now()
-		cutoff := now.Add(-maxAge)
+		cutoff := baseTime.Add(-maxAge)
 		for _, snap := range store.All() {
-			// Allow tolerance for test execution time (up to 5 seconds)
-			if snap.Timestamp.Before(cutoff.Add(-5 * time.Second)) {
+			if snap.Timestamp.Before(cutoff) {
 				return false // Found a snapshot older than maxAge
 			}
 		}
@@ -605,6 +607,44 @@ func TestHistoryChronologicalProperty(t *testing.T) {
 	}
 }
 
+// TestGetHistoryDownsampled tests bucketing 5m history into 30m intervals.
+func TestGetHistoryDownsampled(t *testing.T) {
+	base := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(base)
+	store := NewStore("", 24*time.Hour)
+	store.Clock = fake
+
+	// 3 hours of 5m snapshots: 00:00 .. 02:55
+	for i := 0; i < 36; i++ {
+		fake.Set(base.Add(time.Duration(i*5) * time.Minute))
+		store.Add(&Snapshot{
+			Timestamp: fake.Now(),
+			Items:     map[string]*SnapshotItem{"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: i + 1}},
+		})
+	}
+
+	if got := len(store.GetHistoryDownsampled("BTCUSDT", 0).Snapshots); got != 36 {
+		t.Errorf("interval 0: got %d snapshots, want 36", got)
+	}
+
+	resp := store.GetHistoryDownsampled("BTCUSDT", 30*time.Minute)
+	if len(resp.Snapshots) != 6 {
+		t.Fatalf("interval 30m: got %d snapshots, want 6", len(resp.Snapshots))
+	}
+	for i, snap := range resp.Snapshots {
+		// Last snapshot of each bucket: 00:25, 00:55, ...
+		want := base.Add(time.Duration(i*30+25) * time.Minute)
+		if !snap.Timestamp.Equal(want) {
+			t.Errorf("snapshot %d at %s, want %s", i, snap.Timestamp.Format("15:04"), want.Format("15:04"))
+		}
+	}
+
+	// Downsampling must not affect later full reads
+	if got := len(store.GetHistory("BTCUSDT").Snapshots); got != 36 {
+		t.Errorf("GetHistory after downsampling: got %d snapshots, want 36", got)
+	}
+}
+
 // TestGetMoversBasic tests basic GetMovers functionality.
 func TestGetMoversBasic(t *testing.T) {
 	store := NewStore("", 24*time.Hour)
@@ -706,6 +746,79 @@ func TestGetMoversWithLimit(t *testing.T) {
 	}
 }
 
+// TestGetMoversWithSymbols tests restricting movers to a watchlist.
+func TestGetMoversWithSymbols(t *testing.T) {
+	store := NewStore("", 24*time.Hour)
+	now := time.Now()
+
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-10 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT":  {Symbol: "BTCUSDT", VolumeRank: 10},
+			"ETHUSDT":  {Symbol: "ETHUSDT", VolumeRank: 20},
+			"DOGEUSDT": {Symbol: "DOGEUSDT", VolumeRank: 40},
+		},
+	})
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-5 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT":  {Symbol: "BTCUSDT", VolumeRank: 5},  // Up 5
+			"ETHUSDT":  {Symbol: "ETHUSDT", VolumeRank: 12}, // Up 8
+			"DOGEUSDT": {Symbol: "DOGEUSDT", VolumeRank: 1}, // Up 39, not in watchlist
+		},
+	})
+
+	resp := store.GetMovers(MoversOptions{
+		Type:      RankingTypeVolume,
+		Direction: DirectionUp,
+		Symbols:   []string{"BTCUSDT", "ETHUSDT"},
+	})
+	if len(resp.Items) != 2 {
+		t.Fatalf("Expected 2 movers in watchlist, got %d", len(resp.Items))
+	}
+	for _, item := range resp.Items {
+		if item.Symbol == "DOGEUSDT" {
+			t.Error("DOGEUSDT is not in the watchlist and must not appear")
+		}
+	}
+	if resp.Items[0].Symbol != "ETHUSDT" || resp.Items[0].Rank != 12 {
+		t.Errorf("Expected ETHUSDT with global rank 12 first, got %s rank %d", resp.Items[0].Symbol, resp.Items[0].Rank)
+	}
+}
+
+// TestGetMoversMinChange tests dropping small rank moves before the limit.
+func TestGetMoversMinChange(t *testing.T) {
+	store := NewStore("", 24*time.Hour)
+	now := time.Now()
+
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-10 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT":  {Symbol: "BTCUSDT", VolumeRank: 10},
+			"ETHUSDT":  {Symbol: "ETHUSDT", VolumeRank: 20},
+			"DOGEUSDT": {Symbol: "DOGEUSDT", VolumeRank: 30},
+		},
+	})
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-5 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT":  {Symbol: "BTCUSDT", VolumeRank: 9},   // Up 1
+			"ETHUSDT":  {Symbol: "ETHUSDT", VolumeRank: 17},  // Up 3
+			"DOGEUSDT": {Symbol: "DOGEUSDT", VolumeRank: 23}, // Up 7
+		},
+	})
+
+	resp := store.GetMovers(MoversOptions{Type: RankingTypeVolume, Direction: DirectionUp, MinChange: 5, Limit: 2})
+	if len(resp.Items) != 1 || resp.Items[0].Symbol != "DOGEUSDT" {
+		t.Fatalf("Expected only DOGEUSDT with min change 5, got %+v", resp.Items)
+	}
+
+	resp = store.GetMovers(MoversOptions{Type: RankingTypeVolume, Direction: DirectionUp})
+	if len(resp.Items) != 3 {
+		t.Errorf("Expected 3 movers without min change, got %d", len(resp.Items))
+	}
+}
+
 // TestMoversSortingProperty tests the movers sorting property.
 // Property 8: Movers Sorting
 // Validates: Requirements 7.5
@@ -765,3 +878,273 @@ func TestMoversSortingProperty(t *testing.T) {
 		t.Errorf("Movers sorting property failed: %v", err)
 	}
 }
+
+// TestGetCurrentCacheInvalidatedOnAdd tests that cached items follow new snapshots.
+func TestGetCurrentCacheInvalidatedOnAdd(t *testing.T) {
+	store := NewStore("", 24*time.Hour)
+	now := time.Now()
+
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-10 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, Price: 100},
+			"ETHUSDT": {Symbol: "ETHUSDT", VolumeRank: 2, Price: 50},
+		},
+	})
+
+	resp := store.GetCurrent(CurrentOptions{Type: RankingTypeVolume})
+	if resp.Items[0].Symbol != "BTCUSDT" || resp.Items[0].RankChange != nil {
+		t.Fatalf("unexpected first item %+v", resp.Items[0])
+	}
+
+	// Mutating the response must not affect the cache
+	resp.Items[0].Symbol = "XXX"
+
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-5 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 2, Price: 100},
+			"ETHUSDT": {Symbol: "ETHUSDT", VolumeRank: 1, Price: 50},
+		},
+	})
+
+	resp = store.GetCurrent(CurrentOptions{Type: RankingTypeVolume})
+	if resp.Items[0].Symbol != "ETHUSDT" {
+		t.Errorf("Expected ETHUSDT first after Add, got %s", resp.Items[0].Symbol)
+	}
+	if resp.Items[0].RankChange == nil || *resp.Items[0].RankChange != 1 {
+		t.Errorf("Expected rank change 1, got %v", resp.Items[0].RankChange)
+	}
+
+	// Cached and on-demand paths agree
+	again := store.GetCurrent(CurrentOptions{Type: RankingTypeVolume, Limit: 1})
+	if len(again.Items) != 1 || again.Items[0].Symbol != "ETHUSDT" {
+		t.Errorf("Expected cached ETHUSDT with limit 1, got %+v", again.Items)
+	}
+	movers := store.GetMovers(MoversOptions{Type: RankingTypeVolume, Direction: DirectionUp})
+	if len(movers.Items) != 1 || movers.Items[0].Symbol != "ETHUSDT" {
+		t.Errorf("Expected ETHUSDT as up mover, got %+v", movers.Items)
+	}
+}
+
+func benchmarkRankingStore(symbols int) *Store {
+	store := NewStore("", 24*time.Hour)
+	now := time.Now()
+	for s := 0; s < 2; s++ {
+		items := make(map[string]*SnapshotItem, symbols)
+		for i := 0; i < symbols; i++ {
+			sym := fmt.Sprintf("SYM%dUSDT", i)
+			rank := i + 1
+			if s == 1 {
+				rank = symbols - i
+			}
+			items[sym] = &SnapshotItem{Symbol: sym, VolumeRank: rank, TradesRank: rank, Price: float64(i + 1), Volume: 1000, TradeCount: 100}
+		}
+		store.Add(&Snapshot{Timestamp: now.Add(time.Duration(s-2) * 5 * time.Minute), Items: items})
+	}
+	return store
+}
+
+// BenchmarkGetCurrent_Cached reads the cached latest-vs-previous items.
+func BenchmarkGetCurrent_Cached(b *testing.B) {
+	store := benchmarkRankingStore(500)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.GetCurrent(CurrentOptions{Type: RankingTypeVolume})
+	}
+}
+
+// BenchmarkGetCurrent_Compare builds items on demand (compare-by-duration path).
+func BenchmarkGetCurrent_Compare(b *testing.B) {
+	store := benchmarkRankingStore(500)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store.GetCurrent(CurrentOptions{Type: RankingTypeVolume, Compare: 5 * time.Minute})
+	}
+}
+
+// TestGetCurrentSortByPriceChange tests sorting by price change with new symbols last.
+func TestGetCurrentSortByPriceChange(t *testing.T) {
+	store := NewStore("", 24*time.Hour)
+	now := time.Now()
+
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-10 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 1, Price: 100},
+			"ETHUSDT": {Symbol: "ETHUSDT", VolumeRank: 2, Price: 100},
+			"SOLUSDT": {Symbol: "SOLUSDT", VolumeRank: 3, Price: 100},
+		},
+	})
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-5 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT":  {Symbol: "BTCUSDT", VolumeRank: 1, Price: 101}, // +1%
+			"NEWUSDT":  {Symbol: "NEWUSDT", VolumeRank: 2, Price: 10},  // new
+			"ETHUSDT":  {Symbol: "ETHUSDT", VolumeRank: 3, Price: 95},  // -5%
+			"SOLUSDT":  {Symbol: "SOLUSDT", VolumeRank: 4, Price: 110}, // +10%
+			"NEW2USDT": {Symbol: "NEW2USDT", VolumeRank: 5, Price: 1},  // new
+		},
+	})
+
+	check := func(opts CurrentOptions, want []string) {
+		t.Helper()
+		resp := store.GetCurrent(opts)
+		if len(resp.Items) != len(want) {
+			t.Fatalf("%+v: got %d items, want %d", opts, len(resp.Items), len(want))
+		}
+		for i, sym := range want {
+			if resp.Items[i].Symbol != sym {
+				t.Errorf("%+v: item %d = %s, want %s", opts, i, resp.Items[i].Symbol, sym)
+			}
+		}
+	}
+
+	// Descending, nil changes last (in rank order)
+	check(CurrentOptions{Type: RankingTypeVolume, Sort: SortPriceChange, Order: OrderDesc},
+		[]string{"SOLUSDT", "BTCUSDT", "ETHUSDT", "NEWUSDT", "NEW2USDT"})
+
+	// Ascending keeps nil changes last
+	check(CurrentOptions{Type: RankingTypeVolume, Sort: SortPriceChange, Order: OrderAsc},
+		[]string{"ETHUSDT", "BTCUSDT", "SOLUSDT", "NEWUSDT", "NEW2USDT"})
+
+	// Limit applies after sorting
+	check(CurrentOptions{Type: RankingTypeVolume, Sort: SortPriceChange, Order: OrderDesc, Limit: 2},
+		[]string{"SOLUSDT", "BTCUSDT"})
+
+	// Default remains rank ascending
+	check(CurrentOptions{Type: RankingTypeVolume},
+		[]string{"BTCUSDT", "NEWUSDT", "ETHUSDT", "SOLUSDT", "NEW2USDT"})
+
+	// Rank descending
+	check(CurrentOptions{Type: RankingTypeVolume, Sort: SortRank, Order: OrderDesc},
+		[]string{"NEW2USDT", "SOLUSDT", "ETHUSDT", "NEWUSDT", "BTCUSDT"})
+}
+
+// TestGetCurrentIncludePrev tests that previous values match the compare snapshot.
+func TestGetCurrentIncludePrev(t *testing.T) {
+	store := NewStore("", 24*time.Hour)
+	now := time.Now()
+
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-10 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 5, TradesRank: 3, Price: 100, Volume: 1000},
+		},
+	})
+	store.Add(&Snapshot{
+		Timestamp: now.Add(-5 * time.Minute),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT": {Symbol: "BTCUSDT", VolumeRank: 2, TradesRank: 1, Price: 110, Volume: 1500},
+			"NEWUSDT": {Symbol: "NEWUSDT", VolumeRank: 1, TradesRank: 2, Price: 1, Volume: 2000},
+		},
+	})
+
+	resp := store.GetCurrent(CurrentOptions{Type: RankingTypeVolume, IncludePrev: true})
+	var btc, newSym RankingItem
+	for _, it := range resp.Items {
+		switch it.Symbol {
+		case "BTCUSDT":
+			btc = it
+		case "NEWUSDT":
+			newSym = it
+		}
+	}
+
+	if btc.PrevRank == nil || *btc.PrevRank != 5 {
+		t.Errorf("PrevRank = %v, want 5", btc.PrevRank)
+	}
+	if btc.PrevPrice == nil || *btc.PrevPrice != 100 {
+		t.Errorf("PrevPrice = %v, want 100", btc.PrevPrice)
+	}
+	if btc.PrevVolume == nil || *btc.PrevVolume != 1000 {
+		t.Errorf("PrevVolume = %v, want 1000", btc.PrevVolume)
+	}
+	if newSym.PrevRank != nil || newSym.PrevPrice != nil || newSym.PrevVolume != nil {
+		t.Errorf("new symbol should have no previous values: %+v", newSym)
+	}
+
+	// Trades ranking uses the trades rank of the compare snapshot
+	resp = store.GetCurrent(CurrentOptions{Type: RankingTypeTrades, IncludePrev: true})
+	for _, it := range resp.Items {
+		if it.Symbol == "BTCUSDT" && (it.PrevRank == nil || *it.PrevRank != 3) {
+			t.Errorf("trades PrevRank = %v, want 3", it.PrevRank)
+		}
+	}
+
+	// Omitted by default, also after a request that included them (shared cache)
+	resp = store.GetCurrent(CurrentOptions{Type: RankingTypeVolume})
+	for _, it := range resp.Items {
+		if it.PrevRank != nil || it.PrevPrice != nil || it.PrevVolume != nil {
+			t.Errorf("previous values should be omitted by default: %+v", it)
+		}
+	}
+
+	movers := store.GetMovers(MoversOptions{Type: RankingTypeVolume, Direction: DirectionUp, IncludePrev: true})
+	if len(movers.Items) != 1 || movers.Items[0].PrevRank == nil || *movers.Items[0].PrevRank != 5 {
+		t.Errorf("movers PrevRank mismatch: %+v", movers.Items)
+	}
+}
+
+func TestGetCurrentMinVolume(t *testing.T) {
+	store := NewStore("", 24*time.Hour)
+	store.Add(&Snapshot{
+		Timestamp: time.Now(),
+		Items: map[string]*SnapshotItem{
+			"BTCUSDT":  {Symbol: "BTCUSDT", VolumeRank: 1, TradesRank: 2, Volume: 5e9},
+			"DUSTUSDT": {Symbol: "DUSTUSDT", VolumeRank: 2, TradesRank: 1, Volume: 5e5},
+			"ETHUSDT":  {Symbol: "ETHUSDT", VolumeRank: 3, TradesRank: 3, Volume: 2e9},
+		},
+	})
+
+	resp := store.GetCurrent(CurrentOptions{Type: RankingTypeTrades, MinVolume: 1e6, Limit: 2})
+	if len(resp.Items) != 2 {
+		t.Fatalf("got %d items, want 2: %+v", len(resp.Items), resp.Items)
+	}
+	// DUSTUSDT is dropped, but BTCUSDT keeps its global trades rank
+	if it := resp.Items[0]; it.Symbol != "BTCUSDT" || it.Rank != 2 {
+		t.Errorf("Items[0] = %s rank %d, want BTCUSDT rank 2", it.Symbol, it.Rank)
+	}
+	if it := resp.Items[1]; it.Symbol != "ETHUSDT" || it.Rank != 3 {
+		t.Errorf("Items[1] = %s rank %d, want ETHUSDT rank 3", it.Symbol, it.Rank)
+	}
+
+	// The cached unfiltered ranking is unaffected
+	if resp := store.GetCurrent(CurrentOptions{Type: RankingTypeTrades}); len(resp.Items) != 3 {
+		t.Errorf("unfiltered: got %d items, want 3", len(resp.Items))
+	}
+}
+
+func TestStoreCleanupWithClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC))
+	store := NewStore("", time.Hour)
+	store.Clock = fake
+
+	// Timestamp defaults to the injected clock
+	first := &Snapshot{Items: map[string]*SnapshotItem{}}
+	store.Add(first)
+	if !first.Timestamp.Equal(fake.Now()) {
+		t.Errorf("Timestamp = %v, want %v", first.Timestamp, fake.Now())
+	}
+
+	fake.Advance(30 * time.Minute)
+	store.Add(&Snapshot{Items: map[string]*SnapshotItem{}})
+	if store.Count() != 2 {
+		t.Fatalf("Count = %d, want 2", store.Count())
+	}
+
+	// Exactly maxAge old is still retained
+	fake.Advance(30 * time.Minute)
+	store.Cleanup()
+	if store.Count() != 2 {
+		t.Errorf("Count at maxAge = %d, want 2", store.Count())
+	}
+
+	fake.Advance(time.Second)
+	store.Cleanup()
+	if store.Count() != 1 {
+		t.Errorf("Count past maxAge = %d, want 1", store.Count())
+	}
+}
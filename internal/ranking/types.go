@@ -31,6 +31,12 @@ type RankingItem struct {
 	TradeCount   int64    `json:"trade_count"`
 	TradeChange  *float64 `json:"trade_change,omitempty"` // 成交笔数变化百分比
 	IsNew        bool     `json:"is_new,omitempty"`        // 是否新上榜
+	Alias        string   `json:"alias,omitempty"`         // 显示名称，配置别名时由 httpapi 设置
+
+	// 比较快照中的原始值，仅在 IncludePrev 时返回
+	PrevRank   *int     `json:"prev_rank,omitempty"`
+	PrevPrice  *float64 `json:"prev_price,omitempty"`
+	PrevVolume *float64 `json:"prev_volume,omitempty"`
 }
 
 // SymbolSnapshot 单个交易对的历史快照
@@ -48,6 +54,12 @@ type CurrentOptions struct {
 	Type    string        // "volume" or "trades"
 	Compare time.Duration // 比较时间窗口，0 表示与上一快照比较
 	Limit   int
+	Sort    string // 排序字段：rank（默认）、price_change、volume_change、trade_change
+	Order   string // asc|desc，默认 rank 升序、变化字段降序；变化为空的排在最后
+
+	IncludePrev bool // 返回比较快照中的排名、价格、成交额
+
+	MinVolume float64 // 过滤成交额低于该值的交易对（排名仍为全市场排名），0 表示不过滤
 }
 
 // CurrentResponse 当前排名响应
@@ -63,6 +75,13 @@ type MoversOptions struct {
 	Direction string        // "up" or "down" (required)
 	Compare   time.Duration
 	Limit     int
+
+	// Symbols 仅在这些交易对中筛选异动（如自选列表），为空表示全部；排名仍为全市场排名
+	Symbols []string
+
+	IncludePrev bool // 返回比较快照中的排名、价格、成交额
+
+	MinChange int // 仅返回排名变化绝对值不小于该值的交易对（在 Limit 之前过滤），0 表示不过滤
 }
 
 // MoversResponse 异动响应
@@ -76,6 +95,7 @@ type MoversResponse struct {
 // HistoryResponse 历史响应
 type HistoryResponse struct {
 	Symbol    string           `json:"symbol"`
+	Alias     string           `json:"alias,omitempty"` // 显示名称，配置别名时由 httpapi 设置
 	Snapshots []SymbolSnapshot `json:"snapshots"`
 }
 
@@ -85,6 +105,17 @@ const (
 	RankingTypeTrades = "trades"
 )
 
+// 排序字段与顺序常量
+const (
+	SortRank         = "rank"
+	SortPriceChange  = "price_change"
+	SortVolumeChange = "volume_change"
+	SortTradeChange  = "trade_change"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
 // Direction 方向常量
 const (
 	DirectionUp   = "up"
@@ -0,0 +1,66 @@
+package signal
+
+import (
+	"sort"
+	"time"
+)
+
+// Cluster is a run of signals on one symbol where each trigger follows the
+// previous one within the clustering gap, i.e. a single market event.
+type Cluster struct {
+	Symbol string    `json:"symbol"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Count  int       `json:"count"`
+	Levels []string  `json:"levels"` // Distinct levels in trigger order, e.g. "R3"
+}
+
+// ClusterSignals groups signals per symbol into clusters whose consecutive
+// triggers are at most gap apart. The input may be in any order; clusters
+// are returned newest first (by Start, ties by symbol).
+func ClusterSignals(signals []Signal, gap time.Duration) []Cluster {
+	sorted := make([]Signal, len(signals))
+	copy(sorted, signals)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TriggeredAt.Before(sorted[j].TriggeredAt)
+	})
+
+	var clusters []Cluster
+	open := make(map[string]int) // symbol -> index of its latest cluster
+	for _, s := range sorted {
+		if i, ok := open[s.Symbol]; ok && s.TriggeredAt.Sub(clusters[i].End) <= gap {
+			c := &clusters[i]
+			c.End = s.TriggeredAt
+			c.Count++
+			if !containsString(c.Levels, s.Level) {
+				c.Levels = append(c.Levels, s.Level)
+			}
+			continue
+		}
+		open[s.Symbol] = len(clusters)
+		clusters = append(clusters, Cluster{
+			Symbol: s.Symbol,
+			Start:  s.TriggeredAt,
+			End:    s.TriggeredAt,
+			Count:  1,
+			Levels: []string{s.Level},
+		})
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if !clusters[i].Start.Equal(clusters[j].Start) {
+			return clusters[i].Start.After(clusters[j].Start)
+		}
+		return clusters[i].Symbol < clusters[j].Symbol
+	})
+	return clusters
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
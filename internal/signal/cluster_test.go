@@ -0,0 +1,49 @@
+package signal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClusterSignals(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sig := func(symbol, level string, minutes int) Signal {
+		return Signal{Symbol: symbol, Period: "1d", Level: level, Direction: "up",
+			TriggeredAt: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	// BTC: 0, 5, 14 chain within 10m; 30 starts a new cluster. ETH: one signal.
+	signals := []Signal{
+		sig("BTCUSDT", "R3", 30),
+		sig("BTCUSDT", "R3", 0),
+		sig("ETHUSDT", "S3", 3),
+		sig("BTCUSDT", "R4", 5),
+		sig("BTCUSDT", "R3", 14),
+	}
+
+	clusters := ClusterSignals(signals, 10*time.Minute)
+	if len(clusters) != 3 {
+		t.Fatalf("got %d clusters, want 3: %+v", len(clusters), clusters)
+	}
+
+	// Newest first
+	if c := clusters[0]; c.Symbol != "BTCUSDT" || c.Count != 1 || !c.Start.Equal(base.Add(30*time.Minute)) {
+		t.Errorf("clusters[0] = %+v", c)
+	}
+	if c := clusters[1]; c.Symbol != "ETHUSDT" || c.Count != 1 {
+		t.Errorf("clusters[1] = %+v", c)
+	}
+	c := clusters[2]
+	if c.Symbol != "BTCUSDT" || c.Count != 3 || !c.Start.Equal(base) || !c.End.Equal(base.Add(14*time.Minute)) {
+		t.Errorf("clusters[2] = %+v", c)
+	}
+	if got := strings.Join(c.Levels, ","); got != "R3,R4" {
+		t.Errorf("clusters[2].Levels = %s, want R3,R4", got)
+	}
+
+	// A smaller gap splits the chain
+	if got := len(ClusterSignals(signals, 4*time.Minute)); got != 5 {
+		t.Errorf("gap 4m: got %d clusters, want 5", got)
+	}
+}
@@ -1,6 +1,7 @@
 package signal
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -24,12 +25,17 @@ type CombinedSignal struct {
 	CombinedAt    time.Time        `json:"combined_at"`
 }
 
+// DefaultCombinerMaxPerSymbol caps the pivot and pattern signals a Combiner
+// keeps per symbol; the oldest are dropped first.
+const DefaultCombinerMaxPerSymbol = 100
+
 // Combiner correlates pivot signals with pattern signals.
 type Combiner struct {
 	mu             sync.RWMutex
 	recentPivots   map[string][]Signal         // symbol -> recent pivot signals
 	recentPatterns map[string][]pattern.Signal // symbol -> recent pattern signals
 	window         time.Duration               // Correlation time window
+	maxPerSymbol   int                         // Per-symbol cap of each slice
 	onCombined     func(CombinedSignal)
 }
 
@@ -40,9 +46,65 @@ func NewCombiner(window time.Duration) *Combiner {
 		recentPivots:   make(map[string][]Signal),
 		recentPatterns: make(map[string][]pattern.Signal),
 		window:         window,
+		maxPerSymbol:   DefaultCombinerMaxPerSymbol,
 	}
 }
 
+// SetMaxPerSymbol caps the pivot and pattern signals kept per symbol
+// (each). Non-positive values are ignored. Longer slices are trimmed on
+// their next Add.
+func (c *Combiner) SetMaxPerSymbol(n int) {
+	if n <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxPerSymbol = n
+}
+
+// StartCleaner drops expired signals, and the map entries of symbols left
+// without any, every interval until ctx is done. Add only cleans up while
+// signals keep arriving, so without it an idle combiner holds on to the
+// last burst. Non-positive intervals do nothing.
+func (c *Combiner) StartCleaner(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				c.mu.Lock()
+				c.cleanupOld()
+				c.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// SetWindow updates the correlation time window at runtime.
+// Non-positive values are ignored. Signals already stored are kept until
+// the next cleanup, which uses the new window (2x for safety).
+func (c *Combiner) SetWindow(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = window
+}
+
+// Window returns the current correlation time window.
+func (c *Combiner) Window() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.window
+}
+
 // SetOnCombined sets the callback for combined signals.
 func (c *Combiner) SetOnCombined(fn func(CombinedSignal)) {
 	c.mu.Lock()
@@ -56,7 +118,7 @@ func (c *Combiner) AddPivotSignal(sig Signal) []CombinedSignal {
 	defer c.mu.Unlock()
 
 	// Add to recent pivots
-	c.recentPivots[sig.Symbol] = append(c.recentPivots[sig.Symbol], sig)
+	c.recentPivots[sig.Symbol] = trimOldest(append(c.recentPivots[sig.Symbol], sig), c.maxPerSymbol)
 	c.cleanupOld()
 
 	// Check for correlations with recent patterns
@@ -89,7 +151,7 @@ func (c *Combiner) AddPatternSignal(sig pattern.Signal) []CombinedSignal {
 	defer c.mu.Unlock()
 
 	// Add to recent patterns
-	c.recentPatterns[sig.Symbol] = append(c.recentPatterns[sig.Symbol], sig)
+	c.recentPatterns[sig.Symbol] = trimOldest(append(c.recentPatterns[sig.Symbol], sig), c.maxPerSymbol)
 	c.cleanupOld()
 
 	// Check for correlations with recent pivots
@@ -117,6 +179,7 @@ func (c *Combiner) AddPatternSignal(sig pattern.Signal) []CombinedSignal {
 }
 
 // isWithinWindow checks if two times are within the correlation window.
+// Must be called with c.mu held.
 func (c *Combiner) isWithinWindow(t1, t2 time.Time) bool {
 	diff := t1.Sub(t2)
 	if diff < 0 {
@@ -127,6 +190,11 @@ func (c *Combiner) isWithinWindow(t1, t2 time.Time) bool {
 
 // checkCorrelation determines the correlation strength between signals.
 func (c *Combiner) checkCorrelation(pivot Signal, pat pattern.Signal) CorrelationStrength {
+	return Correlate(pivot, pat)
+}
+
+// Correlate determines the correlation strength between a pivot signal and a pattern.
+func Correlate(pivot Signal, pat pattern.Signal) CorrelationStrength {
 	// Neutral patterns are always moderate
 	if pat.Direction == pattern.DirectionNeutral {
 		return CorrelationModerate
@@ -147,7 +215,18 @@ func (c *Combiner) checkCorrelation(pivot Signal, pat pattern.Signal) Correlatio
 	return CorrelationWeak
 }
 
+// trimOldest drops the first elements of s beyond max. It reslices rather
+// than copying, since CombinedSignals point into the kept elements; the
+// next append past capacity releases the dropped ones.
+func trimOldest[T any](s []T, max int) []T {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	return s[len(s)-max:]
+}
+
 // cleanupOld removes signals outside the time window.
+// Must be called with c.mu held.
 func (c *Combiner) cleanupOld() {
 	now := time.Now()
 	cutoff := now.Add(-c.window * 2) // Keep 2x window for safety
@@ -1,6 +1,7 @@
 package signal
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -204,6 +205,93 @@ func TestCombiner_Callback(t *testing.T) {
 	}
 }
 
+func TestCombiner_SetWindow(t *testing.T) {
+	c := NewCombiner(15 * time.Minute)
+
+	now := time.Now()
+
+	// Pattern 3 minutes old: within the 2x cutoff of both the old and new window
+	recent := pattern.NewSignal("BTCUSDT", pattern.PatternHammer, pattern.DirectionBullish, 75, now)
+	recent.DetectedAt = now.Add(-3 * time.Minute)
+	c.AddPatternSignal(recent)
+
+	// Pattern 10 minutes old: only within the 2x cutoff of the old window
+	older := pattern.NewSignal("ETHUSDT", pattern.PatternHammer, pattern.DirectionBullish, 75, now)
+	older.DetectedAt = now.Add(-10 * time.Minute)
+	c.AddPatternSignal(older)
+
+	// Pivot 5 minutes after the recent pattern correlates under the 15m window
+	pivSig := Signal{ID: "test-1", Symbol: "BTCUSDT", Direction: "up", TriggeredAt: now.Add(2 * time.Minute)}
+	if combined := c.AddPivotSignal(pivSig); len(combined) != 1 {
+		t.Fatalf("Expected 1 combined signal before SetWindow, got %d", len(combined))
+	}
+
+	c.SetWindow(2 * time.Minute)
+	if got := c.Window(); got != 2*time.Minute {
+		t.Fatalf("Expected window 2m, got %v", got)
+	}
+
+	// Same 5 minute gap no longer correlates
+	pivSig = Signal{ID: "test-2", Symbol: "BTCUSDT", Direction: "up", TriggeredAt: now.Add(2 * time.Minute)}
+	if combined := c.AddPivotSignal(pivSig); len(combined) != 0 {
+		t.Errorf("Expected no combined signals after SetWindow, got %d", len(combined))
+	}
+
+	// Recent pattern is still stored (3m < 2x new window)
+	if got := len(c.GetRecentPatterns("BTCUSDT")); got != 1 {
+		t.Errorf("Expected recent pattern to be kept, got %d", got)
+	}
+	// Older pattern is dropped by the cleanup using the new window
+	if got := len(c.GetRecentPatterns("ETHUSDT")); got != 0 {
+		t.Errorf("Expected older pattern to be dropped, got %d", got)
+	}
+
+	// Non-positive windows are ignored
+	c.SetWindow(0)
+	if got := c.Window(); got != 2*time.Minute {
+		t.Errorf("Expected window to stay 2m, got %v", got)
+	}
+}
+
+func TestCombiner_StartCleaner(t *testing.T) {
+	c := NewCombiner(20 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.StartCleaner(ctx, 10*time.Millisecond)
+
+	// The symbol goes idle after one signal: no further Add cleans it up
+	c.AddPivotSignal(Signal{ID: "idle-1", Symbol: "IDLEUSDT", Direction: "up", TriggeredAt: time.Now()})
+	c.AddPatternSignal(pattern.NewSignal("IDLEUSDT", pattern.PatternHammer, pattern.DirectionBullish, 75, time.Now()))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c.mu.RLock()
+		n := len(c.recentPivots) + len(c.recentPatterns)
+		c.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("idle symbol still held %d map entries after 2s", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCombiner_MaxPerSymbol(t *testing.T) {
+	c := NewCombiner(15 * time.Minute)
+	c.SetMaxPerSymbol(3)
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		c.AddPivotSignal(Signal{ID: string(rune('a' + i)), Symbol: "BTCUSDT", Direction: "up", TriggeredAt: now})
+	}
+	got := c.GetRecentPivots("BTCUSDT")
+	if len(got) != 3 || got[0].ID != "h" || got[2].ID != "j" {
+		t.Errorf("Expected the 3 newest pivots h..j, got %+v", got)
+	}
+}
+
 // Property tests
 
 func TestProperty_TimeWindowCorrelation(t *testing.T) {
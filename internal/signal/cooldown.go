@@ -3,9 +3,14 @@ package signal
 import (
 	"sync"
 	"time"
+
+	"example.com/binance-pivot-monitor/internal/clock"
 )
 
 type Cooldown struct {
+	// Clock supplies the current time for ActiveKeys. Nil means the wall clock.
+	Clock clock.Clock
+
 	mu   sync.Mutex
 	dur  time.Duration
 	last map[string]time.Time
@@ -30,3 +35,20 @@ func (c *Cooldown) Allow(key string, now time.Time) bool {
 	c.last[key] = now
 	return true
 }
+
+// ActiveKeys returns a snapshot of keys still in cooldown, mapped to when
+// each cooldown expires. It does not modify the cooldown state.
+func (c *Cooldown) ActiveKeys() map[string]time.Time {
+	now := clock.Or(c.Clock).Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]time.Time)
+	for key, t := range c.last {
+		if expiry := t.Add(c.dur); expiry.After(now) {
+			out[key] = expiry
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package signal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/clock"
+)
+
+func TestCooldown_ActiveKeys(t *testing.T) {
+	now := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	c := NewCooldown(10 * time.Minute)
+	c.Clock = clock.NewFake(now)
+
+	c.Allow("BTCUSDT|1d|R1", now.Add(-time.Minute))
+	c.Allow("ETHUSDT|1d|S1", now.Add(-time.Hour)) // Already expired
+
+	active := c.ActiveKeys()
+	if len(active) != 1 {
+		t.Fatalf("ActiveKeys() = %v, want 1 key", active)
+	}
+	want := now.Add(-time.Minute).Add(10 * time.Minute)
+	if got, ok := active["BTCUSDT|1d|R1"]; !ok || !got.Equal(want) {
+		t.Errorf("expiry = %v, want %v", got, want)
+	}
+
+	// Snapshot must not affect Allow
+	active["BTCUSDT|1d|R1"] = time.Time{}
+	if c.Allow("BTCUSDT|1d|R1", now) {
+		t.Error("Allow should still be blocked after modifying the snapshot")
+	}
+	if !c.Allow("ETHUSDT|1d|S1", now) {
+		t.Error("ActiveKeys must not remove or refresh expired keys")
+	}
+}
+
+func TestCooldown_ActiveKeysConcurrent(t *testing.T) {
+	c := NewCooldown(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				c.Allow(string(rune('A'+j%26)), time.Now())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				_ = c.ActiveKeys()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCooldown_ActiveKeysExpiry(t *testing.T) {
+	start := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	c := NewCooldown(10 * time.Minute)
+	c.Clock = fake
+
+	c.Allow("BTCUSDT", start)
+
+	fake.Advance(10*time.Minute - time.Second)
+	if len(c.ActiveKeys()) != 1 {
+		t.Error("key should still be active just before expiry")
+	}
+
+	fake.Advance(time.Second)
+	if len(c.ActiveKeys()) != 0 {
+		t.Error("key should expire after the cooldown duration")
+	}
+}
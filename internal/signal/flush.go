@@ -0,0 +1,110 @@
+package signal
+
+import (
+	"os"
+	"time"
+)
+
+// maxBatchWrites flushes a bucket's buffered appends early once this many
+// signals are waiting, bounding memory and loss on a crash.
+const maxBatchWrites = 256
+
+// SetFlushInterval buffers appends to the period files and writes them in
+// one batch every interval (see Flush) or every maxBatchWrites signals,
+// instead of one write per signal. Buffered signals are served from memory
+// but reach disk, and followers, up to interval later, and are lost if the
+// process dies without Close. Zero writes each signal as it is added.
+// The unified legacy file always writes through.
+// Must be called before EnablePersistence.
+func (h *History) SetFlushInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	h.flushEvery = interval
+}
+
+// Flush writes the appends buffered by SetFlushInterval. Failed batches are
+// queued for retry like failed single appends. Call it every flush interval.
+func (h *History) Flush() {
+	now := time.Now().UTC()
+	h.bucketsMu.RLock()
+	defer h.bucketsMu.RUnlock()
+	for _, bucket := range h.buckets {
+		bucket.fileMu.Lock()
+		_ = bucket.flushBatchLocked(now)
+		bucket.fileMu.Unlock()
+	}
+}
+
+// bufferLocked encodes p for the next batch write, flushing when the batch
+// is full. Must be called with b.fileMu held.
+func (b *periodBucket) bufferLocked(p pendingWrite, now time.Time) error {
+	buf, err := encodeSignal(b.buf, p.sig, b.writeFormat())
+	if err != nil {
+		return err
+	}
+	b.buf = buf
+	b.batch = append(b.batch, p)
+	if len(b.batch) >= maxBatchWrites {
+		_ = b.flushBatchLocked(now)
+	}
+	return nil
+}
+
+// flushBatchLocked writes the buffered appends in one write. On failure
+// each signal is queued for retry with one more attempt counted.
+// Must be called with b.fileMu held.
+func (b *periodBucket) flushBatchLocked(now time.Time) error {
+	if len(b.batch) == 0 {
+		return nil
+	}
+	batch := b.batch
+	n, err := b.writeFileLocked(b.buf)
+	b.batch, b.buf = nil, b.buf[:0]
+	if err != nil {
+		for _, p := range batch {
+			p.attempts++
+			b.queueFailedLocked(p, err)
+		}
+		return err
+	}
+	b.wroteLocked(len(batch), n, now)
+	return nil
+}
+
+// writeFileLocked appends data to the bucket's file through the kept-open
+// handle, starting a binary file with its header, and returns the data
+// bytes written; the header is counted in fileSize here. rotate,
+// compactFile and clear close the handle, so the next write reopens the
+// file they leave; a file replaced by another process is not noticed
+// until then.
+// Must be called with b.fileMu held.
+func (b *periodBucket) writeFileLocked(data []byte) (int, error) {
+	if b.file == nil {
+		f, err := os.OpenFile(b.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return 0, err
+		}
+		b.file = f
+	}
+
+	if b.writeFormat() == FormatBinary && b.fileSize == 0 {
+		n, err := b.file.Write(binaryMagic)
+		// Count the header as soon as it is on disk so a retry after a
+		// failed data write does not write it again.
+		b.fileSize += int64(n)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return b.file.Write(data)
+}
+
+// closeFileLocked closes the kept-open handle, if any.
+// Must be called with b.fileMu held.
+func (b *periodBucket) closeFileLocked() {
+	if b.file != nil {
+		_ = b.file.Close()
+		b.file = nil
+	}
+}
@@ -0,0 +1,170 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closeHandles resets the kept-open handles of h, as rotation and
+// compaction do, so the next write reopens the file.
+func closeHandles(h *History) {
+	h.bucketsMu.RLock()
+	defer h.bucketsMu.RUnlock()
+	for _, bucket := range h.buckets {
+		bucket.fileMu.Lock()
+		bucket.closeFileLocked()
+		bucket.fileMu.Unlock()
+	}
+}
+
+func TestHistory_FlushInterval(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "history.jsonl")
+	dailyPath := periodFilePath(filePath, PeriodDaily, FormatJSON)
+	fileLines := func() int {
+		b, err := os.ReadFile(dailyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(b), "\n")
+	}
+
+	h := NewHistory(1000)
+	h.SetFlushInterval(time.Hour)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	add := func(id string) {
+		h.Add(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	}
+
+	add("A")
+	add("B")
+	if got := fileLines(); got != 0 {
+		t.Errorf("file has %d lines before flush, want 0", got)
+	}
+	if _, ok := h.Get("A"); !ok {
+		t.Error("buffered signal should be served from memory")
+	}
+	h.Flush()
+	if got := fileLines(); got != 2 {
+		t.Errorf("file has %d lines after flush, want 2", got)
+	}
+
+	// A full batch is written without waiting for the interval
+	for i := 0; i < maxBatchWrites; i++ {
+		add(fmt.Sprintf("batch-%d", i))
+	}
+	if got := fileLines(); got != 2+maxBatchWrites {
+		t.Errorf("file has %d lines after a full batch, want %d", got, 2+maxBatchWrites)
+	}
+
+	// A failed flush is queued for retry: here the file cannot be reopened
+	// after the handle is reset
+	add("C")
+	if err := os.Remove(dailyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dailyPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	closeHandles(h)
+	h.Flush()
+	if got := h.PendingWrites(); got != 1 {
+		t.Fatalf("PendingWrites after failed flush = %d, want 1", got)
+	}
+	if err := os.Remove(dailyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close writes the retried and still buffered appends
+	add("D")
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := fileLines(); got != 2 {
+		t.Errorf("recreated file has %d lines after close, want 2 (C and D)", got)
+	}
+	reloaded := NewHistory(1000)
+	if err := reloaded.EnablePersistence(filePath); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"C", "D"} {
+		if _, ok := reloaded.Get(id); !ok {
+			t.Errorf("signal %s not persisted", id)
+		}
+	}
+}
+
+// BenchmarkHistoryAppend compares opening the file per signal with the
+// kept-open handle, writing through or in batches.
+func BenchmarkHistoryAppend(b *testing.B) {
+	sig := Signal{ID: "1-1", Symbol: "BTCUSDT", Period: "1d", Level: "R3", Price: 50000, Direction: "up",
+		TriggeredAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Source: "markPrice", Kind: KindCross}
+	now := time.Now().UTC()
+
+	b.Run("open-close", func(b *testing.B) {
+		path := filepath.Join(b.TempDir(), "history_1d.jsonl")
+		for i := 0; i < b.N; i++ {
+			data, _ := encodeSignal(nil, sig, FormatJSON)
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, _ = f.Write(data)
+			_ = f.Close()
+		}
+	})
+	for _, every := range []time.Duration{0, time.Second} {
+		name := "kept-open"
+		if every > 0 {
+			name = "batched"
+		}
+		b.Run(name, func(b *testing.B) {
+			bucket := &periodBucket{max: math.MaxInt32, filePath: filepath.Join(b.TempDir(), "history_1d.jsonl"), flushEvery: every}
+			for i := 0; i < b.N; i++ {
+				if err := bucket.writeLocked(pendingWrite{sig: sig}, now); err != nil {
+					b.Fatal(err)
+				}
+			}
+			_ = bucket.flushBatchLocked(now)
+			bucket.closeFileLocked()
+		})
+	}
+}
+
+func TestHistory_ClearResetsHandle(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+	h := NewHistory(1000)
+	h.SetFormat(FormatBinary)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	add := func(id string) {
+		h.Add(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	}
+
+	// The truncated binary file is restarted with its header
+	add("A")
+	if _, err := h.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	add("B")
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reloaded := NewHistory(1000)
+	reloaded.SetFormat(FormatBinary)
+	if err := reloaded.EnablePersistence(filePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.Get("B"); !ok || reloaded.Count() != 1 {
+		t.Errorf("after Clear and reload: B found=%v Count=%d, want only B", ok, reloaded.Count())
+	}
+}
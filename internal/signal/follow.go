@@ -0,0 +1,108 @@
+package signal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/tail"
+)
+
+// Follow tails the period files another process writes after
+// EnablePersistence(filePath) and adds their signals to h, for a read-only
+// replica; persistence must not be enabled on h itself. Both the JSON and
+// binary period files are followed, whatever format the writer uses.
+// Signals already on disk when Follow starts are loaded silently; onNew
+// (optional) is called for each signal appended afterwards. When a file is
+// compacted, rotated or converted it is re-read, skipping signals already
+// held (by ID) or older than the newest one seen, so evicted signals are not
+// added back.
+// It polls every interval until ctx is done.
+func (h *History) Follow(ctx context.Context, filePath string, interval time.Duration, onNew func(Signal)) {
+	files := make([]*followedFile, 0, 3*len(formats))
+	for _, p := range []string{PeriodDaily, PeriodWeekly, PeriodOther} {
+		for _, f := range formats {
+			files = append(files, &followedFile{File: tail.File{Path: periodFilePath(filePath, p, f)}, format: f})
+		}
+	}
+
+	h.followOnce(files, nil)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.followOnce(files, onNew)
+		}
+	}
+}
+
+// followedFile is a tailed period file and the newest signal time read from it.
+type followedFile struct {
+	tail.File
+	format Format
+	latest time.Time
+}
+
+// readNew returns the signals appended to the file since the last call.
+func (f *followedFile) readNew() (signals []Signal, reset bool, err error) {
+	if f.format == FormatBinary {
+		data, reset, err := f.ReadNewRecords(completeRecords)
+		data = bytes.TrimPrefix(data, binaryMagic)
+		_, err2 := readBinarySignals(bufio.NewReader(bytes.NewReader(data)), func(s Signal) {
+			signals = append(signals, s)
+		})
+		if err == nil && err2 != nil && !errors.Is(err2, errTruncatedRecord) {
+			err = err2
+		}
+		return signals, reset, err
+	}
+
+	lines, reset, err := f.ReadNew()
+	for _, line := range lines {
+		var s Signal
+		if err := json.Unmarshal(line, &s); err != nil {
+			continue
+		}
+		signals = append(signals, s)
+	}
+	return signals, reset, err
+}
+
+// followOnce reads new signals from each file and adds unseen ones.
+func (h *History) followOnce(files []*followedFile, onNew func(Signal)) {
+	for _, f := range files {
+		signals, reset, err := f.readNew()
+		if err != nil {
+			log.Printf("signal history follow %s failed: %v", f.Path, err)
+			continue
+		}
+		// A file appearing after the initial load may hold signals already
+		// read from its other-format predecessor
+		fresh := onNew != nil && f.latest.IsZero()
+		for _, s := range signals {
+			if reset && s.TriggeredAt.Before(f.latest) {
+				continue
+			}
+			if reset || fresh {
+				if _, ok := h.Get(s.ID); ok {
+					continue
+				}
+			}
+			if s.TriggeredAt.After(f.latest) {
+				f.latest = s.TriggeredAt
+			}
+			h.Add(s)
+			if onNew != nil {
+				onNew(s)
+			}
+		}
+	}
+}
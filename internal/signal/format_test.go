@@ -0,0 +1,205 @@
+package signal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/tail"
+)
+
+func TestBinaryRecord_RoundTrip(t *testing.T) {
+	want := []Signal{
+		{ID: "1-1", Symbol: "BTCUSDT", Period: "1d", Level: "R3", Price: 50123.45, Direction: "up",
+			TriggeredAt: time.Date(2024, 1, 2, 3, 4, 5, 6789, time.UTC), Source: "markPrice",
+			Kind: KindTouch, Trend: "up", Contract: "usdt", Confluence: "R4", ConfluencePeriod: "1w"},
+		{ID: "1-2", Symbol: "ETHUSD_PERP", Period: "1w", Level: "S1", Price: 0.000123, Direction: "down"},
+		{ID: "1-3", Symbol: "SOLUSDT", Period: "1d", Level: "R5", Price: 101.5, Direction: "up",
+			TriggeredAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Kind: KindWalk, WalkFrom: "R3"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeSignals(&buf, want, FormatBinary); err != nil {
+		t.Fatalf("writeSignals failed: %v", err)
+	}
+	var got []Signal
+	format, records, err := readSignals(&buf, func(s Signal) { got = append(got, s) })
+	if err != nil || format != FormatBinary || records != len(want) {
+		t.Fatalf("readSignals = %s, %d, %v; want binary, %d, nil", format, records, err, len(want))
+	}
+	for i := range want {
+		if !got[i].TriggeredAt.Equal(want[i].TriggeredAt) {
+			t.Errorf("[%d] TriggeredAt = %v, want %v", i, got[i].TriggeredAt, want[i].TriggeredAt)
+		}
+		got[i].TriggeredAt = want[i].TriggeredAt
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestHistory_BinaryFormat(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+	jsonPath := periodFilePath(filePath, PeriodDaily, FormatJSON)
+	binPath := periodFilePath(filePath, PeriodDaily, FormatBinary)
+	add := func(h *History, id string) {
+		h.Add(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	}
+	open := func(f Format) *History {
+		h := NewHistory(1000)
+		h.SetFormat(f)
+		if err := h.EnablePersistence(filePath); err != nil {
+			t.Fatalf("EnablePersistence failed: %v", err)
+		}
+		return h
+	}
+	isBinary := func(path string) bool {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bytes.HasPrefix(data, binaryMagic)
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	// JSON history is converted to a .bin file when reopened as binary, and
+	// appends follow
+	h := open(FormatJSON)
+	add(h, "A")
+	h.Close()
+	h = open(FormatBinary)
+	if !isBinary(binPath) || exists(jsonPath) || h.Count() != 1 {
+		t.Fatalf("after switch to binary: binary=%v json left=%v Count=%d", isBinary(binPath), exists(jsonPath), h.Count())
+	}
+	add(h, "B")
+	h.Close()
+
+	// A torn last record is dropped and the file rewritten cleanly
+	f, err := os.OpenFile(binPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write(appendRecord(nil, Signal{ID: "torn"})[:5])
+	_ = f.Close()
+	h = open(FormatBinary)
+	add(h, "C")
+	h.Close()
+
+	// And back to JSON
+	h = open(FormatJSON)
+	if isBinary(jsonPath) || exists(binPath) {
+		t.Fatalf("after switch back to json: binary=%v bin left=%v", isBinary(jsonPath), exists(binPath))
+	}
+	for _, id := range []string{"A", "B", "C"} {
+		if _, ok := h.Get(id); !ok {
+			t.Errorf("signal %s lost across format switches", id)
+		}
+	}
+	if h.Count() != 3 {
+		t.Errorf("Count = %d, want 3", h.Count())
+	}
+}
+
+func TestHistory_BinaryRotationAndFollow(t *testing.T) {
+	for _, name := range []string{"binary", "gob", " Binary "} {
+		if f, err := ParseFormat(name); err != nil || f != FormatBinary {
+			t.Errorf("ParseFormat(%q) = %q, %v; want binary", name, f, err)
+		}
+	}
+
+	newSignal := func(id string) Signal {
+		return Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()}
+	}
+
+	// Rotated binary files are archived as .bin
+	dir := t.TempDir()
+	h := NewHistory(1000)
+	h.SetFormat(FormatBinary)
+	h.SetRotation(1, false) // rotate after every write
+	if err := h.EnablePersistence(filepath.Join(dir, "history.jsonl")); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	h.Add(newSignal("A"))
+	h.Close()
+	if archives, _ := filepath.Glob(filepath.Join(dir, "history_1d-*.bin")); len(archives) != 1 {
+		t.Errorf("binary archives = %v, want one", archives)
+	}
+	if jsonl, _ := filepath.Glob(filepath.Join(dir, "*.jsonl")); len(jsonl) != 0 {
+		t.Errorf("binary history wrote .jsonl files: %v", jsonl)
+	}
+
+	// A replica follows binary appends
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+	h = NewHistory(1000)
+	h.SetFormat(FormatBinary)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	defer h.Close()
+	h.Add(newSignal("A"))
+
+	replica := NewHistory(1000)
+	files := []*followedFile{{File: tail.File{Path: periodFilePath(filePath, PeriodDaily, FormatBinary)}, format: FormatBinary}}
+	replica.followOnce(files, nil)
+	if _, ok := replica.Get("A"); !ok {
+		t.Fatal("replica did not load the existing binary signal")
+	}
+	var got []string
+	h.Add(newSignal("B"))
+	h.Add(newSignal("C"))
+	replica.followOnce(files, func(s Signal) { got = append(got, s.ID) })
+	if len(got) != 2 || got[0] != "B" || got[1] != "C" {
+		t.Errorf("followed %v, want [B C]", got)
+	}
+}
+
+// BenchmarkHistoryLoad compares loading 100k signals per format.
+func BenchmarkHistoryLoad(b *testing.B) {
+	const n = 100000
+	signals := make([]Signal, n)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range signals {
+		signals[i] = Signal{
+			ID:          fmt.Sprintf("%d-%d", base.UnixNano(), i),
+			Symbol:      "BTCUSDT",
+			Period:      "1d",
+			Level:       "R3",
+			Price:       50000 + float64(i),
+			Direction:   "up",
+			TriggeredAt: base.Add(time.Duration(i) * time.Second),
+			Source:      "markPrice",
+			Kind:        KindCross,
+			Contract:    "usdt",
+		}
+	}
+
+	for _, format := range []Format{FormatJSON, FormatBinary} {
+		b.Run(string(format), func(b *testing.B) {
+			filePath := filepath.Join(b.TempDir(), "history.jsonl")
+			bucket := &periodBucket{max: n, filePath: periodFilePath(filePath, PeriodDaily, format), format: format}
+			if err := bucket.compactFile(signals); err != nil {
+				b.Fatal(err)
+			}
+			fi, err := os.Stat(bucket.filePath)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h := NewHistory(2 * n)
+				h.SetFormat(format)
+				if err := h.EnablePersistence(filePath); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(fi.Size())/n, "bytes/signal")
+		})
+	}
+}
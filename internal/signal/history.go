@@ -8,8 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Period constants for bucket keys
@@ -36,6 +38,25 @@ type periodBucket struct {
 	fileMu    sync.Mutex
 	filePath  string
 	fileLines int
+
+	// Optional rotation of the active file into dated archives
+	rotateSize  int64  // rotate when the file reaches this many bytes (0 = disabled)
+	rotateDaily bool   // rotate when the UTC date changes
+	fileDate    string // UTC date (YYYYMMDD) the active file belongs to
+	fileSize    int64
+
+	format Format // encoding of new writes; files in another format are converted on load
+
+	// Append handle kept open between writes, and appends buffered until
+	// the next flush when flushEvery is set (see SetFlushInterval)
+	file       *os.File
+	flushEvery time.Duration
+	batch      []pendingWrite
+	buf        []byte
+
+	// Failed appends awaiting retry, and where to put them when retries run out
+	pending []pendingWrite
+	dead    *deadLetter
 }
 
 // newPeriodBucket creates a new bucket with the given capacity.
@@ -58,6 +79,11 @@ func normalizePeriod(period string) string {
 	}
 }
 
+// History keeps recent signals in memory per period and, once persistence is
+// enabled, appends them to per-period files. Each file is written through a
+// handle kept open between appends; a file replaced or truncated by another
+// process is not noticed until the bucket rotates, compacts or is cleared,
+// and appends keep going to the file that was open.
 type History struct {
 	// Legacy fields for backward compatibility (used during migration)
 	mu           sync.RWMutex
@@ -82,6 +108,19 @@ type History struct {
 	baseName   string // base filename without extension
 	separated  bool   // true if using period-separated storage
 	migrated   bool   // true if migration has been attempted
+
+	// Rotation configuration, applied to period buckets
+	rotateSize  int64
+	rotateDaily bool
+
+	// Encoding of the period files; empty means FormatJSON
+	format Format
+
+	// Buffer period file appends for this long; zero writes each signal
+	flushEvery time.Duration
+
+	// Signals whose append failed maxWriteAttempts times (period files only)
+	dead *deadLetter
 }
 
 func NewHistory(max int) *History {
@@ -155,9 +194,16 @@ func (h *History) EnablePersistence(filePath string) error {
 	}
 
 	// Enable persistence for each bucket
+	h.dead = &deadLetter{path: filepath.Join(h.baseDir, h.baseName+".deadletter.jsonl")}
 	h.bucketsMu.Lock()
 	for periodKey, bucket := range h.buckets {
+		bucket.rotateSize = h.rotateSize
+		bucket.rotateDaily = h.rotateDaily
+		bucket.format = h.format
+		bucket.flushEvery = h.flushEvery
+		bucket.dead = h.dead
 		bucketFile := h.getPeriodFilePath(periodKey)
+		adoptOtherFormat(bucketFile, h.format)
 		if err := bucket.enablePersistence(bucketFile); err != nil {
 			log.Printf("signal history: failed to enable persistence for period %s: %v", periodKey, err)
 		}
@@ -167,9 +213,61 @@ func (h *History) EnablePersistence(filePath string) error {
 	return nil
 }
 
-// getPeriodFilePath returns the file path for a specific period bucket.
+// SetRotation enables rotation of the period files: when a file reaches
+// maxBytes (0 = no size limit) or, if daily is set, when the UTC date changes,
+// it is renamed to a dated archive (e.g. history_1d-20240101.jsonl, or .bin
+// in FormatBinary) and a
+// fresh file is started. The in-memory window is unaffected, and archives are
+// never reloaded. Must be called before EnablePersistence.
+func (h *History) SetRotation(maxBytes int64, daily bool) {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	h.rotateSize = maxBytes
+	h.rotateDaily = daily
+}
+
+// SetFormat selects the encoding of the period files, which are named
+// .jsonl or .bin accordingly. Existing files in the other format are
+// converted when loaded, so switching formats is safe.
+// The unified legacy file and the dead-letter file stay JSON.
+// Must be called before EnablePersistence.
+func (h *History) SetFormat(f Format) {
+	h.format = f
+}
+
+// getPeriodFilePath returns the file path for a specific period bucket in
+// the configured format.
 func (h *History) getPeriodFilePath(periodKey string) string {
-	return filepath.Join(h.baseDir, h.baseName+"_"+periodKey+".jsonl")
+	return filepath.Join(h.baseDir, h.baseName+"_"+periodKey+h.format.ext())
+}
+
+// periodFilePath returns the period file in format f for a history base
+// path, e.g. ("signals/history.jsonl", "1d", FormatBinary) ->
+// "signals/history_1d.bin".
+func periodFilePath(base, periodKey string, f Format) string {
+	name := filepath.Base(base)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return filepath.Join(filepath.Dir(base), name+"_"+periodKey+f.ext())
+}
+
+// adoptOtherFormat renames a period file written in another format to path
+// when path does not exist yet, so enablePersistence converts it instead of
+// starting empty after the format is switched.
+func adoptOtherFormat(path string, f Format) {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	base := strings.TrimSuffix(path, f.ext())
+	for _, other := range formats {
+		if other.ext() == f.ext() {
+			continue
+		}
+		if err := os.Rename(base+other.ext(), path); err == nil {
+			log.Printf("signal history: converting %s to %s", filepath.Base(base+other.ext()), filepath.Base(path))
+			return
+		}
+	}
 }
 
 // enablePersistence enables persistence for a single bucket.
@@ -192,38 +290,35 @@ func (b *periodBucket) enablePersistence(filePath string) error {
 			_ = f2.Close()
 			b.filePath = filePath
 			b.fileLines = 0
+			b.fileSize = 0
+			b.fileDate = ""
 			return nil
 		}
 		return err
 	}
 	defer f.Close()
 
-	// Load existing signals from file
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if fi, err := f.Stat(); err == nil {
+		b.fileSize = fi.Size()
+		if fi.Size() > 0 {
+			b.fileDate = fi.ModTime().UTC().Format("20060102")
+		}
+	}
 
+	// Load existing signals from file, in whichever format it is
 	capHint := 1024
 	if b.max < capHint {
 		capHint = b.max
 	}
 	loaded := make([]Signal, 0, capHint)
-	lines := 0
-	for scanner.Scan() {
-		lines++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-		var s Signal
-		if err := json.Unmarshal([]byte(line), &s); err != nil {
-			continue
-		}
+	format, lines, err := readSignals(f, func(s Signal) {
 		loaded = append(loaded, s)
 		if len(loaded) > b.max {
 			loaded = loaded[len(loaded)-b.max:]
 		}
-	}
-	if err := scanner.Err(); err != nil {
+	})
+	truncated := errors.Is(err, errTruncatedRecord)
+	if err != nil && !truncated {
 		return err
 	}
 
@@ -240,6 +335,22 @@ func (b *periodBucket) enablePersistence(filePath string) error {
 	b.filePath = filePath
 	b.fileLines = lines
 
+	// Rewrite a file in the other format (or with a torn last record) so
+	// appends never mix encodings
+	if b.fileSize > 0 && (format != b.writeFormat() || truncated) {
+		snapshot := make([]Signal, len(loaded))
+		copy(snapshot, loaded)
+		if err := b.compactFile(snapshot); err != nil {
+			return err
+		}
+		b.fileLines = len(snapshot)
+		if fi, err := os.Stat(filePath); err == nil {
+			b.fileSize = fi.Size()
+		}
+		log.Printf("signal history: rewrote %s as %s (%d signals)", filepath.Base(filePath), b.writeFormat(), len(snapshot))
+		return nil
+	}
+
 	// Compact if needed
 	if b.fileLines > b.max*2 {
 		snapshot := make([]Signal, len(loaded))
@@ -306,13 +417,10 @@ func (h *History) migrateFromUnified(unifiedPath string) error {
 			return err
 		}
 		bw := bufio.NewWriter(fw)
-		enc := json.NewEncoder(bw)
-		for _, s := range signals {
-			if err := enc.Encode(s); err != nil {
-				_ = bw.Flush()
-				_ = fw.Close()
-				return err
-			}
+		if err := writeSignals(bw, signals, h.format); err != nil {
+			_ = bw.Flush()
+			_ = fw.Close()
+			return err
 		}
 		if err := bw.Flush(); err != nil {
 			_ = fw.Close()
@@ -499,6 +607,14 @@ func (h *History) addToBucket(s Signal) {
 	bucket.fileMu.Lock()
 	defer bucket.fileMu.Unlock()
 
+	now := time.Now().UTC()
+	if bucket.rotateDaily && bucket.fileDate != "" && bucket.fileDate != now.Format("20060102") {
+		_ = bucket.flushBatchLocked(now) // buffered appends belong to the old day
+		if err := bucket.rotate(); err != nil {
+			log.Printf("signal history: rotate %s failed: %v", bucket.filePath, err)
+		}
+	}
+
 	bucket.mu.Lock()
 	bucket.signals = append(bucket.signals, s)
 	bucket.symbolsUpper = append(bucket.symbolsUpper, upper)
@@ -508,32 +624,96 @@ func (h *History) addToBucket(s Signal) {
 	}
 	bucket.mu.Unlock()
 
-	if err := bucket.appendToFile(s); err == nil {
-		bucket.fileLines++
-		if bucket.fileLines > bucket.max*2 {
-			bucket.mu.RLock()
-			snapshot := make([]Signal, len(bucket.signals))
-			copy(snapshot, bucket.signals)
-			bucket.mu.RUnlock()
-			if err := bucket.compactFile(snapshot); err == nil {
-				bucket.fileLines = len(snapshot)
-			}
-		}
+	// Earlier failures go first so the file stays roughly in order
+	bucket.retryPendingLocked(now)
+	if err := bucket.writeLocked(pendingWrite{sig: s}, now); err != nil {
+		bucket.queueFailedLocked(pendingWrite{sig: s, attempts: 1}, err)
 	}
 }
 
-// appendToFile appends a signal to the bucket's file.
-func (b *periodBucket) appendToFile(s Signal) error {
-	f, err := os.OpenFile(b.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+// writeLocked appends p.sig to the bucket's file, then rotates or compacts
+// it as configured. With flushEvery set the append is buffered instead and
+// failures are queued when the batch is flushed.
+// Must be called with b.fileMu held.
+func (b *periodBucket) writeLocked(p pendingWrite, now time.Time) error {
+	if b.flushEvery > 0 {
+		return b.bufferLocked(p, now)
+	}
+	data, err := encodeSignal(b.buf[:0], p.sig, b.writeFormat())
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(f)
-	if err := enc.Encode(s); err != nil {
-		_ = f.Close()
+	b.buf = data[:0]
+	n, err := b.writeFileLocked(data)
+	if err != nil {
 		return err
 	}
-	return f.Close()
+	b.wroteLocked(1, n, now)
+	return nil
+}
+
+// wroteLocked accounts for lines appended in n bytes, then rotates or
+// compacts the file as configured. Must be called with b.fileMu held.
+func (b *periodBucket) wroteLocked(lines, n int, now time.Time) {
+	b.fileLines += lines
+	b.fileSize += int64(n)
+	if b.fileDate == "" {
+		b.fileDate = now.Format("20060102")
+	}
+	if b.rotateSize > 0 && b.fileSize >= b.rotateSize {
+		if err := b.rotate(); err != nil {
+			log.Printf("signal history: rotate %s failed: %v", b.filePath, err)
+		}
+	} else if b.fileLines > b.max*2 {
+		b.mu.RLock()
+		snapshot := make([]Signal, len(b.signals))
+		copy(snapshot, b.signals)
+		b.mu.RUnlock()
+		if err := b.compactFile(snapshot); err == nil {
+			b.fileLines = len(snapshot)
+			if fi, err := os.Stat(b.filePath); err == nil {
+				b.fileSize = fi.Size()
+			}
+			b.dropPersistedLocked(snapshot)
+		}
+	}
+}
+
+// writeFormat returns the bucket's file format, defaulting to FormatJSON.
+func (b *periodBucket) writeFormat() Format {
+	if b.format == "" {
+		return FormatJSON
+	}
+	return b.format
+}
+
+// rotate renames the active file to a dated archive and starts a fresh one.
+// Must be called with b.fileMu held.
+func (b *periodBucket) rotate() error {
+	date := b.fileDate
+	if date == "" {
+		date = time.Now().UTC().Format("20060102")
+	}
+	base := strings.TrimSuffix(b.filePath, filepath.Ext(b.filePath))
+	ext := b.writeFormat().ext()
+	archive := base + "-" + date + ext
+	for i := 2; ; i++ {
+		if _, err := os.Stat(archive); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		archive = base + "-" + date + "-" + strconv.Itoa(i) + ext
+	}
+
+	if err := os.Rename(b.filePath, archive); err != nil {
+		return err
+	}
+	b.closeFileLocked()
+	log.Printf("signal history: rotated %s -> %s", filepath.Base(b.filePath), filepath.Base(archive))
+
+	b.fileLines = 0
+	b.fileSize = 0
+	b.fileDate = ""
+	return nil
 }
 
 // compactFile compacts the bucket's file with the given snapshot.
@@ -544,13 +724,10 @@ func (b *periodBucket) compactFile(snapshot []Signal) error {
 		return err
 	}
 	bw := bufio.NewWriter(f)
-	enc := json.NewEncoder(bw)
-	for _, s := range snapshot {
-		if err := enc.Encode(s); err != nil {
-			_ = bw.Flush()
-			_ = f.Close()
-			return err
-		}
+	if err := writeSignals(bw, snapshot, b.writeFormat()); err != nil {
+		_ = bw.Flush()
+		_ = f.Close()
+		return err
 	}
 	if err := bw.Flush(); err != nil {
 		_ = f.Close()
@@ -559,7 +736,11 @@ func (b *periodBucket) compactFile(snapshot []Signal) error {
 	if err := f.Close(); err != nil {
 		return err
 	}
-	return os.Rename(tmp, b.filePath)
+	if err := os.Rename(tmp, b.filePath); err != nil {
+		return err
+	}
+	b.closeFileLocked()
+	return nil
 }
 
 func (h *History) appendLocked(s Signal) error {
@@ -601,16 +782,44 @@ func (h *History) compactLocked(snapshot []Signal) error {
 }
 
 func (h *History) Query(symbolContains, period, level, direction, source string, limit int) []Signal {
+	res, _ := h.QueryPage(symbolContains, period, level, direction, source, 0, limit)
+	return res
+}
+
+// QueryPage is like Query but skips the first offset matches (after filtering,
+// before limit) and also returns the total number of matches.
+// Results are ordered newest first, so offsets are stable while no new signals arrive.
+func (h *History) QueryPage(symbolContains, period, level, direction, source string, offset, limit int) ([]Signal, int) {
+	return h.QueryPageOrdered(symbolContains, period, level, direction, source, offset, limit, false)
+}
+
+// QueryPageOrdered is like QueryPage but returns the page oldest first when
+// ascending is set. Offset and limit still count from the newest match, so
+// the page holds the same (most recent) signals in either order.
+func (h *History) QueryPageOrdered(symbolContains, period, level, direction, source string, offset, limit int, ascending bool) ([]Signal, int) {
+	res, total := h.queryPage(symbolContains, period, level, direction, source, offset, limit)
+	if ascending {
+		for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+			res[i], res[j] = res[j], res[i]
+		}
+	}
+	return res, total
+}
+
+func (h *History) queryPage(symbolContains, period, level, direction, source string, offset, limit int) ([]Signal, int) {
 	if limit <= 0 {
 		limit = 200
 	}
 	if limit > 4000 {
 		limit = 4000
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
 	// Use period-separated query
 	if h.separated {
-		return h.queryFromBuckets(symbolContains, period, level, direction, source, limit)
+		return h.queryFromBuckets(symbolContains, period, level, direction, source, offset, limit)
 	}
 
 	// Legacy unified query
@@ -640,7 +849,8 @@ func (h *History) Query(symbolContains, period, level, direction, source string,
 
 	h.mu.RLock()
 	res := make([]Signal, 0, limit)
-	for i := len(h.signals) - 1; i >= 0 && len(res) < limit; i-- {
+	total := 0
+	for i := len(h.signals) - 1; i >= 0; i-- {
 		s := h.signals[i]
 		if symbolContainsUpper != "" {
 			if !strings.Contains(h.symbolsUpper[i], symbolContainsUpper) {
@@ -664,14 +874,18 @@ func (h *History) Query(symbolContains, period, level, direction, source string,
 		if source != "" && !strings.EqualFold(s.Source, source) {
 			continue
 		}
+		total++
+		if total <= offset || len(res) >= limit {
+			continue
+		}
 		res = append(res, s)
 	}
 	h.mu.RUnlock()
-	return res
+	return res, total
 }
 
 // queryFromBuckets queries signals from period-separated buckets.
-func (h *History) queryFromBuckets(symbolContains, period, level, direction, source string, limit int) []Signal {
+func (h *History) queryFromBuckets(symbolContains, period, level, direction, source string, offset, limit int) ([]Signal, int) {
 	symbolContains = strings.TrimSpace(symbolContains)
 	period = strings.ToLower(strings.TrimSpace(period))
 	level = strings.TrimSpace(level)
@@ -715,7 +929,7 @@ func (h *History) queryFromBuckets(symbolContains, period, level, direction, sou
 	h.bucketsMu.RUnlock()
 
 	if len(bucketsToQuery) == 0 {
-		return []Signal{}
+		return []Signal{}, 0
 	}
 
 	// Collect matching signals from all relevant buckets
@@ -758,12 +972,160 @@ func (h *History) queryFromBuckets(symbolContains, period, level, direction, sou
 		return allMatches[i].TriggeredAt.After(allMatches[j].TriggeredAt)
 	})
 
-	// Apply limit
+	// Apply offset and limit
+	total := len(allMatches)
+	if offset >= total {
+		return []Signal{}, total
+	}
+	allMatches = allMatches[offset:]
 	if len(allMatches) > limit {
 		allMatches = allMatches[:limit]
 	}
 
-	return allMatches
+	return allMatches, total
+}
+
+// QueryNearPrice returns signals for symbol (case-insensitive exact match) whose
+// Price is within pct percent of price, boundary inclusive, newest first.
+// limit follows the same defaults and cap as Query.
+func (h *History) QueryNearPrice(symbol string, price, pct float64, limit int) []Signal {
+	if limit <= 0 {
+		limit = 200
+	}
+	if limit > 4000 {
+		limit = 4000
+	}
+	symbolUpper := strings.ToUpper(strings.TrimSpace(symbol))
+	if symbolUpper == "" || price <= 0 || pct < 0 {
+		return []Signal{}
+	}
+
+	// Small relative epsilon so a signal exactly pct away is not lost to float rounding
+	tol := price * pct / 100 * (1 + 1e-9)
+	near := func(p float64) bool {
+		d := p - price
+		if d < 0 {
+			d = -d
+		}
+		return d <= tol
+	}
+
+	var matches []Signal
+	if h.separated {
+		h.bucketsMu.RLock()
+		for _, bucket := range h.buckets {
+			bucket.mu.RLock()
+			for i := range bucket.signals {
+				if bucket.symbolsUpper[i] == symbolUpper && near(bucket.signals[i].Price) {
+					matches = append(matches, bucket.signals[i])
+				}
+			}
+			bucket.mu.RUnlock()
+		}
+		h.bucketsMu.RUnlock()
+	} else {
+		h.mu.RLock()
+		for i := range h.signals {
+			if h.symbolsUpper[i] == symbolUpper && near(h.signals[i].Price) {
+				matches = append(matches, h.signals[i])
+			}
+		}
+		h.mu.RUnlock()
+	}
+
+	if len(matches) == 0 {
+		return []Signal{}
+	}
+
+	// Sort by triggered_at descending (newest first)
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].TriggeredAt.After(matches[j].TriggeredAt)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Get returns the signal with the given ID.
+func (h *History) Get(id string) (Signal, bool) {
+	if id == "" {
+		return Signal{}, false
+	}
+
+	if h.separated {
+		h.bucketsMu.RLock()
+		defer h.bucketsMu.RUnlock()
+		for _, bucket := range h.buckets {
+			bucket.mu.RLock()
+			for i := len(bucket.signals) - 1; i >= 0; i-- {
+				if bucket.signals[i].ID == id {
+					sig := bucket.signals[i]
+					bucket.mu.RUnlock()
+					return sig, true
+				}
+			}
+			bucket.mu.RUnlock()
+		}
+		return Signal{}, false
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for i := len(h.signals) - 1; i >= 0; i-- {
+		if h.signals[i].ID == id {
+			return h.signals[i], true
+		}
+	}
+	return Signal{}, false
+}
+
+// Since returns the signals recorded after the signal with the given ID,
+// oldest first. ok is false if the ID is no longer in history.
+// Signals are ordered by TriggeredAt; ties (several levels crossed on the same
+// tick) are broken by ID, whose numeric sequence suffix increases per signal.
+func (h *History) Since(id string) ([]Signal, bool) {
+	if id == "" {
+		return nil, false
+	}
+
+	var all []Signal
+	if h.separated {
+		h.bucketsMu.RLock()
+		for _, bucket := range h.buckets {
+			bucket.mu.RLock()
+			all = append(all, bucket.signals...)
+			bucket.mu.RUnlock()
+		}
+		h.bucketsMu.RUnlock()
+	} else {
+		h.mu.RLock()
+		all = append(all, h.signals...)
+		h.mu.RUnlock()
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return signalBefore(all[i], all[j])
+	})
+
+	for i := range all {
+		if all[i].ID == id {
+			return all[i+1:], true
+		}
+	}
+	return nil, false
+}
+
+// signalBefore orders signals by TriggeredAt, then by ID (shorter first, so
+// "...-9" sorts before "...-10").
+func signalBefore(a, b Signal) bool {
+	if !a.TriggeredAt.Equal(b.TriggeredAt) {
+		return a.TriggeredAt.Before(b.TriggeredAt)
+	}
+	if len(a.ID) != len(b.ID) {
+		return len(a.ID) < len(b.ID)
+	}
+	return a.ID < b.ID
 }
 
 // Count returns the number of signals in history.
@@ -787,6 +1149,65 @@ func (h *History) Count() int {
 	return len(h.signals)
 }
 
+// Clear removes all signals from memory and truncates the backing files,
+// dropping any appends still awaiting retry. It returns how many signals
+// were removed.
+func (h *History) Clear() (int, error) {
+	if h.separated {
+		h.bucketsMu.RLock()
+		defer h.bucketsMu.RUnlock()
+		total := 0
+		var firstErr error
+		for _, bucket := range h.buckets {
+			n, err := bucket.clear()
+			total += n
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return total, firstErr
+	}
+
+	h.fileMu.Lock()
+	defer h.fileMu.Unlock()
+
+	h.mu.Lock()
+	n := len(h.signals)
+	h.signals = nil
+	h.symbolsUpper = nil
+	h.mu.Unlock()
+
+	if h.filePath == "" {
+		return n, nil
+	}
+	h.fileLines = 0
+	return n, truncateFile(h.filePath)
+}
+
+// clear empties the bucket and truncates its file.
+func (b *periodBucket) clear() (int, error) {
+	b.fileMu.Lock()
+	defer b.fileMu.Unlock()
+
+	b.mu.Lock()
+	n := len(b.signals)
+	b.signals = nil
+	b.symbolsUpper = nil
+	b.mu.Unlock()
+
+	b.pending = nil
+	b.batch = nil
+	b.buf = nil
+	if b.filePath == "" {
+		return n, nil
+	}
+	b.closeFileLocked()
+	b.fileLines = 0
+	b.fileSize = 0
+	b.fileDate = ""
+	return n, truncateFile(b.filePath)
+}
+
 // SymbolCount returns the number of unique symbols in history.
 func (h *History) SymbolCount() int {
 	// Use period-separated count
@@ -814,3 +1235,11 @@ func (h *History) SymbolCount() int {
 	}
 	return len(seen)
 }
+
+// truncateFile empties path; a missing file is already empty.
+func truncateFile(path string) error {
+	if err := os.Truncate(path, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -3,9 +3,12 @@ package signal
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/tail"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
@@ -142,6 +145,406 @@ func TestHistory_QueryLimit4000(t *testing.T) {
 	}
 }
 
+// TestHistory_QueryPage tests offset pagination and total counts.
+func TestHistory_QueryPage(t *testing.T) {
+	h := NewHistory(1000)
+	base := time.Now()
+
+	for i := 0; i < 10; i++ {
+		h.Add(Signal{
+			ID:          string(rune('A' + i)),
+			Symbol:      "TESTUSDT",
+			Period:      "1d",
+			Level:       "R1",
+			Direction:   "up",
+			TriggeredAt: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	// Newest first: offset 3, limit 4 returns G, F, E, D
+	results, total := h.QueryPage("", "", "", "", "", 3, 4)
+	if total != 10 {
+		t.Errorf("expected total 10, got %d", total)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if results[0].ID != "G" || results[3].ID != "D" {
+		t.Errorf("unexpected page: first=%s last=%s", results[0].ID, results[3].ID)
+	}
+
+	// Negative offset is treated as 0
+	results, _ = h.QueryPage("", "", "", "", "", -5, 1)
+	if len(results) != 1 || results[0].ID != "J" {
+		t.Errorf("expected newest signal for negative offset, got %v", results)
+	}
+
+	// Offset past the end returns no results but still reports the total
+	results, total = h.QueryPage("", "", "", "", "", 20, 5)
+	if len(results) != 0 || total != 10 {
+		t.Errorf("expected 0 results and total 10, got %d and %d", len(results), total)
+	}
+
+	// Total reflects filters
+	_, total = h.QueryPage("", "1w", "", "", "", 0, 5)
+	if total != 0 {
+		t.Errorf("expected total 0 for weekly, got %d", total)
+	}
+}
+
+// TestHistory_QueryPageOrdered tests that ascending order keeps the most recent page.
+func TestHistory_QueryPageOrdered(t *testing.T) {
+	for _, separated := range []bool{false, true} {
+		h := NewHistory(1000)
+		h.separated = separated
+		base := time.Now()
+		for i := 0; i < 10; i++ {
+			h.Add(Signal{
+				ID:          string(rune('A' + i)),
+				Symbol:      "TESTUSDT",
+				Period:      "1d",
+				Level:       "R1",
+				Direction:   "up",
+				TriggeredAt: base.Add(time.Duration(i) * time.Minute),
+			})
+		}
+
+		// Descending: J, I, H
+		desc, total := h.QueryPageOrdered("", "", "", "", "", 0, 3, false)
+		if total != 10 || len(desc) != 3 || desc[0].ID != "J" || desc[2].ID != "H" {
+			t.Errorf("separated=%v desc: total=%d page=%v, want J..H of 10", separated, total, desc)
+		}
+
+		// Ascending: the same three most recent, oldest first
+		asc, total := h.QueryPageOrdered("", "", "", "", "", 0, 3, true)
+		if total != 10 || len(asc) != 3 || asc[0].ID != "H" || asc[2].ID != "J" {
+			t.Errorf("separated=%v asc: total=%d page=%v, want H..J of 10", separated, total, asc)
+		}
+	}
+}
+
+func TestHistory_QueryNearPrice(t *testing.T) {
+	h := NewHistory(1000)
+	base := time.Now()
+
+	prices := []float64{49000, 49500, 50000, 50500, 50501, 51000}
+	for i, p := range prices {
+		h.Add(Signal{
+			ID:          string(rune('A' + i)),
+			Symbol:      "BTCUSDT",
+			Period:      "1d",
+			Level:       "R1",
+			Price:       p,
+			TriggeredAt: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	h.Add(Signal{ID: "W", Symbol: "BTCUSDT", Period: "1w", Level: "S1", Price: 50200, TriggeredAt: base.Add(10 * time.Minute)})
+	h.Add(Signal{ID: "X", Symbol: "ETHUSDT", Period: "1d", Level: "R1", Price: 50000, TriggeredAt: base})
+
+	// 1% of 50000 = 500: 49500 and 50500 are exactly on the boundary and included
+	res := h.QueryNearPrice("btcusdt", 50000, 1, 0)
+	var ids []string
+	for _, s := range res {
+		ids = append(ids, s.ID)
+	}
+	want := []string{"W", "D", "C", "B"}
+	if len(ids) != len(want) {
+		t.Fatalf("expected %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, ids)
+		}
+	}
+
+	if res := h.QueryNearPrice("BTCUSDT", 50000, 1, 2); len(res) != 2 || res[0].ID != "W" {
+		t.Errorf("expected limit 2 newest first, got %v", res)
+	}
+	if res := h.QueryNearPrice("BTCUSDT", 50000, 0, 0); len(res) != 1 || res[0].ID != "C" {
+		t.Errorf("expected exact match only for pct=0, got %v", res)
+	}
+	if res := h.QueryNearPrice("SOLUSDT", 50000, 1, 0); res == nil || len(res) != 0 {
+		t.Errorf("expected empty non-nil result, got %v", res)
+	}
+}
+
+func TestHistory_Get(t *testing.T) {
+	h := NewHistory(1000)
+	h.Add(Signal{ID: "a", Symbol: "BTCUSDT", Period: "1d", Level: "R3", Price: 100})
+	h.Add(Signal{ID: "b", Symbol: "ETHUSDT", Period: "1w", Level: "S3", Price: 200})
+
+	sig, ok := h.Get("b")
+	if !ok || sig.Symbol != "ETHUSDT" || sig.Price != 200 {
+		t.Errorf("Get(b) = %+v, %v", sig, ok)
+	}
+	if _, ok := h.Get("missing"); ok {
+		t.Error("expected missing ID not to be found")
+	}
+	if _, ok := h.Get(""); ok {
+		t.Error("expected empty ID not to be found")
+	}
+}
+
+func TestHistory_Since(t *testing.T) {
+	h := NewHistory(1000)
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// Same tick crossing two levels: equal TriggeredAt, sequence 9 then 10
+	h.Add(Signal{ID: "1-8", Symbol: "BTCUSDT", Period: "1d", Level: "R3", TriggeredAt: base})
+	h.Add(Signal{ID: "2-9", Symbol: "BTCUSDT", Period: "1d", Level: "R4", TriggeredAt: base.Add(time.Minute)})
+	h.Add(Signal{ID: "2-10", Symbol: "BTCUSDT", Period: "1w", Level: "R5", TriggeredAt: base.Add(time.Minute)})
+	h.Add(Signal{ID: "3-11", Symbol: "ETHUSDT", Period: "1d", Level: "S3", TriggeredAt: base.Add(2 * time.Minute)})
+
+	res, ok := h.Since("2-9")
+	if !ok {
+		t.Fatal("expected ID to be found")
+	}
+	if len(res) != 2 || res[0].ID != "2-10" || res[1].ID != "3-11" {
+		t.Errorf("expected [2-10 3-11], got %v", res)
+	}
+
+	if res, ok := h.Since("3-11"); !ok || len(res) != 0 {
+		t.Errorf("expected no newer signals for latest ID, got %v (ok=%v)", res, ok)
+	}
+	if _, ok := h.Since("unknown"); ok {
+		t.Error("expected unknown ID not to be found")
+	}
+}
+
+func TestHistory_RotationBySize(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "history.jsonl")
+
+	h := NewHistory(1000)
+	h.SetRotation(300, false)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		h.Add(Signal{ID: string(rune('A' + i)), Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(dir, "history_1d-*.jsonl"))
+	if len(archives) == 0 {
+		t.Fatal("expected at least one rotated archive")
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "history_1d.jsonl")); err == nil && fi.Size() >= 300 {
+		t.Errorf("active file size %d should be below rotate size", fi.Size())
+	}
+
+	// The in-memory window is intact
+	if got := len(h.Query("", "", "", "", "", 100)); got != 6 {
+		t.Errorf("expected 6 signals in memory, got %d", got)
+	}
+
+	// Reload only reads the active file
+	h2 := NewHistory(1000)
+	if err := h2.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence (reload) failed: %v", err)
+	}
+	if got := len(h2.Query("", "", "", "", "", 100)); got >= 6 {
+		t.Errorf("expected archives not to be reloaded, got %d signals", got)
+	}
+}
+
+func TestHistory_RotationDaily(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "history.jsonl")
+
+	h := NewHistory(1000)
+	h.SetRotation(0, true)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+
+	h.Add(Signal{ID: "A", Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+
+	// Pretend the active file was started on an earlier day
+	bucket := h.buckets[PeriodDaily]
+	bucket.fileDate = "20240101"
+
+	h.Add(Signal{ID: "B", Symbol: "BTCUSDT", Period: "1d", Level: "R2", Direction: "up", TriggeredAt: time.Now()})
+
+	archive := filepath.Join(dir, "history_1d-20240101.jsonl")
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatalf("expected archive %s: %v", archive, err)
+	}
+	var sig Signal
+	if err := json.Unmarshal(data, &sig); err != nil || sig.ID != "A" {
+		t.Errorf("archive should hold signal A, got %q (err=%v)", data, err)
+	}
+
+	h2 := NewHistory(1000)
+	if err := h2.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence (reload) failed: %v", err)
+	}
+	res := h2.Query("", "", "", "", "", 10)
+	if len(res) != 1 || res[0].ID != "B" {
+		t.Errorf("expected only signal B after reload, got %v", res)
+	}
+}
+
+func TestHistory_WriteRetryAndDeadLetter(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "history.jsonl")
+	dailyPath := filepath.Join(dir, "history_1d.jsonl")
+
+	h := NewHistory(1000)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+
+	add := func(id string) {
+		h.Add(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	}
+	// Replacing the file with a directory makes appends fail, even as root,
+	// once the kept-open handle is reset
+	breakFile := func() {
+		if err := os.Remove(dailyPath); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(dailyPath, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		closeHandles(h)
+	}
+	fixFile := func() {
+		if err := os.Remove(dailyPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fileIDs := func() []string {
+		b, _ := os.ReadFile(dailyPath)
+		var ids []string
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			var s Signal
+			if json.Unmarshal([]byte(line), &s) == nil {
+				ids = append(ids, s.ID)
+			}
+		}
+		return ids
+	}
+
+	add("A")
+	breakFile()
+	add("B")
+	if got := h.PendingWrites(); got != 1 {
+		t.Fatalf("PendingWrites = %d, want 1", got)
+	}
+
+	// A transient failure is retried by the next flush
+	fixFile()
+	h.FlushPending()
+	if got := h.PendingWrites(); got != 0 {
+		t.Errorf("PendingWrites after flush = %d, want 0", got)
+	}
+	if ids := fileIDs(); strings.Join(ids, ",") != "B" {
+		t.Errorf("file after flush = %v, want [B] (file was recreated)", ids)
+	}
+
+	// A persistent failure ends in the dead-letter file
+	breakFile()
+	add("C")
+	for i := 1; i < maxWriteAttempts; i++ {
+		h.FlushPending()
+	}
+	if got := h.PendingWrites(); got != 0 {
+		t.Errorf("PendingWrites = %d, want 0 after %d attempts", got, maxWriteAttempts)
+	}
+	if got := h.DeadLettered(); got != 1 {
+		t.Errorf("DeadLettered = %d, want 1", got)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "history.deadletter.jsonl"))
+	if err != nil {
+		t.Fatalf("read dead-letter file: %v", err)
+	}
+	var dead Signal
+	if err := json.Unmarshal(b, &dead); err != nil || dead.ID != "C" {
+		t.Errorf("dead-letter file = %q, want signal C", b)
+	}
+
+	// The signal is still served from memory
+	if _, ok := h.Get("C"); !ok {
+		t.Error("dead-lettered signal should remain in memory")
+	}
+}
+
+func TestHistory_Close(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "history.jsonl")
+	dailyPath := filepath.Join(dir, "history_1d.jsonl")
+
+	h := NewHistory(1000)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	add := func(id string) {
+		h.Add(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	}
+
+	add("A")
+	// A failed append leaves B queued; the file is fixed just before shutdown
+	if err := os.Remove(dailyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dailyPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	closeHandles(h)
+	add("B")
+	if err := os.Remove(dailyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := h.PendingWrites(); got != 0 {
+		t.Errorf("PendingWrites after Close = %d, want 0", got)
+	}
+
+	h2 := NewHistory(1000)
+	if err := h2.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence (reload) failed: %v", err)
+	}
+	if _, ok := h2.Get("B"); !ok {
+		t.Error("signal added just before Close missing after reload")
+	}
+}
+
+func TestHistory_Clear(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	h := NewHistory(1000)
+	if err := h.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	h.Add(Signal{ID: "A", Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: time.Now()})
+	h.Add(Signal{ID: "B", Symbol: "ETHUSDT", Period: "1w", Level: "S1", Direction: "down", TriggeredAt: time.Now()})
+
+	n, err := h.Clear()
+	if err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if n != 2 || h.Count() != 0 {
+		t.Errorf("Clear removed %d, Count = %d; want 2, 0", n, h.Count())
+	}
+
+	// Still writable, and only the new signal survives a reload
+	h.Add(Signal{ID: "C", Symbol: "BTCUSDT", Period: "1d", Level: "R2", Direction: "up", TriggeredAt: time.Now()})
+	h2 := NewHistory(1000)
+	if err := h2.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence (reload) failed: %v", err)
+	}
+	if got := h2.Count(); got != 1 {
+		t.Errorf("Count after reload = %d, want 1", got)
+	}
+	if _, ok := h2.Get("C"); !ok {
+		t.Error("signal added after Clear missing after reload")
+	}
+}
 
 // =============================================================================
 // Property Tests for Signal History Separation
@@ -726,3 +1129,58 @@ func TestMigrationFromUnified(t *testing.T) {
 		t.Errorf("Expected 3 weekly signals, got %d", len(weeklyResults))
 	}
 }
+
+func TestHistory_Follow(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "history.jsonl")
+
+	primary := NewHistory(1000)
+	if err := primary.EnablePersistence(filePath); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	add := func(id string, i int) {
+		primary.Add(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	add("A", 0)
+	add("B", 1)
+
+	replica := NewHistory(1000)
+	files := []*followedFile{{File: tail.File{Path: periodFilePath(filePath, PeriodDaily, FormatJSON)}}}
+	var published []string
+	onNew := func(s Signal) { published = append(published, s.ID) }
+
+	// Existing lines are loaded without being published
+	replica.followOnce(files, nil)
+	if got := len(replica.Query("", "", "", "", "", 100)); got != 2 {
+		t.Fatalf("initial load: got %d signals, want 2", got)
+	}
+
+	add("C", 2)
+	replica.followOnce(files, onNew)
+	if strings.Join(published, ",") != "C" {
+		t.Errorf("after append published %v, want [C]", published)
+	}
+
+	// Replace the file as compaction does: known signals are not re-added
+	published = nil
+	tmp := filepath.Join(dir, "compacted.jsonl")
+	var b []byte
+	for i, id := range []string{"B", "C", "D"} {
+		line, _ := json.Marshal(Signal{ID: id, Symbol: "BTCUSDT", Period: "1d", Level: "R1", Direction: "up", TriggeredAt: base.Add(time.Duration(i+1) * time.Minute)})
+		b = append(append(b, line...), '\n')
+	}
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, periodFilePath(filePath, PeriodDaily, FormatJSON)); err != nil {
+		t.Fatal(err)
+	}
+	replica.followOnce(files, onNew)
+	if strings.Join(published, ",") != "D" {
+		t.Errorf("after compaction published %v, want [D]", published)
+	}
+	if got := len(replica.Query("", "", "", "", "", 100)); got != 4 {
+		t.Errorf("got %d signals, want 4", got)
+	}
+}
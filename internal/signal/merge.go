@@ -0,0 +1,151 @@
+package signal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MergeHistoryFiles combines the signal history of srcs into the
+// period-separated files of dst (e.g. dst "signals/history.jsonl" writes
+// signals/history_1d.jsonl, history_1w.jsonl and history_other.jsonl).
+//
+// Each src may be a unified history file, a single period file, or the base
+// path of period-separated storage; its period files (.jsonl or .bin) are
+// read if present. Signals are deduplicated by ID (first occurrence wins),
+// sorted by TriggeredAt and written one per line. Malformed lines are
+// skipped. Existing dst period files of either format are replaced; pass
+// dst as a src to keep them. A binary server converts the output on start.
+func MergeHistoryFiles(dst string, srcs ...string) error {
+	dst = strings.TrimSpace(dst)
+	if dst == "" {
+		return errors.New("merge history: empty destination")
+	}
+
+	seen := make(map[string]struct{})
+	var merged []Signal
+	for _, src := range srcs {
+		signals, err := readHistorySource(src)
+		if err != nil {
+			return fmt.Errorf("merge history: %s: %w", src, err)
+		}
+		for _, s := range signals {
+			if s.ID != "" {
+				if _, ok := seen[s.ID]; ok {
+					continue
+				}
+				seen[s.ID] = struct{}{}
+			}
+			merged = append(merged, s)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return signalBefore(merged[i], merged[j])
+	})
+
+	byPeriod := map[string][]Signal{
+		PeriodDaily:  nil,
+		PeriodWeekly: nil,
+		PeriodOther:  nil,
+	}
+	for _, s := range merged {
+		key := normalizePeriod(s.Period)
+		byPeriod[key] = append(byPeriod[key], s)
+	}
+
+	dir := filepath.Dir(dst)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for key, signals := range byPeriod {
+		if err := writeSignalsFile(periodFilePath(dst, key, FormatJSON), signals); err != nil {
+			return fmt.Errorf("merge history: %w", err)
+		}
+		// A binary period file would shadow the merged one
+		if err := os.Remove(periodFilePath(dst, key, FormatBinary)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("merge history: %w", err)
+		}
+	}
+	return nil
+}
+
+// readHistorySource reads path itself and the period files derived from it,
+// skipping any that do not exist. At least one must exist.
+func readHistorySource(path string) ([]Signal, error) {
+	paths := []string{path}
+	for _, key := range []string{PeriodDaily, PeriodWeekly, PeriodOther} {
+		for _, f := range formats {
+			paths = append(paths, periodFilePath(path, key, f))
+		}
+	}
+
+	var out []Signal
+	found := false
+	for _, p := range paths {
+		signals, err := readSignalsFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		out = append(out, signals...)
+	}
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	return out, nil
+}
+
+// readSignalsFile reads all well-formed signals from a JSONL or binary
+// history file. A torn trailing binary record is ignored.
+func readSignalsFile(path string) ([]Signal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Signal
+	_, _, err = readSignals(f, func(s Signal) { out = append(out, s) })
+	if errors.Is(err, errTruncatedRecord) {
+		err = nil
+	}
+	return out, err
+}
+
+// writeSignalsFile atomically replaces path with signals, one per line.
+func writeSignalsFile(path string, signals []Signal) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	enc := json.NewEncoder(bw)
+	for _, s := range signals {
+		if err := enc.Encode(s); err != nil {
+			_ = bw.Flush()
+			_ = f.Close()
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
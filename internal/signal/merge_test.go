@@ -0,0 +1,91 @@
+package signal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeHistoryFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sig := func(id, period string, minutes int) Signal {
+		return Signal{ID: id, Symbol: "BTCUSDT", Period: period, Level: "R1", Price: 100, Direction: "up",
+			TriggeredAt: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	// Old server: unified history file with a malformed line
+	srcA := filepath.Join(dir, "a", "history.jsonl")
+	writeLines(t, srcA, []Signal{sig("a1", "1d", 3), sig("shared", "1d", 1), sig("a2", "1w", 5)}, "not json")
+
+	// New server: period-separated storage sharing one ID with the old one
+	srcB := filepath.Join(dir, "b", "history.jsonl")
+	writeLines(t, periodFilePath(srcB, PeriodDaily, FormatJSON), []Signal{sig("b1", "1d", 2), sig("shared", "1d", 1)})
+	writeLines(t, periodFilePath(srcB, PeriodOther, FormatJSON), []Signal{sig("b2", "4h", 0)})
+
+	dst := filepath.Join(dir, "out", "history.jsonl")
+	if err := MergeHistoryFiles(dst, srcA, srcB); err != nil {
+		t.Fatalf("MergeHistoryFiles failed: %v", err)
+	}
+
+	wantIDs := map[string][]string{
+		PeriodDaily:  {"shared", "b1", "a1"},
+		PeriodWeekly: {"a2"},
+		PeriodOther:  {"b2"},
+	}
+	for key, want := range wantIDs {
+		got, err := readSignalsFile(periodFilePath(dst, key, FormatJSON))
+		if err != nil {
+			t.Fatalf("read %s: %v", key, err)
+		}
+		var ids []string
+		for _, s := range got {
+			ids = append(ids, s.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(want, ",") {
+			t.Errorf("period %s IDs = %v, want %v", key, ids, want)
+		}
+	}
+
+	// The merged files load as regular period-separated history
+	h := NewHistory(1000)
+	if err := h.EnablePersistence(dst); err != nil {
+		t.Fatalf("EnablePersistence failed: %v", err)
+	}
+	if h.Count() != 5 {
+		t.Errorf("Count = %d, want 5", h.Count())
+	}
+}
+
+func TestMergeHistoryFiles_MissingSource(t *testing.T) {
+	dir := t.TempDir()
+	err := MergeHistoryFiles(filepath.Join(dir, "out.jsonl"), filepath.Join(dir, "missing.jsonl"))
+	if err == nil {
+		t.Fatal("expected error for missing source")
+	}
+}
+
+func writeLines(t *testing.T, path string, signals []Signal, extra ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSignalsFile(path, signals); err != nil {
+		t.Fatal(err)
+	}
+	if len(extra) == 0 {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, line := range extra {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/sse"
+)
+
+// replayLoopPause separates looped passes (before scaling by Speed), so a
+// file whose signals share one timestamp does not spin.
+const replayLoopPause = time.Second
+
+// Replayer publishes the signals of a history file to a broker, spaced like
+// their TriggeredAt times divided by Speed, so the UI can be developed
+// against realistic streaming data without Binance.
+// Replayed signals are stamped with the time they are published; on looped
+// passes their IDs get a "-r<pass>" suffix so clients do not drop them as
+// duplicates.
+type Replayer struct {
+	Path   string // History file, JSON Lines or binary (see Format)
+	Broker *sse.Broker[Signal]
+	Speed  float64 // Playback speed multiplier; zero or negative means 1
+	Loop   bool    // Start over after the last signal until ctx is done
+}
+
+// Run loads the file and replays it until the last signal is published (or,
+// with Loop, until ctx is done). It returns an error only if the file cannot
+// be read.
+func (r *Replayer) Run(ctx context.Context) error {
+	signals, err := r.load()
+	if err != nil {
+		return err
+	}
+	if len(signals) == 0 {
+		log.Printf("signal replay %s: no signals", r.Path)
+		return nil
+	}
+	speed := r.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	log.Printf("signal replay %s: %d signals speed=%gx loop=%v", r.Path, len(signals), speed, r.Loop)
+
+	for pass := 0; ; pass++ {
+		if !r.replay(ctx, signals, speed, pass) || !r.Loop {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(float64(replayLoopPause) / speed)):
+		}
+	}
+}
+
+// load reads the file's signals ordered by TriggeredAt.
+func (r *Replayer) load() ([]Signal, error) {
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var signals []Signal
+	if _, _, err := readSignals(f, func(s Signal) { signals = append(signals, s) }); err != nil && !errors.Is(err, errTruncatedRecord) {
+		return nil, err
+	}
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].TriggeredAt.Before(signals[j].TriggeredAt) })
+	return signals, nil
+}
+
+// replay publishes one pass of signals. It returns false if ctx is done.
+func (r *Replayer) replay(ctx context.Context, signals []Signal, speed float64, pass int) bool {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for i, s := range signals {
+		if i > 0 {
+			gap := time.Duration(float64(s.TriggeredAt.Sub(signals[i-1].TriggeredAt)) / speed)
+			if gap > 0 {
+				timer.Reset(gap)
+				select {
+				case <-ctx.Done():
+					return false
+				case <-timer.C:
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		s.TriggeredAt = time.Now()
+		if pass > 0 {
+			s.ID = fmt.Sprintf("%s-r%d", s.ID, pass)
+		}
+		if r.Broker != nil {
+			r.Broker.Publish(s)
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"example.com/binance-pivot-monitor/internal/sse"
+)
+
+func writeReplayFile(t *testing.T, signals ...Signal) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	var data []byte
+	for _, s := range signals {
+		line, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestReplayer_Spacing(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Written out of order: the replay follows TriggeredAt
+	path := writeReplayFile(t,
+		Signal{ID: "b", Symbol: "ETHUSDT", Level: "S3", Direction: "down", TriggeredAt: base.Add(10 * time.Second)},
+		Signal{ID: "a", Symbol: "BTCUSDT", Level: "R3", Direction: "up", TriggeredAt: base},
+	)
+
+	broker := sse.NewBroker[Signal]()
+	sub := broker.Subscribe(4)
+	defer broker.Unsubscribe(sub)
+
+	r := &Replayer{Path: path, Broker: broker, Speed: 50} // 10s gap plays in 200ms
+	start := time.Now()
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	first, second := <-sub, <-sub
+	if first.ID != "a" || second.ID != "b" {
+		t.Fatalf("published %s then %s, want a then b", first.ID, second.ID)
+	}
+	if elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("replay took %v, want about 200ms", elapsed)
+	}
+	if gap := second.TriggeredAt.Sub(first.TriggeredAt); gap < 150*time.Millisecond {
+		t.Errorf("published %v apart, want about 200ms", gap)
+	}
+	if first.TriggeredAt.Before(start) {
+		t.Errorf("TriggeredAt = %v, want the publish time", first.TriggeredAt)
+	}
+}
+
+func TestReplayer_Loop(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := writeReplayFile(t, Signal{ID: "a", Symbol: "BTCUSDT", TriggeredAt: base})
+
+	broker := sse.NewBroker[Signal]()
+	sub := broker.Subscribe(16)
+	defer broker.Unsubscribe(sub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- (&Replayer{Path: path, Broker: broker, Speed: 100, Loop: true}).Run(ctx) }()
+
+	if s := <-sub; s.ID != "a" {
+		t.Errorf("first pass ID = %s, want a", s.ID)
+	}
+	if s := <-sub; s.ID != "a-r1" {
+		t.Errorf("second pass ID = %s, want a-r1", s.ID)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+
+	if err := (&Replayer{Path: filepath.Join(t.TempDir(), "missing.jsonl")}).Run(context.Background()); err == nil {
+		t.Error("missing file: want error")
+	}
+}
@@ -0,0 +1,176 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxWriteAttempts is how many times appending a signal to its period
+	// file is tried before the signal goes to the dead-letter file.
+	maxWriteAttempts = 5
+	// maxPendingWrites bounds the retry queue of each period bucket; when it
+	// is full the oldest entry is dead-lettered.
+	maxPendingWrites = 1000
+)
+
+// pendingWrite is a signal whose append to the period file failed.
+type pendingWrite struct {
+	sig      Signal
+	attempts int
+}
+
+// deadLetter is the file collecting signals that could not be persisted,
+// e.g. history.deadletter.jsonl next to the period files.
+type deadLetter struct {
+	mu    sync.Mutex
+	path  string
+	count int64
+}
+
+// write appends s to the dead-letter file. The signal is counted even if
+// that write fails too, in which case it is logged instead.
+func (d *deadLetter) write(s Signal) {
+	atomic.AddInt64(&d.count, 1)
+
+	data, err := json.Marshal(s)
+	if err == nil {
+		d.mu.Lock()
+		var f *os.File
+		f, err = os.OpenFile(d.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		d.mu.Unlock()
+	}
+	if err != nil {
+		log.Printf("signal history: dead-letter write failed, signal lost from disk: id=%s err=%v", s.ID, err)
+	}
+}
+
+// queueFailedLocked queues a failed append for retry, or dead-letters it
+// once it has used up maxWriteAttempts. Must be called with b.fileMu held.
+func (b *periodBucket) queueFailedLocked(p pendingWrite, err error) {
+	if p.attempts >= maxWriteAttempts {
+		log.Printf("signal history: giving up on %s after %d attempts: %v", p.sig.ID, p.attempts, err)
+		b.deadLetter(p.sig)
+		return
+	}
+	if len(b.pending) >= maxPendingWrites {
+		b.deadLetter(b.pending[0].sig)
+		b.pending = b.pending[1:]
+	}
+	b.pending = append(b.pending, p)
+}
+
+// retryPendingLocked re-attempts queued appends in order.
+// Must be called with b.fileMu held.
+func (b *periodBucket) retryPendingLocked(now time.Time) {
+	// Pop from the live queue: a compaction during a write may drop
+	// entries it already persisted. Re-queued failures go to the back.
+	for n := len(b.pending); n > 0 && len(b.pending) > 0; n-- {
+		p := b.pending[0]
+		b.pending = b.pending[1:]
+		if err := b.writeLocked(p, now); err != nil {
+			p.attempts++
+			b.queueFailedLocked(p, err)
+		}
+	}
+}
+
+// dropPersistedLocked removes queued appends already covered by a
+// compaction snapshot. Must be called with b.fileMu held.
+func (b *periodBucket) dropPersistedLocked(snapshot []Signal) {
+	if len(b.pending) == 0 {
+		return
+	}
+	written := make(map[string]struct{}, len(snapshot))
+	for _, s := range snapshot {
+		written[s.ID] = struct{}{}
+	}
+	kept := b.pending[:0]
+	for _, p := range b.pending {
+		if _, ok := written[p.sig.ID]; !ok {
+			kept = append(kept, p)
+		}
+	}
+	b.pending = kept
+}
+
+func (b *periodBucket) deadLetter(s Signal) {
+	if b.dead == nil {
+		log.Printf("signal history: dropping unpersisted signal id=%s", s.ID)
+		return
+	}
+	b.dead.write(s)
+}
+
+// FlushPending retries appends that failed earlier. Add also retries them,
+// so this only matters when no new signals arrive.
+func (h *History) FlushPending() {
+	now := time.Now().UTC()
+	h.bucketsMu.RLock()
+	defer h.bucketsMu.RUnlock()
+	for _, bucket := range h.buckets {
+		bucket.fileMu.Lock()
+		bucket.retryPendingLocked(now)
+		bucket.fileMu.Unlock()
+	}
+}
+
+// Close writes buffered appends (see SetFlushInterval), makes a last attempt
+// to persist queued ones and dead-letters any that still fail, so they are
+// not lost with the process. Then it closes the period files.
+// Call it after the producers of signals have stopped.
+func (h *History) Close() error {
+	now := time.Now().UTC()
+	failed := 0
+	h.bucketsMu.RLock()
+	defer h.bucketsMu.RUnlock()
+	for _, bucket := range h.buckets {
+		bucket.fileMu.Lock()
+		_ = bucket.flushBatchLocked(now)
+		bucket.retryPendingLocked(now)
+		_ = bucket.flushBatchLocked(now)
+		for _, p := range bucket.pending {
+			bucket.deadLetter(p.sig)
+		}
+		failed += len(bucket.pending)
+		bucket.pending = nil
+		bucket.closeFileLocked()
+		bucket.fileMu.Unlock()
+	}
+	if failed > 0 {
+		return fmt.Errorf("signal history: %d signals not persisted on close", failed)
+	}
+	return nil
+}
+
+// PendingWrites returns the number of signals awaiting a retried append.
+func (h *History) PendingWrites() int {
+	total := 0
+	h.bucketsMu.RLock()
+	defer h.bucketsMu.RUnlock()
+	for _, bucket := range h.buckets {
+		bucket.fileMu.Lock()
+		total += len(bucket.pending)
+		bucket.fileMu.Unlock()
+	}
+	return total
+}
+
+// DeadLettered returns the number of signals written to the dead-letter file.
+func (h *History) DeadLettered() int64 {
+	if h.dead == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&h.dead.count)
+}
@@ -2,6 +2,15 @@ package signal
 
 import "time"
 
+// Signal kinds: a cross straddles the level, a touch comes within
+// Monitor.TouchTolerance of it without crossing, and a walk follows crosses
+// of consecutive levels in one direction within Monitor.WalkWindow.
+const (
+	KindCross = "cross"
+	KindTouch = "touch"
+	KindWalk  = "walk"
+)
+
 type Signal struct {
 	ID          string    `json:"id"`
 	Symbol      string    `json:"symbol"`
@@ -11,4 +20,17 @@ type Signal struct {
 	Direction   string    `json:"direction"`
 	TriggeredAt time.Time `json:"triggered_at"`
 	Source      string    `json:"source"`
+	Kind        string    `json:"kind,omitempty"`     // KindCross, KindTouch or KindWalk; empty in older history means cross
+	Trend       string    `json:"trend,omitempty"`    // Kline trend when Monitor.TrendAwareLevels is set
+	Contract    string    `json:"contract,omitempty"` // "usdt" or "coin" margined futures
+	Alias       string    `json:"alias,omitempty"`    // Display name, set by httpapi when aliases are configured
+
+	// Confluence names a level of the other period (daily vs weekly) within
+	// Monitor.ConfluencePct of the crossed one, e.g. "R3" of period "1w".
+	Confluence       string `json:"confluence,omitempty"`
+	ConfluencePeriod string `json:"confluence_period,omitempty"`
+
+	// WalkFrom is the first level of a walk signal: price crossed every
+	// level from WalkFrom to Level in turn, e.g. "R3" for R3, R4, R5.
+	WalkFrom string `json:"walk_from,omitempty"`
 }
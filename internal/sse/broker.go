@@ -24,6 +24,22 @@ func (b *Broker[T]) Subscribe(buffer int) chan T {
 	return ch
 }
 
+// TrySubscribe is like Subscribe but fails if the broker already has max
+// subscribers. The check and the subscription are atomic. max <= 0 means no limit.
+func (b *Broker[T]) TrySubscribe(buffer, max int) (chan T, bool) {
+	if buffer <= 0 {
+		buffer = 16
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if max > 0 && len(b.clients) >= max {
+		return nil, false
+	}
+	ch := make(chan T, buffer)
+	b.clients[ch] = struct{}{}
+	return ch, true
+}
+
 func (b *Broker[T]) Unsubscribe(ch chan T) {
 	b.mu.Lock()
 	if _, ok := b.clients[ch]; ok {
@@ -0,0 +1,82 @@
+// Package tail follows append-only files such as the JSONL histories, so a
+// replica can pick up lines (or other records) written by another process.
+package tail
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// File tracks how far a file has been read. The zero value with Path set
+// starts at the beginning of the file. It is not safe for concurrent use.
+type File struct {
+	Path string
+
+	offset int64
+	info   os.FileInfo
+}
+
+// ReadNew returns the complete lines appended since the previous call,
+// without their trailing newline; a partially written last line is left for
+// the next call. reset reports that the file was replaced (compaction,
+// rotation) or truncated, in which case lines start from its beginning.
+// A missing file yields no lines and no error.
+func (f *File) ReadNew() (lines [][]byte, reset bool, err error) {
+	data, reset, err := f.ReadNewRecords(func(data []byte, _ bool) int {
+		return bytes.LastIndexByte(data, '\n') + 1
+	})
+	if len(data) == 0 {
+		return nil, reset, err
+	}
+	for _, line := range bytes.Split(data[:len(data)-1], []byte{'\n'}) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, reset, nil
+}
+
+// ReadNewRecords is ReadNew for files of any framing. complete returns how
+// many bytes at the start of data form whole records (first reports that
+// data starts at the beginning of the file); those bytes are returned and
+// the rest is left for the next call.
+func (f *File) ReadNewRecords(complete func(data []byte, first bool) int) (data []byte, reset bool, err error) {
+	fh, err := os.Open(f.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// A recreated file is read from its start
+			f.info = nil
+			f.offset = 0
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer fh.Close()
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if f.info != nil && (!os.SameFile(f.info, fi) || fi.Size() < f.offset) {
+		f.offset = 0
+		reset = true
+	}
+	f.info = fi
+	if fi.Size() == f.offset {
+		return nil, reset, nil
+	}
+	if _, err := fh.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, reset, err
+	}
+	data, err = io.ReadAll(fh)
+	if err != nil {
+		return nil, reset, err
+	}
+	n := complete(data, f.offset == 0)
+	if n <= 0 {
+		return nil, reset, nil
+	}
+	f.offset += int64(n)
+	return data[:n], reset, nil
+}
@@ -0,0 +1,62 @@
+package tail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile_ReadNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	f := &File{Path: path}
+
+	if lines, reset, err := f.ReadNew(); err != nil || reset || len(lines) != 0 {
+		t.Fatalf("missing file: lines=%q reset=%v err=%v", lines, reset, err)
+	}
+
+	if err := os.WriteFile(path, []byte("a\nb\npart"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines, reset, err := f.ReadNew()
+	if err != nil || reset || len(lines) != 2 || string(lines[0]) != "a" || string(lines[1]) != "b" {
+		t.Fatalf("first read: lines=%q reset=%v err=%v", lines, reset, err)
+	}
+
+	// The partial line is returned once completed
+	fh, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fh.WriteString("ial\nc\n")
+	fh.Close()
+	lines, reset, err = f.ReadNew()
+	if err != nil || reset || len(lines) != 2 || string(lines[0]) != "partial" || string(lines[1]) != "c" {
+		t.Fatalf("append: lines=%q reset=%v err=%v", lines, reset, err)
+	}
+
+	if lines, _, _ := f.ReadNew(); len(lines) != 0 {
+		t.Errorf("no new data: got %q", lines)
+	}
+
+	// A replaced file is read from the start
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte("c\nd\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	lines, reset, err = f.ReadNew()
+	if err != nil || !reset || len(lines) != 2 || string(lines[0]) != "c" {
+		t.Fatalf("replace: lines=%q reset=%v err=%v", lines, reset, err)
+	}
+
+	// So is a truncated one
+	if err := os.WriteFile(path, []byte("e\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines, reset, err = f.ReadNew()
+	if err != nil || !reset || len(lines) != 1 || string(lines[0]) != "e" {
+		t.Fatalf("truncate: lines=%q reset=%v err=%v", lines, reset, err)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"example.com/binance-pivot-monitor/internal/backoff"
 	"example.com/binance-pivot-monitor/internal/binance"
 	"github.com/gorilla/websocket"
 )
@@ -20,11 +23,21 @@ type TickerBatch struct {
 // Monitor 监控 ticker 数据并广播
 type Monitor struct {
 	Store         *Store
-	BatchInterval time.Duration // 批量推送间隔，默认 500ms
+	BatchInterval time.Duration  // 批量推送间隔，默认 500ms
+	Backoff       backoff.Policy // 重连退避，零值使用默认值（1s~30s，20% 抖动）
+
+	// MinTickerChangePct 价格相对上次推送的变化（百分比）小于该值时不推送，0 表示不过滤
+	MinTickerChangePct float64
+
+	// HeartbeatEvery 心跳日志间隔：消息数、更新交易对数、解析错误、距上条消息时间、丢弃的广播数，0 表示禁用
+	HeartbeatEvery time.Duration
+
+	dropped uint64 // 因订阅者缓冲区已满而丢弃的广播数
 
 	mu        sync.RWMutex
 	listeners []chan TickerBatch
 	pending   map[string]*Ticker // 待推送的变化
+	lastSent  map[string]float64 // 每个交易对最近一次加入待推送时的价格
 }
 
 func NewMonitor(store *Store) *Monitor {
@@ -32,6 +45,7 @@ func NewMonitor(store *Store) *Monitor {
 		Store:         store,
 		BatchInterval: 500 * time.Millisecond,
 		pending:       make(map[string]*Ticker),
+		lastSent:      make(map[string]float64),
 	}
 }
 
@@ -71,16 +85,22 @@ func (m *Monitor) broadcast(batch TickerBatch) {
 		case ch <- batch:
 		default:
 			// 丢弃，避免阻塞
+			atomic.AddUint64(&m.dropped, 1)
 		}
 	}
 }
 
+// Dropped 返回因订阅者缓冲区已满而丢弃的广播数（按订阅者计）
+func (m *Monitor) Dropped() uint64 {
+	return atomic.LoadUint64(&m.dropped)
+}
+
 // Run 启动 ticker 监控
 func (m *Monitor) Run(ctx context.Context) {
 	// 启动批量推送协程
 	go m.batchPusher(ctx)
 
-	backoff := 1 * time.Second
+	bo := m.Backoff.New()
 	for {
 		if ctx.Err() != nil {
 			return
@@ -89,15 +109,14 @@ func (m *Monitor) Run(ctx context.Context) {
 		conn, _, err := binance.DialTickerArr(ctx)
 		if err != nil {
 			log.Printf("ticker ws dial failed: %v", err)
-			if !sleepContext(ctx, backoff) {
+			if !bo.Wait(ctx) {
 				return
 			}
-			backoff = minDuration(backoff*2, 30*time.Second)
 			continue
 		}
 
 		log.Printf("ticker ws connected")
-		backoff = 1 * time.Second
+		bo.Reset()
 
 		err = m.readLoop(ctx, conn)
 		_ = conn.Close()
@@ -105,10 +124,9 @@ func (m *Monitor) Run(ctx context.Context) {
 			log.Printf("ticker ws read loop exit: %v", err)
 		}
 
-		if !sleepContext(ctx, backoff) {
+		if !bo.Wait(ctx) {
 			return
 		}
-		backoff = minDuration(backoff*2, 30*time.Second)
 	}
 }
 
@@ -137,6 +155,29 @@ func (m *Monitor) readLoop(ctx context.Context, conn *websocket.Conn) error {
 	}()
 	defer close(done)
 
+	var hbMsgs, hbSymbols, hbUnmarshalErr int64
+	hbLastMsgUnixNano := time.Now().UnixNano()
+	if m.HeartbeatEvery > 0 {
+		go func() {
+			t := time.NewTicker(m.HeartbeatEvery)
+			defer t.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					msgs := atomic.SwapInt64(&hbMsgs, 0)
+					symbols := atomic.SwapInt64(&hbSymbols, 0)
+					bad := atomic.SwapInt64(&hbUnmarshalErr, 0)
+					last := time.Unix(0, atomic.LoadInt64(&hbLastMsgUnixNano))
+					log.Printf("ticker ws heartbeat msgs=%d symbols_updated=%d unmarshal_err=%d last_msg_ago=%s broadcast_dropped=%d", msgs, symbols, bad, time.Since(last).Round(time.Second), m.Dropped())
+				}
+			}
+		}()
+	}
+
 	msgCount := 0
 	for {
 		if ctx.Err() != nil {
@@ -148,6 +189,8 @@ func (m *Monitor) readLoop(ctx context.Context, conn *websocket.Conn) error {
 			return err
 		}
 		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		atomic.AddInt64(&hbMsgs, 1)
+		atomic.StoreInt64(&hbLastMsgUnixNano, time.Now().UnixNano())
 
 		// 调试：打印前几条原始消息
 		if msgCount < 2 {
@@ -156,6 +199,7 @@ func (m *Monitor) readLoop(ctx context.Context, conn *websocket.Conn) error {
 
 		var events []binance.TickerEvent
 		if err := json.Unmarshal(b, &events); err != nil {
+			atomic.AddInt64(&hbUnmarshalErr, 1)
 			// 打印前几条解析失败的消息
 			if msgCount < 5 {
 				log.Printf("ticker unmarshal error: %v, data prefix: %s", err, string(b[:min(len(b), 300)]))
@@ -169,21 +213,8 @@ func (m *Monitor) readLoop(ctx context.Context, conn *websocket.Conn) error {
 			log.Printf("ticker parsed: count=%d, first=%+v", len(events), events[0])
 		}
 
-		for _, ev := range events {
-			m.Store.Update(ev.Symbol, ev.LastPrice, ev.PricePercent, ev.TradeCount, ev.QuoteVolume)
-
-			// 记录待推送
-			m.mu.Lock()
-			m.pending[ev.Symbol] = &Ticker{
-				Symbol:       ev.Symbol,
-				LastPrice:    ev.LastPrice,
-				PricePercent: ev.PricePercent,
-				TradeCount:   ev.TradeCount,
-				QuoteVolume:  ev.QuoteVolume,
-				UpdatedAt:    time.Now().UnixMilli(),
-			}
-			m.mu.Unlock()
-		}
+		m.Apply(events)
+		atomic.AddInt64(&hbSymbols, int64(len(events)))
 
 		// 首次成功解析时打印日志
 		if msgCount == 0 && len(events) > 0 {
@@ -193,6 +224,51 @@ func (m *Monitor) readLoop(ctx context.Context, conn *websocket.Conn) error {
 	}
 }
 
+// Apply 更新存储并记录待推送的 ticker（供 ws 读取循环和离线模拟使用）
+func (m *Monitor) Apply(events []binance.TickerEvent) {
+	for _, ev := range events {
+		m.Store.Update(ev.Symbol, ev.LastPrice, ev.PricePercent, ev.TradeCount, ev.QuoteVolume)
+
+		// 记录待推送
+		m.mu.Lock()
+		if !m.shouldPush(ev.Symbol, ev.LastPrice) {
+			m.mu.Unlock()
+			continue
+		}
+		m.lastSent[ev.Symbol] = ev.LastPrice
+		m.pending[ev.Symbol] = &Ticker{
+			Symbol:       ev.Symbol,
+			LastPrice:    ev.LastPrice,
+			PricePercent: ev.PricePercent,
+			TradeCount:   ev.TradeCount,
+			QuoteVolume:  ev.QuoteVolume,
+			UpdatedAt:    time.Now().UnixMilli(),
+		}
+		m.mu.Unlock()
+	}
+}
+
+// shouldPush 判断价格变化是否足以推送（需持有 m.mu）
+// 已在待推送中的交易对总是更新为最新数据
+func (m *Monitor) shouldPush(symbol string, price float64) bool {
+	if m.MinTickerChangePct <= 0 {
+		return true
+	}
+	if _, ok := m.pending[symbol]; ok {
+		return true
+	}
+	last, ok := m.lastSent[symbol]
+	if !ok || last <= 0 {
+		return true
+	}
+	return math.Abs(price-last)/last*100 >= m.MinTickerChangePct
+}
+
+// RunBatches 只运行批量推送，不连接 ws（离线模式下配合 Apply 使用）
+func (m *Monitor) RunBatches(ctx context.Context) {
+	m.batchPusher(ctx)
+}
+
 // batchPusher 定时批量推送变化的 ticker
 func (m *Monitor) batchPusher(ctx context.Context) {
 	ticker := time.NewTicker(m.BatchInterval)
@@ -220,24 +296,3 @@ func (m *Monitor) batchPusher(ctx context.Context) {
 		}
 	}
 }
-
-func sleepContext(ctx context.Context, d time.Duration) bool {
-	if d <= 0 {
-		return true
-	}
-	t := time.NewTimer(d)
-	defer t.Stop()
-	select {
-	case <-ctx.Done():
-		return false
-	case <-t.C:
-		return true
-	}
-}
-
-func minDuration(a, b time.Duration) time.Duration {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,90 @@
+package ticker
+
+import (
+	"encoding/json"
+	"testing"
+
+	"example.com/binance-pivot-monitor/internal/binance"
+)
+
+func TestMonitor_MinTickerChangePct(t *testing.T) {
+	m := NewMonitor(NewStore())
+	m.MinTickerChangePct = 0.1
+
+	apply := func(price float64) {
+		m.Apply([]binance.TickerEvent{{Symbol: "BTCUSDT", LastPrice: price}})
+	}
+	flush := func() bool {
+		_, ok := m.pending["BTCUSDT"]
+		m.pending = make(map[string]*Ticker)
+		return ok
+	}
+
+	// 首次更新总是推送
+	apply(50000)
+	if !flush() {
+		t.Fatal("first update should be pending")
+	}
+
+	// 0.05% 变化低于阈值，不推送，但存储仍更新
+	apply(50025)
+	if flush() {
+		t.Error("sub-epsilon move should not be pending")
+	}
+	if tk, _ := m.Store.Get("BTCUSDT"); tk.LastPrice != 50025 {
+		t.Errorf("store LastPrice = %v, want 50025", tk.LastPrice)
+	}
+
+	// 相对上次推送累计 0.1%，推送
+	apply(50050)
+	if !flush() {
+		t.Error("move reaching epsilon should be pending")
+	}
+
+	// 0 表示不过滤
+	m.MinTickerChangePct = 0
+	apply(50051)
+	if !flush() {
+		t.Error("filtering disabled: every update should be pending")
+	}
+}
+
+func TestMonitor_ApplyPricePercentFromJSON(t *testing.T) {
+	var events []binance.TickerEvent
+	data := `[{"s":"BTCUSDT","c":"50000","P":"-2.35","n":100,"q":"1000000"},{"s":"ETHUSDT","c":"3000","P":"4.10"}]`
+	if err := json.Unmarshal([]byte(data), &events); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	m := NewMonitor(NewStore())
+	m.Apply(events)
+
+	for sym, want := range map[string]float64{"BTCUSDT": -2.35, "ETHUSDT": 4.10} {
+		tk, ok := m.Store.Get(sym)
+		if !ok {
+			t.Fatalf("%s missing from store", sym)
+		}
+		if tk.PricePercent != want {
+			t.Errorf("%s PricePercent = %v, want %v", sym, tk.PricePercent, want)
+		}
+	}
+}
+
+func TestMonitor_Dropped(t *testing.T) {
+	m := NewMonitor(NewStore())
+	ch := m.Subscribe(1)
+	defer m.Unsubscribe(ch)
+
+	batch := TickerBatch{Tickers: map[string]*Ticker{}}
+	m.broadcast(batch)
+	if got := m.Dropped(); got != 0 {
+		t.Fatalf("Dropped = %d with room in the buffer, want 0", got)
+	}
+
+	// 缓冲区已满，后续广播被丢弃并计数
+	m.broadcast(batch)
+	m.broadcast(batch)
+	if got := m.Dropped(); got != 2 {
+		t.Errorf("Dropped = %d, want 2", got)
+	}
+}
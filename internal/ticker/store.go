@@ -3,34 +3,55 @@ package ticker
 import (
 	"sync"
 	"time"
+
+	"example.com/binance-pivot-monitor/internal/binance"
 )
 
 // Ticker 精简的行情数据，用于前端显示
 type Ticker struct {
 	Symbol       string  `json:"symbol"`
 	LastPrice    float64 `json:"last_price"`
-	PricePercent float64 `json:"price_percent"` // 24h 涨跌幅
-	TradeCount   int64   `json:"trade_count"`   // 24h 成交数
-	QuoteVolume  float64 `json:"quote_volume"`  // 24h 成交额(USDT)
-	UpdatedAt    int64   `json:"updated_at"`    // 更新时间戳(ms)
+	PricePercent float64 `json:"price_percent"`   // 24h 涨跌幅
+	TradeCount   int64   `json:"trade_count"`     // 24h 成交数
+	QuoteVolume  float64 `json:"quote_volume"`    // 24h 成交额(USDT)
+	UpdatedAt    int64   `json:"updated_at"`      // 更新时间戳(ms)
+	Alias        string  `json:"alias,omitempty"` // 显示名称，仅由 httpapi 在配置别名时设置
 }
 
-// Store 存储所有交易对的行情数据
+// storeShards 是 Store 的分片数，不同交易对的写入分散到各分片锁上，减少竞争
+const storeShards = 16
+
+// Store 存储所有交易对的行情数据（按交易对哈希分片加锁）
 type Store struct {
+	shards [storeShards]storeShard
+}
+
+type storeShard struct {
 	mu      sync.RWMutex
 	tickers map[string]*Ticker
 }
 
 func NewStore() *Store {
-	return &Store{
-		tickers: make(map[string]*Ticker),
+	s := &Store{}
+	for i := range s.shards {
+		s.shards[i].tickers = make(map[string]*Ticker)
 	}
+	return s
+}
+
+// shard 返回交易对所在分片（FNV-1a 哈希）
+func (s *Store) shard(symbol string) *storeShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(symbol); i++ {
+		h ^= uint32(symbol[i])
+		h *= 16777619
+	}
+	return &s.shards[h%storeShards]
 }
 
 // Update 更新单个交易对的行情
 func (s *Store) Update(symbol string, lastPrice, pricePercent float64, tradeCount int64, quoteVolume float64) {
-	s.mu.Lock()
-	s.tickers[symbol] = &Ticker{
+	t := &Ticker{
 		Symbol:       symbol,
 		LastPrice:    lastPrice,
 		PricePercent: pricePercent,
@@ -38,14 +59,18 @@ func (s *Store) Update(symbol string, lastPrice, pricePercent float64, tradeCoun
 		QuoteVolume:  quoteVolume,
 		UpdatedAt:    time.Now().UnixMilli(),
 	}
-	s.mu.Unlock()
+	sh := s.shard(symbol)
+	sh.mu.Lock()
+	sh.tickers[symbol] = t
+	sh.mu.Unlock()
 }
 
 // Get 获取单个交易对的行情
 func (s *Store) Get(symbol string) (*Ticker, bool) {
-	s.mu.RLock()
-	t, ok := s.tickers[symbol]
-	s.mu.RUnlock()
+	sh := s.shard(symbol)
+	sh.mu.RLock()
+	t, ok := sh.tickers[symbol]
+	sh.mu.RUnlock()
 	if !ok {
 		return nil, false
 	}
@@ -54,29 +79,28 @@ func (s *Store) Get(symbol string) (*Ticker, bool) {
 	return &copy, true
 }
 
-// GetAll 获取所有交易对的行情
+// GetAll 获取所有交易对的行情（逐个分片加锁收集，结果不是跨分片的原子快照）
 func (s *Store) GetAll() map[string]*Ticker {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	result := make(map[string]*Ticker, len(s.tickers))
-	for k, v := range s.tickers {
-		copy := *v
-		result[k] = &copy
+	result := make(map[string]*Ticker, s.Count())
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for k, v := range sh.tickers {
+			copy := *v
+			result[k] = &copy
+		}
+		sh.mu.RUnlock()
 	}
 	return result
 }
 
-// GetBySymbols 获取指定交易对的行情
+// GetBySymbols 获取指定交易对的行情（输入经 binance.NormalizeSymbol 规范化，结果以规范化后的交易对为键）
 func (s *Store) GetBySymbols(symbols []string) map[string]*Ticker {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	result := make(map[string]*Ticker, len(symbols))
 	for _, sym := range symbols {
-		if t, ok := s.tickers[sym]; ok {
-			copy := *t
-			result[sym] = &copy
+		sym = binance.NormalizeSymbol(sym)
+		if t, ok := s.Get(sym); ok {
+			result[sym] = t
 		}
 	}
 	return result
@@ -84,7 +108,12 @@ func (s *Store) GetBySymbols(symbols []string) map[string]*Ticker {
 
 // Count 返回存储的交易对数量
 func (s *Store) Count() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.tickers)
+	n := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		n += len(sh.tickers)
+		sh.mu.RUnlock()
+	}
+	return n
 }
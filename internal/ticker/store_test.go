@@ -0,0 +1,125 @@
+package ticker
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStore_GetBySymbolsNormalizes(t *testing.T) {
+	s := NewStore()
+	s.Update("BTCUSDT", 50000, 1.5, 100, 1e6)
+	s.Update("ETHUSDT", 3000, -0.5, 50, 5e5)
+
+	want := s.GetBySymbols([]string{"BTCUSDT", "ETHUSDT"})
+	for _, in := range [][]string{
+		{"btcusdt", "ethusdt"},
+		{" BtcUsdt ", "\tETHUSDT"},
+		{"btc-usdt", "eth/usdt"},
+	} {
+		got := s.GetBySymbols(in)
+		if len(got) != 2 {
+			t.Fatalf("GetBySymbols(%q) returned %d tickers, want 2", in, len(got))
+		}
+		for sym, tk := range want {
+			if got[sym] == nil || *got[sym] != *tk {
+				t.Errorf("GetBySymbols(%q)[%s] = %+v, want %+v", in, sym, got[sym], tk)
+			}
+		}
+	}
+
+	if got := s.GetBySymbols([]string{"", " ", "doge"}); len(got) != 0 {
+		t.Errorf("expected no tickers for unknown symbols, got %v", got)
+	}
+}
+
+func TestStore_ShardedGetAll(t *testing.T) {
+	s := NewStore()
+	for i := 0; i < 100; i++ {
+		s.Update(fmt.Sprintf("SYM%dUSDT", i), float64(i), 0, 0, 0)
+	}
+	s.Update("SYM1USDT", 42, 0, 0, 0)
+
+	all := s.GetAll()
+	if len(all) != 100 || s.Count() != 100 {
+		t.Fatalf("GetAll returned %d tickers, Count %d, want 100", len(all), s.Count())
+	}
+	if got := all["SYM1USDT"].LastPrice; got != 42 {
+		t.Errorf("SYM1USDT price = %v, want 42", got)
+	}
+}
+
+// singleMutexStore is the previous unsharded Store, kept as a benchmark baseline.
+type singleMutexStore struct {
+	mu      sync.RWMutex
+	tickers map[string]*Ticker
+}
+
+func (s *singleMutexStore) Update(symbol string, lastPrice, pricePercent float64, tradeCount int64, quoteVolume float64) {
+	s.mu.Lock()
+	s.tickers[symbol] = &Ticker{
+		Symbol:       symbol,
+		LastPrice:    lastPrice,
+		PricePercent: pricePercent,
+		TradeCount:   tradeCount,
+		QuoteVolume:  quoteVolume,
+		UpdatedAt:    time.Now().UnixMilli(),
+	}
+	s.mu.Unlock()
+}
+
+func (s *singleMutexStore) GetAll() map[string]*Ticker {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]*Ticker, len(s.tickers))
+	for k, v := range s.tickers {
+		copy := *v
+		result[k] = &copy
+	}
+	return result
+}
+
+var benchSymbols = func() []string {
+	out := make([]string, 500)
+	for i := range out {
+		out[i] = fmt.Sprintf("SYM%dUSDT", i)
+	}
+	return out
+}()
+
+// benchmarkParallelUpdates runs concurrent updates across 500 symbols with
+// an occasional GetAll, like the all-tickers stream plus API readers.
+func benchmarkParallelUpdates(b *testing.B, update func(string, float64), getAll func()) {
+	for _, sym := range benchSymbols {
+		update(sym, 1)
+	}
+	var worker int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(atomic.AddInt64(&worker, 1)) * 97
+		for pb.Next() {
+			i++
+			if i%1000 == 0 {
+				getAll()
+				continue
+			}
+			update(benchSymbols[i%len(benchSymbols)], float64(i))
+		}
+	})
+}
+
+func BenchmarkStore_ParallelUpdate_Sharded(b *testing.B) {
+	s := NewStore()
+	benchmarkParallelUpdates(b,
+		func(sym string, p float64) { s.Update(sym, p, 0, 0, 0) },
+		func() { _ = s.GetAll() })
+}
+
+func BenchmarkStore_ParallelUpdate_SingleMutex(b *testing.B) {
+	s := &singleMutexStore{tickers: make(map[string]*Ticker)}
+	benchmarkParallelUpdates(b,
+		func(sym string, p float64) { s.Update(sym, p, 0, 0, 0) },
+		func() { _ = s.GetAll() })
+}